@@ -4,9 +4,9 @@ import (
 	"trunecord/internal/discord"
 )
 
-// checkAudioSupport verifies if the binary was built with audio streaming support
+// checkAudioSupport verifies that the bundled Opus encoder works
 func checkAudioSupport() error {
-	// Try to create an encoder to test if CGO/audio support is available
+	// Try to create an encoder with whichever backend this build uses
 	encoder, err := discord.NewOpusEncoderTest()
 	if err != nil {
 		return err
@@ -5,18 +5,23 @@ import (
 	"io"
 	"log"
 	"net"
+	"net/http"
 	"os"
 	"os/exec"
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"strings"
 	"syscall"
 	"time"
 
+	"trunecord/internal/audio"
 	"trunecord/internal/auth"
+	"trunecord/internal/broadcast"
 	"trunecord/internal/config"
 	"trunecord/internal/constants"
 	"trunecord/internal/discord"
+	"trunecord/internal/source"
 	"trunecord/internal/web"
 	"trunecord/internal/websocket"
 )
@@ -25,6 +30,11 @@ type App struct {
 	config     *config.Config
 	streamer   *discord.Streamer
 	wsServer   *websocket.Server
+	sources    *source.Manager
+	files      *source.FileSource
+	tone       *source.ToneSource
+	streams    *broadcast.Server
+	settings   *config.Settings
 	authClient *auth.Client
 	userToken  string
 }
@@ -39,6 +49,7 @@ func (a *App) startServers() {
 	a.startWebSocketServer()
 	a.startAudioStreaming()
 	a.startWebServer()
+	a.startStreamServer()
 	// Browser auto-open is handled by web.Server
 }
 
@@ -53,15 +64,15 @@ func (a *App) startWebSocketServer() {
 }
 
 func (a *App) startAudioStreaming() {
-	// Connect WebSocket audio buffer to Discord streamer
+	// Connect the selected audio source to Discord streamer
 	go func() {
 		ticker := time.NewTicker(constants.WebSocketTickerInterval)
 		defer ticker.Stop()
 
 		for range ticker.C {
 			if a.streamer.IsConnected() && !a.streamer.IsStreaming() {
-				// Start streaming with WebSocket audio buffer
-				err := a.streamer.StartStreaming(a.wsServer.GetAudioChannel())
+				// Start streaming whichever source is selected
+				err := a.streamer.StartStreaming(a.sources.Frames())
 				if err != nil {
 					log.Printf("Failed to start streaming: %v", err)
 					continue
@@ -75,6 +86,11 @@ func (a *App) startAudioStreaming() {
 func (a *App) startWebServer() {
 	// Start web server for OAuth callback and web UI
 	webServer := web.NewServer(a.config.WebPort, a.authClient, a.streamer, a.wsServer, a.config)
+	webServer.SetSourceManager(a.sources)
+	webServer.SetFileQueue(a.files)
+	webServer.SetToneGenerator(a.tone)
+	webServer.SetSettings(a.settings)
+	webServer.SetStreamOutput(a.streams)
 	go func() {
 		if err := webServer.Start(); err != nil {
 			log.Fatalf("Web server error: %v", err)
@@ -82,6 +98,45 @@ func (a *App) startWebServer() {
 	}()
 }
 
+func (a *App) startStreamServer() {
+	// The web server only listens on localhost; listeners elsewhere get the
+	// streams, and nothing else, on their own address
+	if a.config.StreamAddress == "" {
+		return
+	}
+	go func() {
+		log.Printf("Serving listener streams on %s", a.config.StreamAddress)
+		if err := http.ListenAndServe(a.config.StreamAddress, a.streams.Handler()); err != nil {
+			log.Printf("Stream server error: %v", err)
+		}
+	}()
+}
+
+// volumeMenuStep is how far the menu bar's louder and quieter items move the
+// volume.
+const volumeMenuStep = 10
+
+// setVolume changes the output volume from the menu bar, clamped to the
+// valid range and saved for the connected guild like the web UI does.
+func (a *App) setVolume(percent int) {
+	if percent < 0 {
+		percent = 0
+	}
+	if percent > audio.MaxVolume {
+		percent = audio.MaxVolume
+	}
+	if err := a.streamer.SetVolume(percent); err != nil {
+		log.Printf("Failed to set volume: %v", err)
+		return
+	}
+	if guildID := a.streamer.GetGuildID(); guildID != "" {
+		err := a.settings.UpdateGuild(guildID, func(guild *config.GuildSettings) { guild.Volume = percent })
+		if err != nil {
+			log.Printf("Failed to save volume: %v", err)
+		}
+	}
+}
+
 func (a *App) printStatus() {
 	// Print status
 	fmt.Println("")
@@ -149,12 +204,99 @@ func main() {
 	fmt.Println("")
 	log.Printf("Starting %s...", constants.ApplicationName)
 
+	settingsPath := cfg.SettingsPath
+	if settingsPath == "" {
+		if settingsPath, err = config.DefaultSettingsPath(); err != nil {
+			log.Printf("Settings will not be saved: %v", err)
+		}
+	}
+	settings, err := config.LoadSettings(settingsPath)
+	if err != nil {
+		log.Printf("Failed to load settings, starting from defaults: %v", err)
+		settings = config.NewSettings(settingsPath)
+	}
+
+	recordingDir := cfg.RecordingDir
+	if recordingDir == "" {
+		if recordingDir, err = config.DefaultRecordingDir(); err != nil {
+			log.Printf("Recording is unavailable: %v", err)
+		}
+	}
+
+	mediaDir := cfg.MediaDir
+	if mediaDir == "" {
+		if mediaDir, err = config.DefaultMediaDir(); err != nil {
+			log.Printf("Queueing files is unavailable: %v", err)
+		}
+	}
+
 	// Initialize app (config already loaded)
 	app := &App{
 		config:     cfg,
 		authClient: auth.NewClient(cfg.AuthAPIURL),
 		streamer:   discord.NewStreamer(),
 		wsServer:   websocket.NewServer(),
+		sources:    source.NewManager(cfg.AudioChannels),
+		files:      source.NewFileSource(cfg.AudioChannels),
+		tone:       source.NewToneSource(cfg.AudioChannels),
+		streams:    broadcast.NewServer(cfg.AudioChannels),
+		settings:   settings,
+	}
+
+	// Stereo or mono output is chosen once for the whole pipeline
+	if err := app.streamer.SetChannels(cfg.AudioChannels); err != nil {
+		log.Fatalf("Failed to configure audio channels: %v", err)
+	}
+	if err := app.wsServer.SetOutputChannels(cfg.AudioChannels); err != nil {
+		log.Fatalf("Failed to configure audio channels: %v", err)
+	}
+	if err := app.streamer.SetJitterConfig(cfg.JitterConfig()); err != nil {
+		log.Fatalf("Failed to configure jitter buffer: %v", err)
+	}
+	if err := app.wsServer.SetJitterConfig(cfg.JitterConfig()); err != nil {
+		log.Fatalf("Failed to configure jitter buffer: %v", err)
+	}
+	if err := app.streamer.SetEncoderOptions(cfg.EncoderOptions()); err != nil {
+		log.Fatalf("Failed to configure Opus encoder: %v", err)
+	}
+	app.streamer.SetPassthroughChannel(app.sources.Packets())
+	app.wsServer.SetMixClients(cfg.MixClients)
+	app.wsServer.SetPauseListener(func(paused bool) {
+		if paused {
+			app.streamer.Pause()
+		} else {
+			app.streamer.Resume()
+		}
+	})
+	if err := app.streamer.SetLoudness(cfg.Normalize, cfg.LoudnessTarget); err != nil {
+		log.Fatalf("Failed to configure loudness normalization: %v", err)
+	}
+	if err := app.streamer.SetSilenceHold(cfg.SilenceHold); err != nil {
+		log.Fatalf("Failed to configure silence detection: %v", err)
+	}
+	if err := app.streamer.SetFilters(cfg.Filters); err != nil {
+		log.Fatalf("Failed to configure DSP filters: %v", err)
+	}
+	app.streamer.SetRecordingDir(recordingDir)
+	app.streamer.SetBroadcast(app.streams)
+	app.files.SetMediaDir(mediaDir)
+	// Recordings get a chapter for each queued file
+	app.files.SetTrackListener(func(item source.QueueItem) {
+		app.streamer.MarkTrack(strings.TrimSuffix(item.Name, filepath.Ext(item.Name)))
+	})
+
+	app.sources.Register(app.wsServer.Source())
+	app.sources.Register(app.files)
+	app.sources.Register(app.tone)
+	if cfg.ProcessCommand != "" {
+		process, err := source.NewProcessSource(cfg.ProcessCommand, cfg.ProcessFormat)
+		if err != nil {
+			log.Fatalf("Invalid PROCESS_COMMAND: %v", err)
+		}
+		app.sources.Register(process)
+	}
+	if err := app.sources.Select(cfg.AudioSource); err != nil {
+		log.Fatalf("Failed to select audio source: %v", err)
 	}
 
 	// Run the application
@@ -12,6 +12,7 @@ import (
 	"time"
 
 	"github.com/getlantern/systray"
+	"trunecord/internal/audio"
 	"trunecord/internal/icon"
 )
 
@@ -42,6 +43,14 @@ func onReady(app *App) {
 	
 	systray.AddSeparator()
 	
+	// Volume controls
+	mVolume := systray.AddMenuItem(fmt.Sprintf("Volume: %d%%", app.streamer.GetVolume()), "Volume of the audio sent to Discord")
+	mVolumeUp := mVolume.AddSubMenuItem("Louder", "Raise the volume")
+	mVolumeDown := mVolume.AddSubMenuItem("Quieter", "Lower the volume")
+	mVolumeReset := mVolume.AddSubMenuItem("Reset to 100%", "Restore the original volume")
+	
+	systray.AddSeparator()
+	
 	// Action items
 	mOpenWeb := systray.AddMenuItem("Open Web Interface", "Open the web interface")
 	mViewLogs := systray.AddMenuItem("View Logs", "View application logs")
@@ -52,6 +61,12 @@ func onReady(app *App) {
 	go func() {
 		for {
 			select {
+			case <-mVolumeUp.ClickedCh:
+				app.setVolume(app.streamer.GetVolume() + volumeMenuStep)
+			case <-mVolumeDown.ClickedCh:
+				app.setVolume(app.streamer.GetVolume() - volumeMenuStep)
+			case <-mVolumeReset.ClickedCh:
+				app.setVolume(audio.DefaultVolume)
 			case <-mOpenWeb.ClickedCh:
 				openBrowser(fmt.Sprintf("http://localhost:%s", app.config.WebPort))
 			case <-mViewLogs.ClickedCh:
@@ -75,6 +90,7 @@ func onReady(app *App) {
 		for {
 			select {
 			case <-ticker.C:
+				mVolume.SetTitle(fmt.Sprintf("Volume: %d%%", app.streamer.GetVolume()))
 				if app.streamer.IsConnected() {
 					mStatus.SetTitle("✓ Connected to Discord")
 					mStreamStatus.Show()
@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/getlantern/systray"
+	"trunecord/internal/audio"
 	"trunecord/internal/icon"
 )
 
@@ -43,6 +44,14 @@ func onReady(app *App) {
 	
 	systray.AddSeparator()
 	
+	// Volume controls
+	mVolume := systray.AddMenuItem(fmt.Sprintf("Volume: %d%%", app.streamer.GetVolume()), "Volume of the audio sent to Discord")
+	mVolumeUp := mVolume.AddSubMenuItem("Louder", "Raise the volume")
+	mVolumeDown := mVolume.AddSubMenuItem("Quieter", "Lower the volume")
+	mVolumeReset := mVolume.AddSubMenuItem("Reset to 100%", "Restore the original volume")
+	
+	systray.AddSeparator()
+	
 	// Action items
 	mOpenWeb := systray.AddMenuItem("Open Web Interface", "Open the web interface")
 	mViewLogs := systray.AddMenuItem("View Logs", "View application logs")
@@ -53,6 +62,12 @@ func onReady(app *App) {
 	go func() {
 		for {
 			select {
+			case <-mVolumeUp.ClickedCh:
+				app.setVolume(app.streamer.GetVolume() + volumeMenuStep)
+			case <-mVolumeDown.ClickedCh:
+				app.setVolume(app.streamer.GetVolume() - volumeMenuStep)
+			case <-mVolumeReset.ClickedCh:
+				app.setVolume(audio.DefaultVolume)
 			case <-mOpenWeb.ClickedCh:
 				openBrowser(fmt.Sprintf("http://localhost:%s", app.config.WebPort))
 			case <-mViewLogs.ClickedCh:
@@ -77,6 +92,7 @@ func onReady(app *App) {
 	// Update status periodically
 	go func() {
 		for {
+			mVolume.SetTitle(fmt.Sprintf("Volume: %d%%", app.streamer.GetVolume()))
 			if app.streamer.IsConnected() {
 				mStatus.SetTitle("✓ Connected to Discord")
 				mStreamStatus.Show()
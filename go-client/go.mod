@@ -1,12 +1,14 @@
 module trunecord
 
-go 1.21
+go 1.22.0
 
 require (
 	github.com/bwmarrin/discordgo v0.27.1
 	github.com/getlantern/systray v1.2.2
 	github.com/gorilla/websocket v1.5.0
-	layeh.com/gopus v0.0.0-20210501142526-1ee02d434e32
+	github.com/jfreymuth/oggvorbis v1.0.5
+	github.com/jj11hh/opus v1.0.1
+	github.com/mewkiz/flac v1.0.12
 )
 
 require (
@@ -17,7 +19,11 @@ require (
 	github.com/getlantern/hidden v0.0.0-20190325191715-f02dbb02be55 // indirect
 	github.com/getlantern/ops v0.0.0-20190325191751-d70cb0d6f85f // indirect
 	github.com/go-stack/stack v1.8.0 // indirect
+	github.com/icza/bitio v1.1.0 // indirect
+	github.com/jfreymuth/vorbis v1.0.2 // indirect
+	github.com/mewkiz/pkg v0.0.0-20230226050401-4010bf0fec14 // indirect
 	github.com/oxtoacart/bpool v0.0.0-20190530202638-03653db5a59c // indirect
-	golang.org/x/crypto v0.0.0-20210421170649-83a5a9bb288b // indirect
+	github.com/tetratelabs/wazero v1.9.0 // indirect
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519 // indirect
 	golang.org/x/sys v0.15.0 // indirect
 )
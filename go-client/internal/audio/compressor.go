@@ -0,0 +1,93 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"trunecord/internal/constants"
+)
+
+func init() {
+	RegisterFilter(FilterType{
+		Name: "compressor",
+		Defaults: map[string]float64{
+			"threshold": -18, // dBFS
+			"ratio":     4,
+			"attack":    10,  // ms
+			"release":   150, // ms
+			"makeup":    0,   // dB
+		},
+		New: newCompressor,
+	})
+}
+
+// compressor reduces the level above the threshold by the ratio. Channels
+// share one detector so the stereo image does not shift.
+type compressor struct {
+	threshold float64
+	slope     float64
+	attack    float64
+	release   float64
+	makeup    float64
+	reduction float64 // current gain reduction in dB
+}
+
+func newCompressor(params map[string]float64) (Filter, error) {
+	switch {
+	case !(params["threshold"] >= -60 && params["threshold"] <= 0):
+		return nil, fmt.Errorf("threshold must be between -60 and 0 dBFS: %g", params["threshold"])
+	case !(params["ratio"] >= 1 && params["ratio"] <= 20):
+		return nil, fmt.Errorf("ratio must be between 1 and 20: %g", params["ratio"])
+	case !(params["attack"] >= 0.1 && params["attack"] <= 200):
+		return nil, fmt.Errorf("attack must be between 0.1 and 200 ms: %g", params["attack"])
+	case !(params["release"] >= 10 && params["release"] <= 2000):
+		return nil, fmt.Errorf("release must be between 10 and 2000 ms: %g", params["release"])
+	case !(params["makeup"] >= 0 && params["makeup"] <= 24):
+		return nil, fmt.Errorf("makeup must be between 0 and 24 dB: %g", params["makeup"])
+	}
+
+	// One-pole smoothing coefficients per sample frame
+	coefficient := func(ms float64) float64 {
+		return math.Exp(-1 / (ms / 1000 * constants.SampleRate))
+	}
+	return &compressor{
+		threshold: params["threshold"],
+		slope:     1 - 1/params["ratio"],
+		attack:    coefficient(params["attack"]),
+		release:   coefficient(params["release"]),
+		makeup:    params["makeup"],
+	}, nil
+}
+
+func (c *compressor) Process(pcm []int16, channels int) {
+	for i := 0; i+channels <= len(pcm); i += channels {
+		peak := 0.0
+		for _, sample := range pcm[i : i+channels] {
+			peak = math.Max(peak, math.Abs(float64(sample)/32768))
+		}
+
+		target := 0.0
+		if peak > 0 {
+			if over := 20*math.Log10(peak) - c.threshold; over > 0 {
+				target = over * c.slope
+			}
+		}
+		if target > c.reduction {
+			c.reduction = target + (c.reduction-target)*c.attack
+		} else {
+			c.reduction = target + (c.reduction-target)*c.release
+		}
+
+		gain := dbToGain(c.makeup - c.reduction)
+		if gain == 1 {
+			continue
+		}
+		for j := i; j < i+channels; j++ {
+			pcm[j] = FloatToInt16(float32(float64(pcm[j]) / 32768 * gain))
+		}
+	}
+}
+
+func (c *compressor) Reset() {
+	c.reduction = 0
+}
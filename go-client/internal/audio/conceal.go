@@ -0,0 +1,41 @@
+package audio
+
+import "encoding/binary"
+
+// ConcealFadeChunks is how many repeated chunks it takes to fade to silence.
+const ConcealFadeChunks = 3
+
+// IngestStats counts how audio chunks arrived from a source.
+type IngestStats struct {
+	Received  uint64
+	Lost      uint64
+	Late      uint64
+	Concealed uint64
+}
+
+// ConcealLoss synthesizes count replacement chunks for lost 16-bit PCM by
+// repeating the last good chunk while fading it out over
+// ConcealFadeChunks chunks. Anything past the fade is silence.
+func ConcealLoss(last []byte, channels, count int) [][]byte {
+	frameBytes := channels * 2
+	frames := len(last) / frameBytes
+	total := float64(ConcealFadeChunks * frames)
+
+	chunks := make([][]byte, count)
+	for k := range chunks {
+		chunk := make([]byte, len(last))
+		chunks[k] = chunk
+		if k >= ConcealFadeChunks || frames == 0 {
+			continue
+		}
+		for i := 0; i < frames; i++ {
+			gain := 1 - float64(k*frames+i)/total
+			for ch := 0; ch < channels; ch++ {
+				offset := i*frameBytes + ch*2
+				sample := float64(int16(binary.LittleEndian.Uint16(last[offset:])))
+				binary.LittleEndian.PutUint16(chunk[offset:], uint16(int16(sample*gain)))
+			}
+		}
+	}
+	return chunks
+}
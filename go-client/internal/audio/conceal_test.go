@@ -0,0 +1,39 @@
+package audio
+
+import "testing"
+
+func TestConcealLossFadesToSilence(t *testing.T) {
+	last := Int16ToBytes([]int16{1000, -1000, 1000, -1000})
+
+	chunks := ConcealLoss(last, 2, 5)
+	if len(chunks) != 5 {
+		t.Fatalf("ConcealLoss() returned %d chunks, want 5", len(chunks))
+	}
+
+	first := BytesToInt16(chunks[0])
+	if first[0] != 1000 || first[1] != -1000 {
+		t.Errorf("first concealed frame = %v, want the last chunk repeated", first[:2])
+	}
+
+	prev := int16(1000)
+	for k := 0; k < ConcealFadeChunks; k++ {
+		samples := BytesToInt16(chunks[k])
+		for i := 0; i < len(samples); i += 2 {
+			if samples[i] > prev {
+				t.Fatalf("chunk %d frame %d = %d, should not be louder than %d", k, i/2, samples[i], prev)
+			}
+			if samples[i] != -samples[i+1] {
+				t.Fatalf("chunk %d frame %d channels differ: %v", k, i/2, samples[i:i+2])
+			}
+			prev = samples[i]
+		}
+	}
+
+	for k := ConcealFadeChunks; k < len(chunks); k++ {
+		for _, sample := range BytesToInt16(chunks[k]) {
+			if sample != 0 {
+				t.Fatalf("chunk %d should be silent after the fade", k)
+			}
+		}
+	}
+}
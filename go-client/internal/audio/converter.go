@@ -0,0 +1,127 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+)
+
+// Converter turns PCM in an arbitrary client format into another format,
+// typically PipelineFormat. A converter is stateful and must be used for a
+// single continuous stream.
+type Converter struct {
+	in        Format
+	out       Format
+	resampler *Resampler
+}
+
+func NewConverter(in, out Format) (*Converter, error) {
+	if err := in.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid input format: %v", err)
+	}
+	if err := out.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid output format: %v", err)
+	}
+
+	c := &Converter{in: in, out: out}
+	if in.SampleRate != out.SampleRate {
+		c.resampler = NewResampler(out.Channels, in.SampleRate, out.SampleRate)
+	}
+	return c, nil
+}
+
+func (c *Converter) Input() Format {
+	return c.in
+}
+
+func (c *Converter) Output() Format {
+	return c.out
+}
+
+// Convert converts one chunk. Trailing bytes that do not form a whole
+// sample frame are dropped.
+func (c *Converter) Convert(data []byte) ([]byte, error) {
+	if c.in == c.out {
+		return data, nil
+	}
+
+	// Channel-only changes of 16-bit PCM stay in the integer domain
+	if c.resampler == nil && c.in.Encoding == EncodingInt16 && c.out.Encoding == EncodingInt16 {
+		return ConvertChannels(data, c.in.Channels, c.out.Channels)
+	}
+
+	samples := DecodeFloat32(data, c.in.Encoding)
+	samples = c.remix(samples)
+	if c.resampler != nil {
+		samples = c.resampler.Process(samples)
+	}
+	return EncodeFloat32(samples, c.out.Encoding), nil
+}
+
+func (c *Converter) remix(samples []float32) []float32 {
+	from, to := c.in.Channels, c.out.Channels
+	if from == to {
+		return samples
+	}
+
+	frames := len(samples) / from
+	out := make([]float32, frames*to)
+	for i := 0; i < frames; i++ {
+		if from == 1 {
+			for ch := 0; ch < to; ch++ {
+				out[i*to+ch] = samples[i]
+			}
+			continue
+		}
+		var sum float32
+		for ch := 0; ch < from; ch++ {
+			sum += samples[i*from+ch]
+		}
+		out[i] = sum / float32(from)
+	}
+	return out
+}
+
+// DecodeFloat32 converts PCM bytes to interleaved samples in [-1, 1].
+func DecodeFloat32(data []byte, encoding Encoding) []float32 {
+	size := encoding.BytesPerSample()
+	samples := make([]float32, len(data)/size)
+	for i := range samples {
+		if encoding == EncodingFloat32 {
+			v := math.Float32frombits(binary.LittleEndian.Uint32(data[i*4:]))
+			if v != v { // NaN
+				v = 0
+			}
+			samples[i] = v
+		} else {
+			samples[i] = float32(int16(binary.LittleEndian.Uint16(data[i*2:]))) / 32768
+		}
+	}
+	return samples
+}
+
+// EncodeFloat32 converts samples in [-1, 1] to PCM bytes, clipping values
+// outside that range.
+func EncodeFloat32(samples []float32, encoding Encoding) []byte {
+	data := make([]byte, len(samples)*encoding.BytesPerSample())
+	for i, v := range samples {
+		if encoding == EncodingFloat32 {
+			binary.LittleEndian.PutUint32(data[i*4:], math.Float32bits(v))
+			continue
+		}
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(FloatToInt16(v)))
+	}
+	return data
+}
+
+// FloatToInt16 scales a sample in [-1, 1] to 16 bits with clipping.
+func FloatToInt16(v float32) int16 {
+	scaled := math.Round(float64(v) * 32768)
+	if scaled > math.MaxInt16 {
+		return math.MaxInt16
+	}
+	if scaled < math.MinInt16 {
+		return math.MinInt16
+	}
+	return int16(scaled)
+}
@@ -0,0 +1,140 @@
+package audio
+
+import (
+	"math"
+	"testing"
+)
+
+func TestParseEncoding(t *testing.T) {
+	tests := []struct {
+		name    string
+		want    Encoding
+		wantErr bool
+	}{
+		{"", EncodingInt16, false},
+		{"s16", EncodingInt16, false},
+		{"int16", EncodingInt16, false},
+		{"f32", EncodingFloat32, false},
+		{"float32", EncodingFloat32, false},
+		{"u8", EncodingInt16, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseEncoding(tt.name)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseEncoding(%q) error = %v, wantErr %v", tt.name, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseEncoding(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFormatValidate(t *testing.T) {
+	if err := PipelineFormat(2).Validate(); err != nil {
+		t.Errorf("PipelineFormat(2).Validate() error = %v", err)
+	}
+	if err := (Format{SampleRate: 1000, Channels: 1}).Validate(); err == nil {
+		t.Error("Validate() should reject sample rate 1000")
+	}
+	if err := (Format{SampleRate: 48000, Channels: 3}).Validate(); err == nil {
+		t.Error("Validate() should reject 3 channels")
+	}
+}
+
+func TestConverterFloatToInt16(t *testing.T) {
+	in := Format{SampleRate: 48000, Channels: 1, Encoding: EncodingFloat32}
+	c, err := NewConverter(in, PipelineFormat(1))
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v", err)
+	}
+
+	out, err := c.Convert(EncodeFloat32([]float32{0, 0.5, -1, 2}, EncodingFloat32))
+	if err != nil {
+		t.Fatalf("Convert() error = %v", err)
+	}
+
+	got := BytesToInt16(out)
+	want := []int16{0, 16384, -32768, 32767}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample %d = %d, want %d", i, got[i], want[i])
+		}
+	}
+}
+
+func TestConverterResamplesToPipelineRate(t *testing.T) {
+	in := Format{SampleRate: 44100, Channels: 1, Encoding: EncodingFloat32}
+	c, err := NewConverter(in, PipelineFormat(2))
+	if err != nil {
+		t.Fatalf("NewConverter() error = %v", err)
+	}
+
+	// One second of a 441Hz tone delivered in 10ms chunks
+	const freq = 441.0
+	var total []int16
+	for chunk := 0; chunk < 100; chunk++ {
+		samples := make([]float32, 441)
+		for i := range samples {
+			n := chunk*441 + i
+			samples[i] = float32(0.5 * math.Sin(2*math.Pi*freq*float64(n)/44100))
+		}
+		out, err := c.Convert(EncodeFloat32(samples, EncodingFloat32))
+		if err != nil {
+			t.Fatalf("Convert() error = %v", err)
+		}
+		total = append(total, BytesToInt16(out)...)
+	}
+
+	frames := len(total) / 2
+	if frames < 47990 || frames > 48010 {
+		t.Fatalf("resampled frame count = %d, want ~48000", frames)
+	}
+
+	// Count rising zero crossings on the left channel; pitch must be preserved
+	crossings := 0
+	for i := 1; i < frames; i++ {
+		if total[(i-1)*2] < 0 && total[i*2] >= 0 {
+			crossings++
+		}
+	}
+	if crossings < 439 || crossings > 442 {
+		t.Errorf("zero crossings = %d, want ~441", crossings)
+	}
+
+	// Both channels carry the same upmixed signal
+	for i := 0; i < frames; i++ {
+		if total[i*2] != total[i*2+1] {
+			t.Fatalf("frame %d: left %d != right %d", i, total[i*2], total[i*2+1])
+		}
+	}
+}
+
+func TestResamplerChunkingIsSeamless(t *testing.T) {
+	input := make([]float32, 1000)
+	for i := range input {
+		input[i] = float32(math.Sin(float64(i) / 10))
+	}
+
+	whole := NewResampler(1, 44100, 48000).Process(input)
+
+	chunked := NewResampler(1, 44100, 48000)
+	var pieces []float32
+	for i := 0; i < len(input); i += 137 {
+		end := i + 137
+		if end > len(input) {
+			end = len(input)
+		}
+		pieces = append(pieces, chunked.Process(input[i:end])...)
+	}
+
+	if len(pieces) != len(whole) {
+		t.Fatalf("chunked length = %d, want %d", len(pieces), len(whole))
+	}
+	for i := range whole {
+		if math.Abs(float64(pieces[i]-whole[i])) > 1e-5 {
+			t.Fatalf("sample %d = %f, want %f", i, pieces[i], whole[i])
+		}
+	}
+}
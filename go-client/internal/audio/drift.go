@@ -0,0 +1,119 @@
+package audio
+
+import (
+	"sync"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+const (
+	// driftWarmup is how long arrivals are measured before corrections start.
+	driftWarmup = 30 * time.Second
+	// driftResetGap restarts the measurement when the source pauses.
+	driftResetGap = time.Second
+	// maxDriftCorrectionPPM keeps pitch changes below ~2 cents.
+	maxDriftCorrectionPPM = 1000
+	// depthGainPPM pulls the buffer back to its target: 10ms of excess
+	// latency speeds playback up by 100ppm.
+	depthGainPPM = 10000
+	// depthSmoothing averages the buffer depth over roughly two seconds.
+	depthSmoothing = 0.01
+)
+
+// DriftStats reports the estimated clock drift between the source and the
+// 20ms playback ticker, and the correction currently applied.
+type DriftStats struct {
+	DriftPPM      float64
+	CorrectionPPM float64
+}
+
+// DriftCompensator estimates how much faster or slower a source delivers
+// samples than real time and micro-resamples its audio to match, so the
+// jitter buffer neither drains nor grows over long sessions.
+type DriftCompensator struct {
+	mu            sync.Mutex
+	channels      int
+	resampler     *Resampler
+	start         time.Time
+	lastArrival   time.Time
+	arrived       int64
+	driftPPM      float64
+	depth         float64
+	correctionPPM float64
+}
+
+// NewDriftCompensator creates a compensator for 48kHz 16-bit PCM.
+func NewDriftCompensator(channels int) *DriftCompensator {
+	return &DriftCompensator{
+		channels:  channels,
+		resampler: NewResampler(channels, constants.SampleRate, constants.SampleRate),
+	}
+}
+
+// Process records the arrival of a chunk and returns it resampled by the
+// current correction.
+func (d *DriftCompensator) Process(pcm []byte, now time.Time) []byte {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	frames := int64(len(pcm) / (PipelineFormat(d.channels).FrameBytes()))
+	if d.start.IsZero() || now.Sub(d.lastArrival) > driftResetGap {
+		// The first chunk only marks the start of the measurement window
+		d.start = now
+		d.arrived = 0
+		d.correctionPPM = 0
+	} else {
+		d.arrived += frames
+	}
+	d.lastArrival = now
+
+	if elapsed := now.Sub(d.start); elapsed >= driftWarmup {
+		rate := float64(d.arrived) / elapsed.Seconds()
+		d.driftPPM = (rate/constants.SampleRate - 1) * 1e6
+	}
+
+	if d.correctionPPM == 0 {
+		// Keep the resampler's history current so a later correction does
+		// not interpolate against audio from before the bypass
+		if frameBytes := PipelineFormat(d.channels).FrameBytes(); len(pcm) >= frameBytes {
+			d.resampler.Continue(DecodeFloat32(pcm[len(pcm)-frameBytes:], EncodingInt16))
+		}
+		return pcm
+	}
+
+	d.resampler.SetRatio(1 + d.correctionPPM/1e6)
+	samples := d.resampler.Process(DecodeFloat32(pcm, EncodingInt16))
+	return EncodeFloat32(samples, EncodingInt16)
+}
+
+// Update feeds the jitter buffer depth after each played frame and
+// recomputes the correction.
+func (d *DriftCompensator) Update(depth, target time.Duration) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.depth += (depth.Seconds() - d.depth) * depthSmoothing
+
+	if d.start.IsZero() || d.lastArrival.Sub(d.start) < driftWarmup {
+		d.correctionPPM = 0
+		return
+	}
+
+	correction := d.driftPPM + (d.depth-target.Seconds())*depthGainPPM
+	if correction > maxDriftCorrectionPPM {
+		correction = maxDriftCorrectionPPM
+	} else if correction < -maxDriftCorrectionPPM {
+		correction = -maxDriftCorrectionPPM
+	}
+	d.correctionPPM = correction
+}
+
+func (d *DriftCompensator) Stats() DriftStats {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return DriftStats{
+		DriftPPM:      d.driftPPM,
+		CorrectionPPM: d.correctionPPM,
+	}
+}
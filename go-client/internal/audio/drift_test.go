@@ -0,0 +1,104 @@
+package audio
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+// feedDrifting delivers 10ms chunks from a source whose clock runs ppm
+// faster than real time and returns how many frames came out.
+func feedDrifting(d *DriftCompensator, start time.Time, seconds int, ppm float64, depth, target time.Duration) (time.Time, int) {
+	chunk := make([]byte, 480*2)
+	interval := time.Duration(float64(10*time.Millisecond) / (1 + ppm/1e6))
+	now := start
+	out := 0
+	for i := 0; i < seconds*100; i++ {
+		out += len(d.Process(chunk, now)) / 2
+		d.Update(depth, target)
+		now = now.Add(interval)
+	}
+	return now, out
+}
+
+func TestDriftCompensatorWaitsForWarmup(t *testing.T) {
+	d := NewDriftCompensator(1)
+	_, out := feedDrifting(d, time.Unix(0, 0), 10, 500, 60*time.Millisecond, 60*time.Millisecond)
+
+	if out != 10*100*480 {
+		t.Errorf("output frames during warmup = %d, want passthrough %d", out, 10*100*480)
+	}
+	if stats := d.Stats(); stats.CorrectionPPM != 0 {
+		t.Errorf("CorrectionPPM during warmup = %f, want 0", stats.CorrectionPPM)
+	}
+}
+
+func TestDriftCompensatorEstimatesAndCorrectsDrift(t *testing.T) {
+	d := NewDriftCompensator(1)
+	target := 60 * time.Millisecond
+
+	now, _ := feedDrifting(d, time.Unix(0, 0), 40, 500, target, target)
+
+	stats := d.Stats()
+	if math.Abs(stats.DriftPPM-500) > 20 {
+		t.Fatalf("DriftPPM = %f, want ~500", stats.DriftPPM)
+	}
+	if math.Abs(stats.CorrectionPPM-500) > 20 {
+		t.Fatalf("CorrectionPPM = %f, want ~500", stats.CorrectionPPM)
+	}
+
+	// With the correction applied, the 480000 frames a fast source delivers in
+	// 9.995s of wall time come out as 9.995s of audio
+	_, out := feedDrifting(d, now, 10, 500, target, target)
+	if want := 479760; math.Abs(float64(out-want)) > 48 {
+		t.Errorf("corrected output frames = %d, want ~%d", out, want)
+	}
+}
+
+func TestDriftCompensatorPullsDepthTowardTarget(t *testing.T) {
+	d := NewDriftCompensator(1)
+
+	// No clock drift, but the buffer sits 20ms above target
+	feedDrifting(d, time.Unix(0, 0), 40, 0, 80*time.Millisecond, 60*time.Millisecond)
+
+	if stats := d.Stats(); stats.CorrectionPPM < 150 || stats.CorrectionPPM > 250 {
+		t.Errorf("CorrectionPPM = %f, want ~200 to drain the excess", stats.CorrectionPPM)
+	}
+}
+
+func TestDriftCompensatorResetsAfterPause(t *testing.T) {
+	d := NewDriftCompensator(1)
+	now, _ := feedDrifting(d, time.Unix(0, 0), 40, 500, 60*time.Millisecond, 60*time.Millisecond)
+
+	// A long pause restarts the measurement and disables correction
+	d.Process(make([]byte, 960), now.Add(5*time.Second))
+	d.Update(60*time.Millisecond, 60*time.Millisecond)
+
+	if stats := d.Stats(); stats.CorrectionPPM != 0 {
+		t.Errorf("CorrectionPPM after pause = %f, want 0", stats.CorrectionPPM)
+	}
+}
+
+func TestDriftCompensatorResumesWithoutClick(t *testing.T) {
+	d := NewDriftCompensator(1)
+	now, _ := feedDrifting(d, time.Unix(0, 0), 40, 500, 60*time.Millisecond, 60*time.Millisecond)
+
+	// The pause bypasses the resampler for a chunk of loud audio, and the
+	// correction starts again when the next chunk arrives
+	loud := make([]byte, 960)
+	for i := 0; i < len(loud); i += 2 {
+		loud[i], loud[i+1] = 0x00, 0x40
+	}
+	now = now.Add(5 * time.Second)
+	if out := d.Process(loud, now); string(out) != string(loud) {
+		t.Fatal("audio should pass through untouched while correction is off")
+	}
+	d.correctionPPM = 500
+
+	out := BytesToInt16(d.Process(loud, now.Add(10*time.Millisecond)))
+	for i, sample := range out {
+		if sample < 0x4000-1 {
+			t.Fatalf("sample %d = %d, want the loud level continued, not the audio before the pause", i, sample)
+		}
+	}
+}
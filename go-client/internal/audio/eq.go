@@ -0,0 +1,112 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+
+	"trunecord/internal/constants"
+)
+
+func init() {
+	RegisterFilter(FilterType{
+		Name:     "highpass",
+		Defaults: map[string]float64{"freq": 80, "q": math.Sqrt2 / 2},
+		New: func(params map[string]float64) (Filter, error) {
+			if err := checkBiquadParams(params); err != nil {
+				return nil, err
+			}
+			return newBiquadFilter(highPass(params["freq"], params["q"])), nil
+		},
+	})
+	RegisterFilter(FilterType{
+		Name:     "eq",
+		Defaults: map[string]float64{"freq": 1000, "gain": 0, "q": 1},
+		New: func(params map[string]float64) (Filter, error) {
+			if err := checkBiquadParams(params); err != nil {
+				return nil, err
+			}
+			if gain := params["gain"]; gain < -24 || gain > 24 {
+				return nil, fmt.Errorf("gain must be between -24 and 24 dB: %g", gain)
+			}
+			return newBiquadFilter(peaking(params["freq"], params["gain"], params["q"])), nil
+		},
+	})
+}
+
+func checkBiquadParams(params map[string]float64) error {
+	if freq := params["freq"]; !(freq >= 10 && freq <= 20000) {
+		return fmt.Errorf("freq must be between 10 and 20000 Hz: %g", freq)
+	}
+	if q := params["q"]; !(q >= 0.1 && q <= 20) {
+		return fmt.Errorf("q must be between 0.1 and 20: %g", q)
+	}
+	return nil
+}
+
+// biquad is a second order IIR filter in direct form I.
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	x1, x2, y1, y2     float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.b1*f.x1 + f.b2*f.x2 - f.a1*f.y1 - f.a2*f.y2
+	f.x2, f.x1 = f.x1, x
+	f.y2, f.y1 = f.y1, y
+	return y
+}
+
+// highPass and peaking follow the Audio EQ Cookbook, normalized so a0 is 1.
+func highPass(freq, q float64) biquad {
+	w0 := 2 * math.Pi * freq / constants.SampleRate
+	cos, alpha := math.Cos(w0), math.Sin(w0)/(2*q)
+	a0 := 1 + alpha
+	return biquad{
+		b0: (1 + cos) / 2 / a0,
+		b1: -(1 + cos) / a0,
+		b2: (1 + cos) / 2 / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha) / a0,
+	}
+}
+
+func peaking(freq, gainDB, q float64) biquad {
+	w0 := 2 * math.Pi * freq / constants.SampleRate
+	cos, alpha := math.Cos(w0), math.Sin(w0)/(2*q)
+	a := math.Pow(10, gainDB/40)
+	a0 := 1 + alpha/a
+	return biquad{
+		b0: (1 + alpha*a) / a0,
+		b1: -2 * cos / a0,
+		b2: (1 - alpha*a) / a0,
+		a1: -2 * cos / a0,
+		a2: (1 - alpha/a) / a0,
+	}
+}
+
+// biquadFilter runs a biquad over each channel independently.
+type biquadFilter struct {
+	coefficients biquad
+	channels     []biquad
+}
+
+func newBiquadFilter(coefficients biquad) *biquadFilter {
+	return &biquadFilter{coefficients: coefficients}
+}
+
+func (f *biquadFilter) Process(pcm []int16, channels int) {
+	if len(f.channels) != channels {
+		f.channels = make([]biquad, channels)
+		f.Reset()
+	}
+	for i := range pcm {
+		x := float64(pcm[i]) / 32768
+		pcm[i] = FloatToInt16(float32(f.channels[i%channels].process(x)))
+	}
+}
+
+func (f *biquadFilter) Reset() {
+	for i := range f.channels {
+		f.channels[i] = f.coefficients
+	}
+}
@@ -0,0 +1,12 @@
+package audio
+
+// FadeIn ramps a frame of interleaved PCM up from silence in place, so audio
+// starting mid-waveform does not click.
+func FadeIn(pcm []int16, channels int) {
+	applyGain(pcm, channels, 0, 1)
+}
+
+// FadeOut ramps a frame of interleaved PCM down to silence in place.
+func FadeOut(pcm []int16, channels int) {
+	applyGain(pcm, channels, 1, 0)
+}
@@ -0,0 +1,20 @@
+package audio
+
+import "testing"
+
+func TestFadeInAndOut(t *testing.T) {
+	pcm := constantFrame(10000, 960)
+	FadeIn(pcm, 2)
+	if pcm[0] > 100 || pcm[959] != 10000 {
+		t.Errorf("FadeIn() starts at %d and ends at %d, want a ramp from silence to 10000", pcm[0], pcm[959])
+	}
+	if pcm[0] != pcm[1] {
+		t.Errorf("FadeIn() gave the channels of one frame %d and %d, want them ramped together", pcm[0], pcm[1])
+	}
+
+	pcm = constantFrame(10000, 960)
+	FadeOut(pcm, 2)
+	if pcm[0] < 9900 || pcm[959] != 0 {
+		t.Errorf("FadeOut() starts at %d and ends at %d, want a ramp from 10000 to silence", pcm[0], pcm[959])
+	}
+}
@@ -0,0 +1,183 @@
+package audio
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Filter is one stage of the DSP chain. Process works on a frame of
+// interleaved PCM in place and is called with the same channel count until
+// Reset.
+type Filter interface {
+	Process(pcm []int16, channels int)
+	Reset()
+}
+
+// FilterSpec describes a filter by its registered type and parameters, as
+// it appears in configuration and the web API.
+type FilterSpec struct {
+	Type   string             `json:"type"`
+	Params map[string]float64 `json:"params,omitempty"`
+}
+
+// FilterType is a kind of filter the chain can build. Defaults lists every
+// parameter the type accepts.
+type FilterType struct {
+	Name     string
+	Defaults map[string]float64
+	New      func(params map[string]float64) (Filter, error)
+}
+
+var (
+	filterTypesMu sync.RWMutex
+	filterTypes   = make(map[string]FilterType)
+)
+
+// RegisterFilter makes a filter type available to chains by name, replacing
+// any type registered under the same name.
+func RegisterFilter(filterType FilterType) {
+	filterTypesMu.Lock()
+	defer filterTypesMu.Unlock()
+	filterTypes[filterType.Name] = filterType
+}
+
+// FilterTypes lists the registered filter types ordered by name.
+func FilterTypes() []FilterType {
+	filterTypesMu.RLock()
+	defer filterTypesMu.RUnlock()
+
+	types := make([]FilterType, 0, len(filterTypes))
+	for _, filterType := range filterTypes {
+		types = append(types, filterType)
+	}
+	sort.Slice(types, func(i, j int) bool { return types[i].Name < types[j].Name })
+	return types
+}
+
+// buildFilter fills in default parameters and builds the filter. It returns
+// the spec with every parameter set.
+func buildFilter(spec FilterSpec) (Filter, FilterSpec, error) {
+	filterTypesMu.RLock()
+	filterType, ok := filterTypes[spec.Type]
+	filterTypesMu.RUnlock()
+	if !ok {
+		return nil, spec, fmt.Errorf("unknown filter type: %s", spec.Type)
+	}
+
+	params := make(map[string]float64, len(filterType.Defaults))
+	for name, value := range filterType.Defaults {
+		params[name] = value
+	}
+	for name, value := range spec.Params {
+		if _, ok := filterType.Defaults[name]; !ok {
+			return nil, spec, fmt.Errorf("%s filter has no parameter %s", spec.Type, name)
+		}
+		params[name] = value
+	}
+
+	filter, err := filterType.New(params)
+	if err != nil {
+		return nil, spec, fmt.Errorf("%s filter: %v", spec.Type, err)
+	}
+	return filter, FilterSpec{Type: spec.Type, Params: params}, nil
+}
+
+// ParseFilterSpecs reads filters written as type:name=value,... separated by
+// semicolons, for example "highpass:freq=80;compressor:ratio=3".
+func ParseFilterSpecs(value string) ([]FilterSpec, error) {
+	var specs []FilterSpec
+	for _, entry := range strings.Split(value, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		filterType, paramList, _ := strings.Cut(entry, ":")
+		spec := FilterSpec{Type: strings.TrimSpace(filterType)}
+		for _, param := range strings.Split(paramList, ",") {
+			if strings.TrimSpace(param) == "" {
+				continue
+			}
+			name, raw, ok := strings.Cut(param, "=")
+			value, err := strconv.ParseFloat(strings.TrimSpace(raw), 64)
+			if !ok || err != nil {
+				return nil, fmt.Errorf("invalid %s filter parameter: %s", spec.Type, param)
+			}
+			if spec.Params == nil {
+				spec.Params = make(map[string]float64)
+			}
+			spec.Params[strings.TrimSpace(name)] = value
+		}
+		specs = append(specs, spec)
+	}
+	return specs, nil
+}
+
+// FilterChain runs PCM through an ordered list of filters. The list can be
+// replaced while audio is flowing.
+type FilterChain struct {
+	mu      sync.Mutex
+	specs   []FilterSpec
+	filters []Filter
+}
+
+func NewFilterChain() *FilterChain {
+	return &FilterChain{}
+}
+
+// Set builds the filters described by specs and swaps them in. On error the
+// current chain is left as it is.
+func (c *FilterChain) Set(specs []FilterSpec) error {
+	filters := make([]Filter, 0, len(specs))
+	built := make([]FilterSpec, 0, len(specs))
+	for i, spec := range specs {
+		filter, full, err := buildFilter(spec)
+		if err != nil {
+			return fmt.Errorf("filter %d: %v", i+1, err)
+		}
+		filters = append(filters, filter)
+		built = append(built, full)
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.specs = built
+	c.filters = filters
+	return nil
+}
+
+// Specs returns the chain with every parameter filled in.
+func (c *FilterChain) Specs() []FilterSpec {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	specs := make([]FilterSpec, len(c.specs))
+	for i, spec := range c.specs {
+		params := make(map[string]float64, len(spec.Params))
+		for name, value := range spec.Params {
+			params[name] = value
+		}
+		specs[i] = FilterSpec{Type: spec.Type, Params: params}
+	}
+	return specs
+}
+
+// Reset clears the filters' state, for example when a new stream starts.
+func (c *FilterChain) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, filter := range c.filters {
+		filter.Reset()
+	}
+}
+
+func (c *FilterChain) Process(pcm []int16, channels int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, filter := range c.filters {
+		filter.Process(pcm, channels)
+	}
+}
@@ -0,0 +1,122 @@
+package audio
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+// runChain processes a second of a 997Hz sine at amplitude and returns the
+// peak of the last frame.
+func runChain(t *testing.T, specs []FilterSpec, amplitude float64) float64 {
+	t.Helper()
+	chain := NewFilterChain()
+	if err := chain.Set(specs); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	var last []int16
+	for _, frame := range sineFrames(amplitude, 2, 1) {
+		chain.Process(frame, 2)
+		last = frame
+	}
+	peak := 0.0
+	for _, sample := range last {
+		peak = math.Max(peak, math.Abs(float64(sample)/32768))
+	}
+	return peak
+}
+
+func TestParseFilterSpecs(t *testing.T) {
+	specs, err := ParseFilterSpecs("highpass:freq=80; eq:freq=3000,gain=-2.5 ;compressor")
+	if err != nil {
+		t.Fatalf("ParseFilterSpecs() error = %v", err)
+	}
+	want := []FilterSpec{
+		{Type: "highpass", Params: map[string]float64{"freq": 80}},
+		{Type: "eq", Params: map[string]float64{"freq": 3000, "gain": -2.5}},
+		{Type: "compressor"},
+	}
+	if !reflect.DeepEqual(specs, want) {
+		t.Errorf("ParseFilterSpecs() = %+v, want %+v", specs, want)
+	}
+
+	if _, err := ParseFilterSpecs("eq:gain=loud"); err == nil {
+		t.Error("ParseFilterSpecs() should reject a parameter that is not a number")
+	}
+}
+
+func TestFilterChainSet(t *testing.T) {
+	chain := NewFilterChain()
+	if err := chain.Set([]FilterSpec{{Type: "highpass", Params: map[string]float64{"freq": 120}}}); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+	if specs := chain.Specs(); len(specs) != 1 || specs[0].Params["freq"] != 120 || specs[0].Params["q"] == 0 {
+		t.Errorf("Specs() = %+v, want the highpass with its default q filled in", specs)
+	}
+
+	for _, specs := range [][]FilterSpec{
+		{{Type: "reverb"}},
+		{{Type: "eq", Params: map[string]float64{"width": 2}}},
+		{{Type: "eq", Params: map[string]float64{"freq": 30000}}},
+		{{Type: "compressor", Params: map[string]float64{"ratio": 0.5}}},
+	} {
+		if err := chain.Set(specs); err == nil {
+			t.Errorf("Set(%+v) should fail", specs)
+		}
+	}
+	if specs := chain.Specs(); len(specs) != 1 || specs[0].Type != "highpass" {
+		t.Errorf("Specs() after failed Set() = %+v, want the highpass kept", specs)
+	}
+}
+
+type scaleFilter struct{ factor int16 }
+
+func (f scaleFilter) Process(pcm []int16, channels int) {
+	for i := range pcm {
+		pcm[i] *= f.factor
+	}
+}
+
+func (f scaleFilter) Reset() {}
+
+func TestFilterChainRunsRegisteredFilters(t *testing.T) {
+	RegisterFilter(FilterType{
+		Name:     "test-scale",
+		Defaults: map[string]float64{"factor": 2},
+		New: func(params map[string]float64) (Filter, error) {
+			return scaleFilter{factor: int16(params["factor"])}, nil
+		},
+	})
+
+	chain := NewFilterChain()
+	chain.Set([]FilterSpec{{Type: "test-scale"}, {Type: "test-scale", Params: map[string]float64{"factor": 3}}})
+	pcm := []int16{100, -100}
+	chain.Process(pcm, 2)
+	if pcm[0] != 600 || pcm[1] != -600 {
+		t.Errorf("Process() = %v, want both filters applied in order", pcm)
+	}
+}
+
+func TestBuiltinFilters(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     FilterSpec
+		min, max float64
+	}{
+		{"highpass above the tone", FilterSpec{Type: "highpass", Params: map[string]float64{"freq": 2000}}, 0, 0.2},
+		{"highpass below the tone", FilterSpec{Type: "highpass", Params: map[string]float64{"freq": 80}}, 0.48, 0.51},
+		{"eq boost", FilterSpec{Type: "eq", Params: map[string]float64{"freq": 997, "gain": 6}}, 0.95, 1.01},
+		{"eq cut far from the tone", FilterSpec{Type: "eq", Params: map[string]float64{"freq": 8000, "gain": -12}}, 0.48, 0.51},
+		// -6 dBFS is 14 dB over the threshold, leaving 3.5 dB: -16.5 dBFS
+		{"compressor", FilterSpec{Type: "compressor", Params: map[string]float64{"threshold": -20, "ratio": 4}}, 0.13, 0.17},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if peak := runChain(t, []FilterSpec{tt.spec}, 0.5); peak < tt.min || peak > tt.max {
+				t.Errorf("peak = %f, want between %f and %f", peak, tt.min, tt.max)
+			}
+		})
+	}
+}
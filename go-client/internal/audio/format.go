@@ -0,0 +1,89 @@
+package audio
+
+import (
+	"fmt"
+	"strings"
+
+	"trunecord/internal/constants"
+)
+
+// Encoding describes how individual samples are stored.
+type Encoding int
+
+const (
+	EncodingInt16 Encoding = iota
+	EncodingFloat32
+)
+
+const (
+	MinSampleRate = 8000
+	MaxSampleRate = 192000
+)
+
+func (e Encoding) String() string {
+	switch e {
+	case EncodingInt16:
+		return "s16"
+	case EncodingFloat32:
+		return "f32"
+	}
+	return fmt.Sprintf("encoding(%d)", int(e))
+}
+
+// BytesPerSample returns the size of a single sample for the encoding.
+func (e Encoding) BytesPerSample() int {
+	if e == EncodingFloat32 {
+		return 4
+	}
+	return 2
+}
+
+// ParseEncoding accepts the sample format names used by the extension and
+// by the binary frame header.
+func ParseEncoding(name string) (Encoding, error) {
+	switch strings.ToLower(strings.TrimSpace(name)) {
+	case "", "s16", "s16le", "int16", "pcm16":
+		return EncodingInt16, nil
+	case "f32", "f32le", "float32", "float":
+		return EncodingFloat32, nil
+	}
+	return EncodingInt16, fmt.Errorf("unsupported sample format: %s", name)
+}
+
+// Format describes interleaved little-endian PCM.
+type Format struct {
+	SampleRate int
+	Channels   int
+	Encoding   Encoding
+}
+
+// PipelineFormat is what the Opus encoder consumes: 48kHz 16-bit PCM.
+func PipelineFormat(channels int) Format {
+	return Format{
+		SampleRate: constants.SampleRate,
+		Channels:   channels,
+		Encoding:   EncodingInt16,
+	}
+}
+
+func (f Format) Validate() error {
+	if f.SampleRate < MinSampleRate || f.SampleRate > MaxSampleRate {
+		return fmt.Errorf("unsupported sample rate: %d", f.SampleRate)
+	}
+	if !ValidChannels(f.Channels) {
+		return fmt.Errorf("unsupported channel count: %d", f.Channels)
+	}
+	if f.Encoding != EncodingInt16 && f.Encoding != EncodingFloat32 {
+		return fmt.Errorf("unsupported sample encoding: %v", f.Encoding)
+	}
+	return nil
+}
+
+// FrameBytes returns the size of one sample across all channels.
+func (f Format) FrameBytes() int {
+	return f.Channels * f.Encoding.BytesPerSample()
+}
+
+func (f Format) String() string {
+	return fmt.Sprintf("%dHz/%dch/%s", f.SampleRate, f.Channels, f.Encoding)
+}
@@ -0,0 +1,197 @@
+package audio
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+// JitterConfig bounds the latency the jitter buffer may add.
+type JitterConfig struct {
+	Target time.Duration
+	Min    time.Duration
+	Max    time.Duration
+}
+
+// DefaultJitterConfig returns the latency bounds used when none are configured.
+func DefaultJitterConfig() JitterConfig {
+	return JitterConfig{
+		Target: constants.JitterTargetLatency,
+		Min:    constants.JitterMinLatency,
+		Max:    constants.JitterMaxLatency,
+	}
+}
+
+func (c JitterConfig) Validate() error {
+	if c.Min < constants.AudioFrameInterval {
+		return fmt.Errorf("minimum latency must be at least %v", constants.AudioFrameInterval)
+	}
+	if c.Max < c.Min {
+		return fmt.Errorf("maximum latency %v is below minimum %v", c.Max, c.Min)
+	}
+	if c.Target < c.Min || c.Target > c.Max {
+		return fmt.Errorf("target latency %v must be between %v and %v", c.Target, c.Min, c.Max)
+	}
+	return nil
+}
+
+// JitterStats is a snapshot of the jitter buffer state.
+type JitterStats struct {
+	Depth     time.Duration
+	Target    time.Duration
+	Underruns uint64
+	Trimmed   uint64
+}
+
+// JitterBuffer smooths bursty PCM arrival into one frame per tick. It waits
+// until the target depth is buffered before playing, conceals underruns with
+// silence and trims old audio when it falls too far behind. The target grows
+// after underruns and shrinks again while playback is stable.
+type JitterBuffer struct {
+	mu         sync.Mutex
+	config     JitterConfig
+	frameBytes int
+	target     time.Duration
+	buffer     []byte
+	playing    bool
+	stable     int
+	silentRun  int
+	underruns  uint64
+	trimmed    uint64
+}
+
+// jitterShrinkFrames is how many clean frames (10s) must pass before the
+// target latency is lowered by one frame.
+const jitterShrinkFrames = 500
+
+// NewJitterBuffer creates a buffer for frames of frameBytes bytes, each
+// lasting constants.AudioFrameInterval.
+func NewJitterBuffer(frameBytes int, config JitterConfig) *JitterBuffer {
+	return &JitterBuffer{
+		config:     config,
+		frameBytes: frameBytes,
+		target:     config.Target,
+	}
+}
+
+// Push appends PCM to the buffer, trimming the oldest audio if the depth has
+// grown well past the target.
+func (j *JitterBuffer) Push(data []byte) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	j.buffer = append(j.buffer, data...)
+
+	if j.durationOf(len(j.buffer)) <= j.highWater() {
+		return
+	}
+
+	excess := len(j.buffer) - j.bytesFor(j.target)
+	excess -= excess % j.frameBytes
+	if excess <= 0 {
+		return
+	}
+	j.buffer = append(j.buffer[:0], j.buffer[excess:]...)
+	j.trimmed += uint64(excess / j.frameBytes)
+}
+
+// Pop returns the next frame to play. While the buffer is priming or idle it
+// returns nil; during an underrun it returns a frame of silence.
+func (j *JitterBuffer) Pop() []byte {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	if !j.playing {
+		if len(j.buffer) < j.frameBytes || j.durationOf(len(j.buffer)) < j.target {
+			return nil
+		}
+		j.playing = true
+	}
+
+	if len(j.buffer) >= j.frameBytes {
+		frame := make([]byte, j.frameBytes)
+		copy(frame, j.buffer)
+		j.buffer = append(j.buffer[:0], j.buffer[j.frameBytes:]...)
+		j.silentRun = 0
+
+		j.stable++
+		if j.stable >= jitterShrinkFrames && j.target > j.config.Min {
+			j.target -= constants.AudioFrameInterval
+			if j.target < j.config.Min {
+				j.target = j.config.Min
+			}
+			j.stable = 0
+		}
+		return frame
+	}
+
+	// Underrun: ask for more headroom next time and fill the gap with silence
+	j.underruns++
+	j.stable = 0
+	if j.silentRun == 0 && j.target < j.config.Max {
+		j.target += constants.AudioFrameInterval
+		if j.target > j.config.Max {
+			j.target = j.config.Max
+		}
+	}
+
+	// Give up concealing once the source has been gone for the max latency;
+	// playback restarts when the buffer primes again.
+	j.silentRun++
+	if j.durationOf(j.silentRun*j.frameBytes) > j.config.Max {
+		j.playing = false
+		j.silentRun = 0
+		return nil
+	}
+	return make([]byte, j.frameBytes)
+}
+
+// Depth returns how much audio is currently buffered.
+func (j *JitterBuffer) Depth() time.Duration {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return j.durationOf(len(j.buffer))
+}
+
+func (j *JitterBuffer) Stats() JitterStats {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	return JitterStats{
+		Depth:     j.durationOf(len(j.buffer)),
+		Target:    j.target,
+		Underruns: j.underruns,
+		Trimmed:   j.trimmed,
+	}
+}
+
+// Reset drops all buffered audio and waits to prime again.
+func (j *JitterBuffer) Reset() {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+	j.buffer = j.buffer[:0]
+	j.playing = false
+	j.silentRun = 0
+}
+
+// highWater is the depth above which Push trims back to the target.
+func (j *JitterBuffer) highWater() time.Duration {
+	headroom := j.target / 2
+	if floor := 2 * constants.AudioFrameInterval; headroom < floor {
+		headroom = floor
+	}
+	high := j.target + headroom
+	if high > j.config.Max && j.config.Max > j.target {
+		high = j.config.Max
+	}
+	return high
+}
+
+func (j *JitterBuffer) durationOf(bytes int) time.Duration {
+	return time.Duration(bytes) * constants.AudioFrameInterval / time.Duration(j.frameBytes)
+}
+
+func (j *JitterBuffer) bytesFor(d time.Duration) int {
+	return int(d * time.Duration(j.frameBytes) / constants.AudioFrameInterval)
+}
@@ -0,0 +1,143 @@
+package audio
+
+import (
+	"testing"
+	"time"
+)
+
+const testFrameBytes = 1920 // 20ms of 48kHz mono 16-bit
+
+func testJitterConfig() JitterConfig {
+	return JitterConfig{
+		Target: 60 * time.Millisecond,
+		Min:    40 * time.Millisecond,
+		Max:    200 * time.Millisecond,
+	}
+}
+
+func TestJitterConfigValidate(t *testing.T) {
+	if err := DefaultJitterConfig().Validate(); err != nil {
+		t.Errorf("DefaultJitterConfig().Validate() error = %v", err)
+	}
+
+	invalid := []JitterConfig{
+		{Target: 60 * time.Millisecond, Min: 10 * time.Millisecond, Max: 200 * time.Millisecond},
+		{Target: 60 * time.Millisecond, Min: 100 * time.Millisecond, Max: 80 * time.Millisecond},
+		{Target: 300 * time.Millisecond, Min: 40 * time.Millisecond, Max: 200 * time.Millisecond},
+	}
+	for _, cfg := range invalid {
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("Validate(%+v) should fail", cfg)
+		}
+	}
+}
+
+func TestJitterBufferPrimesToTarget(t *testing.T) {
+	jb := NewJitterBuffer(testFrameBytes, testJitterConfig())
+
+	jb.Push(make([]byte, testFrameBytes*2))
+	if frame := jb.Pop(); frame != nil {
+		t.Fatal("Pop() should wait until the target depth is buffered")
+	}
+
+	jb.Push(make([]byte, testFrameBytes))
+	if frame := jb.Pop(); len(frame) != testFrameBytes {
+		t.Fatalf("Pop() returned %d bytes, want %d", len(frame), testFrameBytes)
+	}
+
+	if depth := jb.Depth(); depth != 40*time.Millisecond {
+		t.Errorf("Depth() = %v, want 40ms", depth)
+	}
+}
+
+func TestJitterBufferConcealsUnderrunWithSilence(t *testing.T) {
+	jb := NewJitterBuffer(testFrameBytes, testJitterConfig())
+
+	frame := make([]byte, testFrameBytes)
+	for i := range frame {
+		frame[i] = 1
+	}
+	for i := 0; i < 3; i++ {
+		jb.Push(frame)
+	}
+	for i := 0; i < 3; i++ {
+		if got := jb.Pop(); got == nil || got[0] != 1 {
+			t.Fatalf("Pop() %d did not return buffered audio", i)
+		}
+	}
+
+	silence := jb.Pop()
+	if len(silence) != testFrameBytes {
+		t.Fatalf("Pop() on underrun returned %d bytes, want a silent frame", len(silence))
+	}
+	for _, b := range silence {
+		if b != 0 {
+			t.Fatal("Pop() on underrun should return silence")
+		}
+	}
+
+	stats := jb.Stats()
+	if stats.Underruns != 1 {
+		t.Errorf("Underruns = %d, want 1", stats.Underruns)
+	}
+	if stats.Target != 80*time.Millisecond {
+		t.Errorf("Target after underrun = %v, want 80ms", stats.Target)
+	}
+}
+
+func TestJitterBufferGoesIdleAfterMaxLatency(t *testing.T) {
+	jb := NewJitterBuffer(testFrameBytes, testJitterConfig())
+	for i := 0; i < 3; i++ {
+		jb.Push(make([]byte, testFrameBytes))
+	}
+	for i := 0; i < 3; i++ {
+		jb.Pop()
+	}
+
+	// 200ms of silence is concealed, then the buffer stops producing frames
+	for i := 0; i < 10; i++ {
+		if jb.Pop() == nil {
+			t.Fatalf("Pop() %d returned nil before max latency elapsed", i)
+		}
+	}
+	if jb.Pop() != nil {
+		t.Error("Pop() should return nil once the source has been silent past max latency")
+	}
+}
+
+func TestJitterBufferTrimsExcess(t *testing.T) {
+	jb := NewJitterBuffer(testFrameBytes, testJitterConfig())
+
+	// 20 frames (400ms) arriving at once is far past the 60ms target
+	for i := 0; i < 20; i++ {
+		jb.Push(make([]byte, testFrameBytes))
+	}
+
+	// Trimming drops back to the target whenever 60ms + 40ms headroom is exceeded
+	if depth := jb.Depth(); depth > 100*time.Millisecond {
+		t.Errorf("Depth() after burst = %v, want at most 100ms", depth)
+	}
+	if trimmed := jb.Stats().Trimmed; trimmed == 0 {
+		t.Error("Trimmed should count dropped frames")
+	}
+}
+
+func TestJitterBufferShrinksTargetWhenStable(t *testing.T) {
+	cfg := testJitterConfig()
+	cfg.Target = 100 * time.Millisecond
+	jb := NewJitterBuffer(testFrameBytes, cfg)
+
+	for i := 0; i < 5; i++ {
+		jb.Push(make([]byte, testFrameBytes))
+	}
+	for i := 0; i < jitterShrinkFrames; i++ {
+		jb.Push(make([]byte, testFrameBytes))
+		if jb.Pop() == nil {
+			t.Fatalf("Pop() %d returned nil", i)
+		}
+	}
+
+	if target := jb.Stats().Target; target != 80*time.Millisecond {
+		t.Errorf("Target after stable playback = %v, want 80ms", target)
+	}
+}
@@ -0,0 +1,259 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+
+	"trunecord/internal/constants"
+)
+
+const (
+	DefaultLoudnessTarget = -16.0
+	MinLoudnessTarget     = -36.0
+	MaxLoudnessTarget     = -6.0
+	// LoudnessFloor is reported for anything quieter than the absolute gate
+	// of ITU-R BS.1770.
+	LoudnessFloor = -70.0
+
+	// loudnessHop is the 100ms step between 400ms momentary blocks.
+	loudnessHop = constants.SampleRate / 10
+	// momentaryHops and shortTermHops cover the 400ms and 3s windows.
+	momentaryHops = 4
+	shortTermHops = 30
+	// loudnessBins histogram momentary blocks in 0.1 LU steps from the
+	// absolute gate up, for the gated integrated loudness.
+	loudnessBinsPerLU = 10
+	loudnessBins      = 80 * loudnessBinsPerLU
+	relativeGate      = -10.0
+
+	// normalizeSilence holds the gain during pauses and fades instead of
+	// boosting them.
+	normalizeSilence = -50.0
+	normalizeMaxGain = 12.0
+	normalizeMinGain = -20.0
+	// Gain comes down quickly when a track is too loud and rises slowly so
+	// quiet passages are not pumped up.
+	normalizeAttack  = 10.0 // dB per second
+	normalizeRelease = 3.0  // dB per second
+)
+
+// LoudnessStats reports the measured loudness of the stream in LUFS and the
+// gain applied to normalize it.
+type LoudnessStats struct {
+	Enabled    bool
+	Target     float64
+	Momentary  float64
+	ShortTerm  float64
+	Integrated float64
+	GainDB     float64
+}
+
+// newKWeighting returns the BS.1770 K-weighting pre-filter for 48kHz: a
+// high shelf modelling the head followed by a high-pass.
+func newKWeighting() [2]biquad {
+	return [2]biquad{
+		{b0: 1.53512485958697, b1: -2.69169618940638, b2: 1.19839281085285, a1: -1.69065929318241, a2: 0.73248077421585},
+		{b0: 1, b1: -2, b2: 1, a1: -1.99004745483398, a2: 0.99007225036621},
+	}
+}
+
+// Normalizer measures loudness as specified by EBU R128 and, when enabled,
+// steers the gain so the short-term loudness approaches the target.
+type Normalizer struct {
+	mu        sync.Mutex
+	enabled   bool
+	target    float64
+	channels  int
+	filters   [][2]biquad
+	hopEnergy float64
+	hopFrames int
+	hops      [shortTermHops]float64
+	hopCount  int
+	histogram [loudnessBins]struct{ count, energy float64 }
+	momentary float64
+	shortTerm float64
+	gainDB    float64
+	appliedDB float64
+}
+
+func NewNormalizer() *Normalizer {
+	return &Normalizer{
+		target:    DefaultLoudnessTarget,
+		momentary: LoudnessFloor,
+		shortTerm: LoudnessFloor,
+	}
+}
+
+// Set enables or disables normalization and sets its target in LUFS.
+func (n *Normalizer) Set(enabled bool, target float64) error {
+	if target < MinLoudnessTarget || target > MaxLoudnessTarget || math.IsNaN(target) {
+		return fmt.Errorf("loudness target must be between %.0f and %.0f LUFS: %g", MinLoudnessTarget, MaxLoudnessTarget, target)
+	}
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.enabled = enabled
+	n.target = target
+	return nil
+}
+
+// Reset starts a new measurement, for example when a new stream starts.
+func (n *Normalizer) Reset() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.channels = 0
+	n.reset()
+}
+
+// reset clears the measurement. Callers must hold the mutex.
+func (n *Normalizer) reset() {
+	n.filters = make([][2]biquad, n.channels)
+	for i := range n.filters {
+		n.filters[i] = newKWeighting()
+	}
+	n.hopEnergy = 0
+	n.hopFrames = 0
+	n.hopCount = 0
+	n.histogram = [loudnessBins]struct{ count, energy float64 }{}
+	n.momentary = LoudnessFloor
+	n.shortTerm = LoudnessFloor
+}
+
+// Process measures one frame of interleaved PCM and, when enabled, applies
+// the normalization gain to it in place.
+func (n *Normalizer) Process(pcm []int16, channels int) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if channels != n.channels {
+		n.channels = channels
+		n.reset()
+	}
+
+	frames := len(pcm) / channels
+	for i := 0; i < frames; i++ {
+		for ch := 0; ch < channels; ch++ {
+			x := float64(pcm[i*channels+ch]) / 32768
+			for stage := range n.filters[ch] {
+				x = n.filters[ch][stage].process(x)
+			}
+			n.hopEnergy += x * x
+		}
+		n.hopFrames++
+		if n.hopFrames == loudnessHop {
+			n.addHop(n.hopEnergy / loudnessHop)
+			n.hopEnergy = 0
+			n.hopFrames = 0
+		}
+	}
+
+	start := n.appliedDB
+	n.appliedDB = 0
+	if n.enabled {
+		n.steer(float64(frames) / constants.SampleRate)
+		n.appliedDB = n.gainDB
+	} else {
+		n.gainDB = 0
+	}
+	if start != 0 || n.appliedDB != 0 {
+		applyGain(pcm, channels, dbToGain(start), dbToGain(n.appliedDB))
+	}
+}
+
+// addHop records the mean square of a 100ms hop and updates the
+// measurements. Callers must hold the mutex.
+func (n *Normalizer) addHop(energy float64) {
+	n.hops[n.hopCount%shortTermHops] = energy
+	n.hopCount++
+
+	if n.hopCount >= momentaryHops {
+		block := n.meanEnergy(momentaryHops)
+		n.momentary = energyToLoudness(block)
+		if n.momentary > LoudnessFloor {
+			bin := int((n.momentary - LoudnessFloor) * loudnessBinsPerLU)
+			if bin >= loudnessBins {
+				bin = loudnessBins - 1
+			}
+			n.histogram[bin].count++
+			n.histogram[bin].energy += block
+		}
+	}
+	n.shortTerm = energyToLoudness(n.meanEnergy(shortTermHops))
+}
+
+// meanEnergy averages the most recent hops, up to count of them.
+func (n *Normalizer) meanEnergy(count int) float64 {
+	if count > n.hopCount {
+		count = n.hopCount
+	}
+	sum := 0.0
+	for i := 1; i <= count; i++ {
+		sum += n.hops[(n.hopCount-i)%shortTermHops]
+	}
+	return sum / float64(count)
+}
+
+// steer moves the gain toward the target over elapsed seconds of audio.
+// Callers must hold the mutex.
+func (n *Normalizer) steer(elapsed float64) {
+	if n.hopCount < momentaryHops || n.shortTerm <= normalizeSilence {
+		return
+	}
+
+	desired := math.Max(normalizeMinGain, math.Min(normalizeMaxGain, n.target-n.shortTerm))
+	if desired < n.gainDB {
+		n.gainDB = math.Max(desired, n.gainDB-normalizeAttack*elapsed)
+	} else {
+		n.gainDB = math.Min(desired, n.gainDB+normalizeRelease*elapsed)
+	}
+}
+
+// integrated applies the absolute and relative gates to the histogram of
+// momentary blocks. Callers must hold the mutex.
+func (n *Normalizer) integrated() float64 {
+	gated := func(threshold float64) float64 {
+		count, energy := 0.0, 0.0
+		for bin, entry := range n.histogram {
+			if LoudnessFloor+float64(bin)/loudnessBinsPerLU >= threshold {
+				count += entry.count
+				energy += entry.energy
+			}
+		}
+		if count == 0 {
+			return 0
+		}
+		return energy / count
+	}
+
+	ungated := gated(LoudnessFloor)
+	if ungated == 0 {
+		return LoudnessFloor
+	}
+	return energyToLoudness(gated(energyToLoudness(ungated) + relativeGate))
+}
+
+func (n *Normalizer) Stats() LoudnessStats {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return LoudnessStats{
+		Enabled:    n.enabled,
+		Target:     n.target,
+		Momentary:  n.momentary,
+		ShortTerm:  n.shortTerm,
+		Integrated: n.integrated(),
+		GainDB:     n.appliedDB,
+	}
+}
+
+// energyToLoudness converts the K-weighted mean square summed over channels
+// to LUFS, bottoming out at LoudnessFloor.
+func energyToLoudness(energy float64) float64 {
+	if energy <= 0 {
+		return LoudnessFloor
+	}
+	return math.Max(LoudnessFloor, -0.691+10*math.Log10(energy))
+}
+
+func dbToGain(db float64) float64 {
+	return math.Pow(10, db/20)
+}
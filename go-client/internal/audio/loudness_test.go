@@ -0,0 +1,116 @@
+package audio
+
+import (
+	"math"
+	"testing"
+
+	"trunecord/internal/constants"
+)
+
+// sineFrames renders seconds of a 997Hz sine with the given peak amplitude
+// in all channels, in 20ms frames.
+func sineFrames(amplitude float64, channels int, seconds float64) [][]int16 {
+	var frames [][]int16
+	position := 0
+	for i := 0; i < int(seconds*50); i++ {
+		frame := make([]int16, constants.PCMFrameSize*channels)
+		for j := 0; j < constants.PCMFrameSize; j++ {
+			sample := int16(amplitude * 32767 * math.Sin(2*math.Pi*997*float64(position)/constants.SampleRate))
+			for ch := 0; ch < channels; ch++ {
+				frame[j*channels+ch] = sample
+			}
+			position++
+		}
+		frames = append(frames, frame)
+	}
+	return frames
+}
+
+func TestNormalizerMeasuresLoudness(t *testing.T) {
+	n := NewNormalizer()
+	for _, frame := range sineFrames(0.1, 1, 4) {
+		n.Process(frame, 1)
+	}
+
+	// A -20 dBFS sine in one channel reads 3.01 LU lower
+	stats := n.Stats()
+	for name, value := range map[string]float64{
+		"momentary":  stats.Momentary,
+		"short-term": stats.ShortTerm,
+		"integrated": stats.Integrated,
+	} {
+		if math.Abs(value-(-23.01)) > 0.1 {
+			t.Errorf("%s loudness = %.2f LUFS, want -23.01", name, value)
+		}
+	}
+	if stats.GainDB != 0 {
+		t.Errorf("gain = %.2f dB, want none while disabled", stats.GainDB)
+	}
+}
+
+func TestNormalizerGatesSilence(t *testing.T) {
+	n := NewNormalizer()
+	for _, frame := range sineFrames(0.1, 2, 2) {
+		n.Process(frame, 2)
+	}
+	for _, frame := range sineFrames(0, 2, 4) {
+		n.Process(frame, 2)
+	}
+
+	// Stereo reads 3 LU louder than mono, and silence does not drag it
+	// down; only blocks straddling the end of the tone pass the gate
+	stats := n.Stats()
+	if math.Abs(stats.Integrated-(-20)) > 0.5 {
+		t.Errorf("integrated loudness = %.2f LUFS, want -20 with silence gated", stats.Integrated)
+	}
+	if stats.ShortTerm != LoudnessFloor {
+		t.Errorf("short-term loudness = %.2f LUFS, want the floor after 3s of silence", stats.ShortTerm)
+	}
+}
+
+func TestNormalizerSteersTowardTarget(t *testing.T) {
+	tests := []struct {
+		name      string
+		amplitude float64
+		wantGain  float64
+	}{
+		{name: "quiet track is boosted slowly", amplitude: 0.1, wantGain: 7},
+		{name: "loud track is cut quickly", amplitude: 0.9, wantGain: -12.1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := NewNormalizer()
+			if err := n.Set(true, -16); err != nil {
+				t.Fatalf("Set() error = %v", err)
+			}
+
+			var last []int16
+			for _, frame := range sineFrames(tt.amplitude, 1, 6) {
+				n.Process(frame, 1)
+				last = frame
+			}
+			if gain := n.Stats().GainDB; math.Abs(gain-tt.wantGain) > 0.2 {
+				t.Errorf("gain = %.2f dB, want %.1f", gain, tt.wantGain)
+			}
+
+			peak := 0.0
+			for _, sample := range last {
+				peak = math.Max(peak, math.Abs(float64(sample))/32768)
+			}
+			want := tt.amplitude * dbToGain(tt.wantGain)
+			if math.Abs(peak-want) > want*0.05 {
+				t.Errorf("output peak = %.3f, want about %.3f", peak, want)
+			}
+		})
+	}
+}
+
+func TestNormalizerSetValidates(t *testing.T) {
+	n := NewNormalizer()
+	if err := n.Set(true, 0); err == nil {
+		t.Error("Set() should reject a target above MaxLoudnessTarget")
+	}
+	if stats := n.Stats(); stats.Enabled || stats.Target != DefaultLoudnessTarget {
+		t.Errorf("Stats() = %+v, want the defaults after a rejected Set()", stats)
+	}
+}
@@ -0,0 +1,249 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+const (
+	MaxMixerGain = 4.0 // +12dB
+	// mixerMaxBuffered caps how far one input may run ahead of the others.
+	mixerMaxBuffered = 200 * time.Millisecond
+)
+
+// MixerInput describes one input to the mixer.
+type MixerInput struct {
+	ID     string  `json:"id"`
+	Gain   float64 `json:"gain"`
+	Muted  bool    `json:"muted"`
+	Active bool    `json:"active"`
+}
+
+type mixerInput struct {
+	gain     float64
+	muted    bool
+	pending  []byte
+	lastPush time.Time
+}
+
+// Mixer sums 16-bit PCM in the pipeline format from several inputs, each
+// with its own gain. Output is produced as input arrives, so a single input
+// passes through without added latency; with several active inputs, audio
+// is released once every one of them has supplied it.
+type Mixer struct {
+	mu       sync.Mutex
+	channels int
+	inputs   map[string]*mixerInput
+	clipped  uint64
+	// idleTimeout drops an input from the mix once it stops sending, so a
+	// paused tab does not hold back the others.
+	idleTimeout time.Duration
+}
+
+func NewMixer(channels int) *Mixer {
+	return &Mixer{
+		channels:    channels,
+		inputs:      make(map[string]*mixerInput),
+		idleTimeout: DefaultJitterConfig().Max,
+	}
+}
+
+// SetIdleTimeout sets how long an input may go without sending before the
+// others stop waiting for it. It should cover the longest gap the jitter
+// buffer rides out, so a late input is not dropped from the mix.
+func (m *Mixer) SetIdleTimeout(timeout time.Duration) error {
+	if timeout <= 0 {
+		return fmt.Errorf("mixer idle timeout must be positive: %v", timeout)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.idleTimeout = timeout
+	return nil
+}
+
+// SetChannels changes the channel count of the PCM being mixed, discarding
+// anything still pending in the old layout.
+func (m *Mixer) SetChannels(channels int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.channels = channels
+	for _, in := range m.inputs {
+		in.pending = nil
+	}
+}
+
+// Push queues pcm from an input and returns whatever can be mixed, or nil.
+func (m *Mixer) Push(id string, pcm []byte, now time.Time) []byte {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	in := m.input(id)
+	if now.Sub(in.lastPush) > m.idleTimeout {
+		// Whatever is left from before the pause would now play late
+		in.pending = nil
+	}
+	in.lastPush = now
+	in.pending = append(in.pending, pcm...)
+
+	frameBytes := m.channels * 2
+	maxBytes := int(mixerMaxBuffered/constants.AudioFrameInterval) * constants.PCMFrameBytes(m.channels)
+	if excess := len(in.pending) - maxBytes; excess > 0 {
+		excess += (frameBytes - excess%frameBytes) % frameBytes
+		in.pending = in.pending[excess:]
+	}
+
+	// Release what every active input has supplied. Muted inputs add
+	// nothing, so the others do not wait for them, unless all are muted.
+	var active, muted []*mixerInput
+	for _, input := range m.inputs {
+		if now.Sub(input.lastPush) > m.idleTimeout {
+			continue
+		}
+		if input.muted {
+			muted = append(muted, input)
+			continue
+		}
+		active = append(active, input)
+	}
+	if len(active) == 0 {
+		active = muted
+	} else {
+		for _, input := range muted {
+			input.pending = nil
+		}
+	}
+	available := len(active[0].pending)
+	for _, input := range active[1:] {
+		if len(input.pending) < available {
+			available = len(input.pending)
+		}
+	}
+	if len(active) == 1 && active[0] == in && !in.muted && in.gain == 1 {
+		out := in.pending
+		in.pending = nil
+		return out
+	}
+
+	available -= available % frameBytes
+	if available == 0 {
+		return nil
+	}
+
+	out := make([]byte, available)
+	for i := 0; i < available; i += 2 {
+		sum := 0.0
+		for _, input := range active {
+			if input.muted {
+				continue
+			}
+			sum += float64(int16(binary.LittleEndian.Uint16(input.pending[i:]))) / 32768 * input.gain
+		}
+		if math.Abs(sum) > limiterKnee {
+			m.clipped++
+			sum = softClip(sum)
+		}
+		binary.LittleEndian.PutUint16(out[i:], uint16(FloatToInt16(float32(sum))))
+	}
+	for _, input := range active {
+		input.pending = input.pending[available:]
+	}
+	return out
+}
+
+func (m *Mixer) input(id string) *mixerInput {
+	in, ok := m.inputs[id]
+	if !ok {
+		in = &mixerInput{gain: 1}
+		m.inputs[id] = in
+	}
+	return in
+}
+
+// Add registers an input so its gain can be set before it sends audio.
+func (m *Mixer) Add(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.input(id)
+}
+
+// Remove forgets an input, for example when its client disconnects.
+func (m *Mixer) Remove(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.inputs, id)
+}
+
+// Pause drops an input out of the mix until it pushes again, so the others
+// do not wait for it.
+func (m *Mixer) Pause(id string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if in, ok := m.inputs[id]; ok {
+		in.pending = nil
+		in.lastPush = time.Time{}
+	}
+}
+
+// SetGain sets an input's linear gain, from 0 to MaxMixerGain.
+func (m *Mixer) SetGain(id string, gain float64) error {
+	if gain < 0 || gain > MaxMixerGain || math.IsNaN(gain) {
+		return fmt.Errorf("gain must be between 0 and %g: %g", MaxMixerGain, gain)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	in, ok := m.inputs[id]
+	if !ok {
+		return fmt.Errorf("unknown mixer input: %s", id)
+	}
+	in.gain = gain
+	return nil
+}
+
+func (m *Mixer) SetMuted(id string, muted bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	in, ok := m.inputs[id]
+	if !ok {
+		return fmt.Errorf("unknown mixer input: %s", id)
+	}
+	in.muted = muted
+	return nil
+}
+
+// Inputs lists the inputs ordered by ID.
+func (m *Mixer) Inputs() []MixerInput {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	inputs := make([]MixerInput, 0, len(m.inputs))
+	for id, in := range m.inputs {
+		inputs = append(inputs, MixerInput{
+			ID:     id,
+			Gain:   in.gain,
+			Muted:  in.muted,
+			Active: now.Sub(in.lastPush) <= m.idleTimeout,
+		})
+	}
+	// Shorter IDs first keeps numeric IDs in numeric order
+	sort.Slice(inputs, func(i, j int) bool {
+		if len(inputs[i].ID) != len(inputs[j].ID) {
+			return len(inputs[i].ID) < len(inputs[j].ID)
+		}
+		return inputs[i].ID < inputs[j].ID
+	})
+	return inputs
+}
+
+// Clipped counts samples the soft clipper had to bend.
+func (m *Mixer) Clipped() uint64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.clipped
+}
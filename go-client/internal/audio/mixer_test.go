@@ -0,0 +1,166 @@
+package audio
+
+import (
+	"encoding/binary"
+	"testing"
+	"time"
+)
+
+func constantPCM(value int16, samples int) []byte {
+	pcm := make([]byte, samples*2)
+	for i := 0; i < samples; i++ {
+		binary.LittleEndian.PutUint16(pcm[i*2:], uint16(value))
+	}
+	return pcm
+}
+
+func firstSample(pcm []byte) int16 {
+	return int16(binary.LittleEndian.Uint16(pcm))
+}
+
+func TestMixerPassesSingleInputThrough(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+
+	in := constantPCM(1234, 480)
+	out := m.Push("a", in, now)
+	if len(out) != len(in) || firstSample(out) != 1234 {
+		t.Fatalf("Push() = %d bytes starting with %d, want the input unchanged", len(out), firstSample(out))
+	}
+}
+
+func TestMixerSumsActiveInputs(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+
+	if out := m.Push("a", constantPCM(1000, 480), now); out == nil {
+		t.Fatal("first input alone should pass through")
+	}
+
+	// Once b is active, its audio waits for a's next chunk
+	if out := m.Push("b", constantPCM(2000, 480), now); out != nil {
+		t.Fatalf("b's chunk should wait for a, got %d bytes", len(out))
+	}
+	out := m.Push("a", constantPCM(1000, 480), now.Add(10*time.Millisecond))
+	if len(out) != 960 || firstSample(out) != 3000 {
+		t.Fatalf("mixed chunk = %d bytes starting with %d, want 960 bytes of 3000", len(out), firstSample(out))
+	}
+}
+
+func TestMixerAppliesGainAndMute(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+	m.Add("a")
+	m.Add("b")
+
+	if err := m.SetGain("a", 0.5); err != nil {
+		t.Fatalf("SetGain() error = %v", err)
+	}
+	if err := m.SetMuted("b", true); err != nil {
+		t.Fatalf("SetMuted() error = %v", err)
+	}
+
+	m.Push("a", constantPCM(2000, 480), now)
+	m.Push("b", constantPCM(8000, 480), now)
+	out := m.Push("a", constantPCM(2000, 480), now)
+	if firstSample(out) != 1000 {
+		t.Errorf("mixed sample = %d, want 1000 from a at half gain with b muted", firstSample(out))
+	}
+
+	if err := m.SetGain("a", 5); err == nil {
+		t.Error("SetGain() should reject gains above MaxMixerGain")
+	}
+	if err := m.SetGain("missing", 1); err == nil {
+		t.Error("SetGain() should reject unknown inputs")
+	}
+}
+
+func TestMixerDoesNotWaitForMutedInputs(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+	m.Add("b")
+	if err := m.SetMuted("b", true); err != nil {
+		t.Fatalf("SetMuted() error = %v", err)
+	}
+
+	m.Push("b", constantPCM(8000, 480), now)
+	out := m.Push("a", constantPCM(1000, 480), now)
+	if len(out) != 960 || firstSample(out) != 1000 {
+		t.Fatalf("Push() with b muted = %d bytes starting with %d, want a right away", len(out), firstSample(out))
+	}
+
+	// A muted input alone still keeps time with silence
+	m.Pause("a")
+	out = m.Push("b", constantPCM(8000, 480), now)
+	if len(out) != 960 || firstSample(out) != 0 {
+		t.Errorf("Push() from b alone = %d bytes starting with %d, want 960 bytes of silence", len(out), firstSample(out))
+	}
+}
+
+func TestMixerIdleTimeout(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+	if err := m.SetIdleTimeout(400 * time.Millisecond); err != nil {
+		t.Fatalf("SetIdleTimeout() error = %v", err)
+	}
+
+	m.Push("a", constantPCM(1000, 480), now)
+	m.Push("b", constantPCM(2000, 480), now)
+
+	// A gap the jitter buffer would ride out keeps b in the mix
+	out := m.Push("a", constantPCM(1000, 480), now.Add(300*time.Millisecond))
+	if len(out) != 960 || firstSample(out) != 3000 {
+		t.Fatalf("Push() within the idle timeout = %d bytes starting with %d, want a mixed with b", len(out), firstSample(out))
+	}
+
+	if err := m.SetIdleTimeout(0); err == nil {
+		t.Error("SetIdleTimeout() should reject a timeout of 0")
+	}
+}
+
+func TestMixerSoftClips(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+
+	m.Push("a", constantPCM(16000, 480), now)
+	m.Push("b", constantPCM(16000, 480), now)
+	out := m.Push("a", constantPCM(16000, 480), now)
+	if sample := firstSample(out); sample < 28000 || sample >= 32000 {
+		t.Errorf("mixed sample = %d, want 32000 bent below itself by the soft clipper", sample)
+	}
+
+	// Far past full scale the output saturates instead of wrapping around
+	m.Push("b", constantPCM(30000, 480), now)
+	out = m.Push("a", constantPCM(30000, 480), now)
+	if sample := firstSample(out); sample < 32000 {
+		t.Errorf("overloaded sample = %d, want close to full scale", sample)
+	}
+	if m.Clipped() == 0 {
+		t.Error("Clipped() should count limited samples")
+	}
+}
+
+func TestMixerDropsIdleInputs(t *testing.T) {
+	m := NewMixer(1)
+	now := time.Unix(0, 0)
+
+	m.Push("a", constantPCM(1000, 480), now)
+	m.Push("b", constantPCM(2000, 480), now)
+
+	// b stops sending; after the idle timeout a plays on its own again
+	later := now.Add(DefaultJitterConfig().Max + 10*time.Millisecond)
+	out := m.Push("a", constantPCM(1000, 480), later)
+	if len(out) != 960 || firstSample(out) != 1000 {
+		t.Fatalf("Push() after b went idle = %d bytes starting with %d, want a alone", len(out), firstSample(out))
+	}
+
+	inputs := m.Inputs()
+	if len(inputs) != 2 || inputs[0].ID != "a" || inputs[1].ID != "b" {
+		t.Fatalf("Inputs() = %+v, want a and b", inputs)
+	}
+
+	m.Remove("b")
+	if inputs := m.Inputs(); len(inputs) != 1 {
+		t.Errorf("Inputs() after Remove() = %+v, want only a", inputs)
+	}
+}
@@ -0,0 +1,72 @@
+package audio
+
+import (
+	"encoding/binary"
+	"fmt"
+)
+
+// BytesToInt16 converts little-endian 16-bit PCM bytes into samples.
+func BytesToInt16(data []byte) []int16 {
+	samples := make([]int16, len(data)/2)
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*2:]))
+	}
+	return samples
+}
+
+// Int16ToBytes converts samples into little-endian 16-bit PCM bytes.
+func Int16ToBytes(samples []int16) []byte {
+	data := make([]byte, len(samples)*2)
+	for i, sample := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(sample))
+	}
+	return data
+}
+
+// ConvertChannels remixes interleaved 16-bit PCM between mono and stereo.
+// Mono is duplicated into both channels; stereo is averaged down to mono.
+func ConvertChannels(data []byte, from, to int) ([]byte, error) {
+	if from == to {
+		return data, nil
+	}
+
+	switch {
+	case from == 1 && to == 2:
+		out := make([]byte, len(data)*2)
+		for i := 0; i+1 < len(data); i += 2 {
+			copy(out[i*2:i*2+2], data[i:i+2])
+			copy(out[i*2+2:i*2+4], data[i:i+2])
+		}
+		return out, nil
+
+	case from == 2 && to == 1:
+		out := make([]byte, len(data)/2)
+		for i := 0; i+3 < len(data); i += 4 {
+			left := int32(int16(binary.LittleEndian.Uint16(data[i:])))
+			right := int32(int16(binary.LittleEndian.Uint16(data[i+2:])))
+			binary.LittleEndian.PutUint16(out[i/2:], uint16(int16((left+right)/2)))
+		}
+		return out, nil
+	}
+
+	return nil, fmt.Errorf("unsupported channel conversion: %d -> %d", from, to)
+}
+
+// SilenceThreshold is the peak below which samples count as digital
+// silence, about -72 dBFS so dither alone does not count as sound.
+const SilenceThreshold = 8
+
+// IsSilent reports whether every sample of pcm is below SilenceThreshold.
+func IsSilent(pcm []int16) bool {
+	for _, sample := range pcm {
+		if sample >= SilenceThreshold || sample <= -SilenceThreshold {
+			return false
+		}
+	}
+	return true
+}
+
+// ValidChannels reports whether the channel count can be encoded by the pipeline.
+func ValidChannels(channels int) bool {
+	return channels == 1 || channels == 2
+}
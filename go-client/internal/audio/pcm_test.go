@@ -0,0 +1,74 @@
+package audio
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestInt16RoundTrip(t *testing.T) {
+	samples := []int16{0, 1, -1, 32767, -32768, 1234}
+
+	got := BytesToInt16(Int16ToBytes(samples))
+	if !reflect.DeepEqual(got, samples) {
+		t.Errorf("round trip = %v, want %v", got, samples)
+	}
+}
+
+func TestConvertChannels(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []int16
+		from int
+		to   int
+		want []int16
+	}{
+		{
+			name: "mono passthrough",
+			in:   []int16{1, 2, 3},
+			from: 1,
+			to:   1,
+			want: []int16{1, 2, 3},
+		},
+		{
+			name: "mono to stereo",
+			in:   []int16{100, -200},
+			from: 1,
+			to:   2,
+			want: []int16{100, 100, -200, -200},
+		},
+		{
+			name: "stereo to mono",
+			in:   []int16{100, 300, -32768, -32768},
+			from: 2,
+			to:   1,
+			want: []int16{200, -32768},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ConvertChannels(Int16ToBytes(tt.in), tt.from, tt.to)
+			if err != nil {
+				t.Fatalf("ConvertChannels() error = %v", err)
+			}
+			if got := BytesToInt16(out); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ConvertChannels() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsSilent(t *testing.T) {
+	if !IsSilent([]int16{0, 7, -7, 3}) {
+		t.Error("IsSilent() should treat dither-level samples as silence")
+	}
+	if IsSilent([]int16{0, 0, -8, 0}) {
+		t.Error("IsSilent() should not treat a sample at the threshold as silence")
+	}
+}
+
+func TestConvertChannelsUnsupported(t *testing.T) {
+	if _, err := ConvertChannels([]byte{0, 0}, 1, 6); err == nil {
+		t.Error("ConvertChannels() should reject unsupported channel counts")
+	}
+}
@@ -0,0 +1,71 @@
+package audio
+
+// Resampler converts interleaved float samples between sample rates using
+// linear interpolation. It keeps state between calls so that consecutive
+// chunks join without clicks.
+type Resampler struct {
+	channels int
+	step     float64
+	pos      float64
+	last     []float32
+}
+
+// NewResampler creates a resampler from inRate to outRate.
+func NewResampler(channels, inRate, outRate int) *Resampler {
+	return &Resampler{
+		channels: channels,
+		step:     float64(inRate) / float64(outRate),
+		last:     make([]float32, channels),
+	}
+}
+
+// SetRatio adjusts the input/output rate ratio, e.g. for drift correction.
+// A ratio above 1 consumes input faster than it produces output.
+func (r *Resampler) SetRatio(ratio float64) {
+	if ratio > 0 {
+		r.step = ratio
+	}
+}
+
+// Ratio returns the current input/output rate ratio.
+func (r *Resampler) Ratio() float64 {
+	return r.step
+}
+
+// Continue sets the resampler up to pick up right after last, the final
+// frame of audio that went around it, as if it had just been processed.
+func (r *Resampler) Continue(last []float32) {
+	copy(r.last, last)
+	r.pos = 1
+}
+
+// Process resamples one chunk of interleaved samples.
+func (r *Resampler) Process(in []float32) []float32 {
+	frames := len(in) / r.channels
+	if frames == 0 {
+		return nil
+	}
+
+	// Position 0 is the last frame of the previous chunk, position i is
+	// in[i-1], so interpolation always has both neighbours available.
+	out := make([]float32, 0, int(float64(frames)/r.step+2)*r.channels)
+	for r.pos < float64(frames) {
+		index := int(r.pos)
+		frac := float32(r.pos - float64(index))
+		for ch := 0; ch < r.channels; ch++ {
+			var a float32
+			if index == 0 {
+				a = r.last[ch]
+			} else {
+				a = in[(index-1)*r.channels+ch]
+			}
+			b := in[index*r.channels+ch]
+			out = append(out, a+(b-a)*frac)
+		}
+		r.pos += r.step
+	}
+
+	r.pos -= float64(frames)
+	copy(r.last, in[(frames-1)*r.channels:frames*r.channels])
+	return out
+}
@@ -0,0 +1,98 @@
+package audio
+
+import (
+	"fmt"
+	"math"
+	"sync"
+)
+
+const (
+	DefaultVolume = 100
+	// MaxVolume allows boosting quiet sources by up to +6dB.
+	MaxVolume = 200
+	// limiterKnee is where the soft limiter starts bending samples.
+	limiterKnee = 0.8
+)
+
+// Volume is a gain stage for 16-bit PCM, set in percent. Gain changes ramp
+// over one frame so they do not click, and boosted samples go through a soft
+// limiter instead of clipping.
+type Volume struct {
+	mu      sync.Mutex
+	percent int
+	gain    float64 // applied at the end of the last frame
+	limited uint64
+}
+
+func NewVolume() *Volume {
+	return &Volume{percent: DefaultVolume, gain: 1}
+}
+
+func (v *Volume) Set(percent int) error {
+	if percent < 0 || percent > MaxVolume {
+		return fmt.Errorf("volume must be between 0 and %d%%: %d", MaxVolume, percent)
+	}
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	v.percent = percent
+	return nil
+}
+
+func (v *Volume) Get() int {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.percent
+}
+
+// Limited counts samples the soft limiter had to bend.
+func (v *Volume) Limited() uint64 {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.limited
+}
+
+// Apply scales one frame of interleaved PCM in place. At 100% the samples
+// are left untouched.
+func (v *Volume) Apply(pcm []int16, channels int) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	start, target := v.gain, float64(v.percent)/100
+	v.gain = target
+	v.limited += applyGain(pcm, channels, start, target)
+}
+
+// applyGain scales a frame of interleaved PCM in place, ramping linearly
+// from start to target across it. Boosted samples above the knee are soft
+// limited; it returns how many were.
+func applyGain(pcm []int16, channels int, start, target float64) uint64 {
+	if start == 1 && target == 1 {
+		return 0
+	}
+
+	var limited uint64
+	frames := len(pcm) / channels
+	for i := range pcm {
+		gain := target
+		if start != target && frames > 0 {
+			gain = start + (target-start)*float64(i/channels+1)/float64(frames)
+		}
+		sample := float64(pcm[i]) / 32768 * gain
+		if gain > 1 && math.Abs(sample) > limiterKnee {
+			limited++
+			sample = softClip(sample)
+		}
+		pcm[i] = FloatToInt16(float32(sample))
+	}
+	return limited
+}
+
+// softClip bends samples above the knee smoothly toward full scale instead
+// of letting them wrap or hard clip.
+func softClip(v float64) float64 {
+	sign := 1.0
+	if v < 0 {
+		sign, v = -1, -v
+	}
+	return sign * (limiterKnee + (1-limiterKnee)*math.Tanh((v-limiterKnee)/(1-limiterKnee)))
+}
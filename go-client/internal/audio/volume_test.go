@@ -0,0 +1,65 @@
+package audio
+
+import "testing"
+
+func constantFrame(value int16, samples int) []int16 {
+	pcm := make([]int16, samples)
+	for i := range pcm {
+		pcm[i] = value
+	}
+	return pcm
+}
+
+func TestVolumeLeavesUnityUntouched(t *testing.T) {
+	v := NewVolume()
+	pcm := []int16{32767, -32768, 1}
+	v.Apply(pcm, 1)
+	if pcm[0] != 32767 || pcm[1] != -32768 || pcm[2] != 1 {
+		t.Errorf("Apply() at 100%% = %v, want the samples unchanged", pcm)
+	}
+}
+
+func TestVolumeRampsToNewGain(t *testing.T) {
+	v := NewVolume()
+	if err := v.Set(50); err != nil {
+		t.Fatalf("Set() error = %v", err)
+	}
+
+	// The first frame glides from full to half gain
+	pcm := constantFrame(10000, 960)
+	v.Apply(pcm, 2)
+	if pcm[0] < 9900 || pcm[len(pcm)-1] != 5000 {
+		t.Errorf("ramp went from %d to %d, want about 10000 down to 5000", pcm[0], pcm[len(pcm)-1])
+	}
+	if pcm[0] != pcm[1] {
+		t.Errorf("channels of the first frame = %d and %d, want the same gain", pcm[0], pcm[1])
+	}
+
+	pcm = constantFrame(10000, 960)
+	v.Apply(pcm, 2)
+	if pcm[0] != 5000 {
+		t.Errorf("second frame sample = %d, want 5000", pcm[0])
+	}
+}
+
+func TestVolumeSoftLimitsBoost(t *testing.T) {
+	v := NewVolume()
+	v.Set(MaxVolume)
+	v.Apply(constantFrame(0, 960), 1)
+
+	pcm := constantFrame(20000, 960)
+	v.Apply(pcm, 1)
+	if pcm[0] <= 20000 || pcm[0] >= 32767 {
+		t.Errorf("boosted sample = %d, want louder but below full scale", pcm[0])
+	}
+	if v.Limited() == 0 {
+		t.Error("Limited() should count limited samples")
+	}
+
+	if err := v.Set(MaxVolume + 1); err == nil {
+		t.Error("Set() should reject volumes above MaxVolume")
+	}
+	if v.Get() != MaxVolume {
+		t.Errorf("Get() = %d, want %d after a rejected Set()", v.Get(), MaxVolume)
+	}
+}
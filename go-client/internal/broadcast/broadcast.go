@@ -0,0 +1,283 @@
+package broadcast
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
+// Stream formats
+const (
+	FormatOgg = "ogg"
+	FormatWAV = "wav"
+)
+
+// ListenerStats describes one connected HTTP listener.
+type ListenerStats struct {
+	ID        int
+	Format    string
+	Address   string
+	Connected time.Time
+	Dropped   uint64
+}
+
+// Server streams the audio sent to Discord to HTTP listeners, as Ogg Opus
+// made from the same packets or as WAV made from the PCM they were encoded
+// from. Every listener has its own buffer, so a slow one loses audio
+// instead of holding up Discord.
+type Server struct {
+	channels  int
+	mu        sync.Mutex
+	listeners map[*listener]struct{}
+	nextID    int
+}
+
+type listener struct {
+	ListenerStats
+	frames  chan []byte
+	dropped atomic.Uint64
+}
+
+// streamWriter turns frames into a listener's stream.
+type streamWriter interface {
+	WriteFrame(frame []byte) error
+	Flush() error
+}
+
+func NewServer(channels int) *Server {
+	return &Server{
+		channels:  channels,
+		listeners: make(map[*listener]struct{}),
+	}
+}
+
+// WritePacket sends an Opus packet to the Ogg listeners. It never blocks.
+func (s *Server) WritePacket(packet []byte) {
+	if _, err := opus.PacketDuration(packet); err != nil {
+		return
+	}
+	s.publish(FormatOgg, func() []byte { return append([]byte(nil), packet...) })
+}
+
+// WritePCM sends a frame of interleaved samples to the WAV listeners. It
+// never blocks.
+func (s *Server) WritePCM(pcm []int16) {
+	s.publish(FormatWAV, func() []byte {
+		frame := make([]byte, len(pcm)*2)
+		for i, sample := range pcm {
+			binary.LittleEndian.PutUint16(frame[i*2:], uint16(sample))
+		}
+		return frame
+	})
+}
+
+// publish offers a frame, built only if someone listens, to every listener
+// of the format.
+func (s *Server) publish(format string, build func() []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var frame []byte
+	for l := range s.listeners {
+		if l.Format != format {
+			continue
+		}
+		if frame == nil {
+			frame = build()
+		}
+		l.offer(frame)
+	}
+}
+
+// offer queues a frame, dropping the oldest one when the listener has
+// fallen too far behind.
+func (l *listener) offer(frame []byte) {
+	for {
+		select {
+		case l.frames <- frame:
+			return
+		default:
+		}
+		select {
+		case <-l.frames:
+			l.dropped.Add(1)
+		default:
+		}
+	}
+}
+
+func (l *listener) next() ([]byte, bool) {
+	select {
+	case frame := <-l.frames:
+		return frame, true
+	default:
+		return nil, false
+	}
+}
+
+// Listeners reports the connected listeners in the order they connected.
+func (s *Server) Listeners() []ListenerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stats := make([]ListenerStats, 0, len(s.listeners))
+	for l := range s.listeners {
+		stat := l.ListenerStats
+		stat.Dropped = l.dropped.Load()
+		stats = append(stats, stat)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].ID < stats[j].ID })
+	return stats
+}
+
+// Handler serves /stream.ogg and /stream.wav on their own, so they can be
+// offered to other machines without the web UI.
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/stream.ogg", s.ServeOgg)
+	mux.HandleFunc("/stream.wav", s.ServeWAV)
+	return mux
+}
+
+// ServeOgg streams Ogg Opus until the listener goes away.
+func (s *Server) ServeOgg(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, FormatOgg, constants.ContentTypeOgg, func(w io.Writer) (streamWriter, error) {
+		ogg, err := opus.NewOggWriter(w, s.channels, constants.ApplicationName+" "+constants.ApplicationVersion)
+		if err != nil {
+			return nil, err
+		}
+		return oggStream{ogg}, nil
+	})
+}
+
+// ServeWAV streams 16-bit 48kHz WAV until the listener goes away.
+func (s *Server) ServeWAV(w http.ResponseWriter, r *http.Request) {
+	s.serve(w, r, FormatWAV, constants.ContentTypeWAV, func(w io.Writer) (streamWriter, error) {
+		if _, err := w.Write(wavHeader(s.channels)); err != nil {
+			return nil, err
+		}
+		return wavStream{w}, nil
+	})
+}
+
+func (s *Server) serve(w http.ResponseWriter, r *http.Request, format, contentType string, open func(io.Writer) (streamWriter, error)) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "no-cache, no-store")
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	l, err := s.subscribe(format, r.RemoteAddr)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	defer s.unsubscribe(l)
+
+	rc := http.NewResponseController(w)
+	stream, err := open(w)
+	if err != nil {
+		return
+	}
+	for {
+		if err := rc.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+			return
+		}
+
+		select {
+		case <-r.Context().Done():
+			return
+		case frame := <-l.frames:
+			// A listener that stops reading is dropped rather than kept
+			// around with a full buffer
+			rc.SetWriteDeadline(time.Now().Add(constants.ListenerWriteTimeout))
+			for ok := true; ok; frame, ok = l.next() {
+				if err := stream.WriteFrame(frame); err != nil {
+					return
+				}
+			}
+			if err := stream.Flush(); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *Server) subscribe(format, address string) (*listener, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.listeners) >= constants.MaxStreamListeners {
+		return nil, fmt.Errorf("too many listeners")
+	}
+	s.nextID++
+	l := &listener{
+		ListenerStats: ListenerStats{ID: s.nextID, Format: format, Address: address, Connected: time.Now()},
+		frames:        make(chan []byte, constants.ListenerBufferFrames),
+	}
+	s.listeners[l] = struct{}{}
+	log.Printf("Stream listener %d connected from %s (%s)", l.ID, address, format)
+	return l, nil
+}
+
+func (s *Server) unsubscribe(l *listener) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.listeners, l)
+	log.Printf("Stream listener %d disconnected after %v", l.ID, time.Since(l.Connected).Round(time.Second))
+}
+
+type oggStream struct {
+	*opus.OggWriter
+}
+
+func (o oggStream) WriteFrame(frame []byte) error {
+	return o.WritePacket(frame)
+}
+
+type wavStream struct {
+	w io.Writer
+}
+
+func (s wavStream) WriteFrame(frame []byte) error {
+	_, err := s.w.Write(frame)
+	return err
+}
+
+func (s wavStream) Flush() error {
+	return nil
+}
+
+// wavHeader describes an endless 16-bit PCM stream; the sizes are left at
+// their maximum since the length is not known.
+func wavHeader(channels int) []byte {
+	header := make([]byte, 44)
+	copy(header, "RIFF")
+	binary.LittleEndian.PutUint32(header[4:], 0xFFFFFFFF)
+	copy(header[8:], "WAVEfmt ")
+	binary.LittleEndian.PutUint32(header[16:], 16)
+	binary.LittleEndian.PutUint16(header[20:], 1) // PCM
+	binary.LittleEndian.PutUint16(header[22:], uint16(channels))
+	binary.LittleEndian.PutUint32(header[24:], constants.SampleRate)
+	binary.LittleEndian.PutUint32(header[28:], uint32(constants.SampleRate*channels*constants.PCMBytesPerSample))
+	binary.LittleEndian.PutUint16(header[32:], uint16(channels*constants.PCMBytesPerSample))
+	binary.LittleEndian.PutUint16(header[34:], 16)
+	copy(header[36:], "data")
+	binary.LittleEndian.PutUint32(header[40:], 0xFFFFFFFF)
+	return header
+}
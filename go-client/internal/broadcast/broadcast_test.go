@@ -0,0 +1,128 @@
+package broadcast
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+// listen connects to url and waits until the server has the listener.
+func listen(t *testing.T, s *Server, url string) *http.Response {
+	t.Helper()
+	resp, err := http.Get(url)
+	if err != nil {
+		t.Fatalf("GET %s error = %v", url, err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET %s returned %d", url, resp.StatusCode)
+	}
+	for deadline := time.Now().Add(time.Second); len(s.Listeners()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("listener was not registered")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	return resp
+}
+
+func TestServeOgg(t *testing.T) {
+	s := NewServer(2)
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	resp := listen(t, s, server.URL+"/stream.ogg")
+	if got := resp.Header.Get("Content-Type"); got != constants.ContentTypeOgg {
+		t.Errorf("Content-Type = %q, want %q", got, constants.ContentTypeOgg)
+	}
+
+	s.WritePacket([]byte{0xFF}) // not a valid packet, never sent
+	s.WritePacket([]byte{31<<3 | 0x4, 0xaa, 0xbb})
+	s.WritePCM(make([]int16, 1920)) // only for WAV listeners
+
+	// OpusHead, OpusTags and one page with the packet
+	data := make([]byte, 0, 256)
+	buf := make([]byte, 256)
+	for bytes.Count(data, []byte("OggS")) < 3 || !bytes.HasSuffix(data, []byte{0xaa, 0xbb}) {
+		n, err := resp.Body.Read(buf)
+		if err != nil {
+			t.Fatalf("read error = %v after %q", err, data)
+		}
+		data = append(data, buf[:n]...)
+	}
+	if !bytes.Contains(data, []byte("OpusHead")) || !bytes.Contains(data, []byte("OpusTags")) {
+		t.Errorf("stream = %q, want the Opus headers first", data)
+	}
+}
+
+func TestServeWAV(t *testing.T) {
+	s := NewServer(1)
+	server := httptest.NewServer(s.Handler())
+	t.Cleanup(server.Close)
+
+	resp := listen(t, s, server.URL+"/stream.wav")
+	s.WritePCM([]int16{1000, -1000})
+
+	data := make([]byte, 48)
+	if _, err := io.ReadFull(resp.Body, data); err != nil {
+		t.Fatalf("read error = %v", err)
+	}
+	if string(data[:4]) != "RIFF" || string(data[36:40]) != "data" || binary.LittleEndian.Uint16(data[22:]) != 1 {
+		t.Errorf("header = %q, want a mono WAV header", data[:44])
+	}
+	if int16(binary.LittleEndian.Uint16(data[44:])) != 1000 || int16(binary.LittleEndian.Uint16(data[46:])) != -1000 {
+		t.Errorf("samples = %v, want 1000 and -1000", data[44:])
+	}
+}
+
+func TestSlowListenerDoesNotBlock(t *testing.T) {
+	s := NewServer(2)
+	slow, err := s.subscribe(FormatOgg, "slow")
+	if err != nil {
+		t.Fatalf("subscribe() error = %v", err)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 200; i++ {
+			s.WritePacket([]byte{31 << 3, byte(i)})
+		}
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("WritePacket() blocked on a listener that does not read")
+	}
+
+	if stats := s.Listeners(); len(stats) != 1 || stats[0].Dropped != 200-constants.ListenerBufferFrames {
+		t.Errorf("Listeners() = %+v, want the oldest packets dropped", stats)
+	}
+	// The newest audio is kept
+	var last []byte
+	for frame, ok := slow.next(); ok; frame, ok = slow.next() {
+		last = frame
+	}
+	if last[1] != 199 {
+		t.Errorf("last queued packet = %v, want the newest", last)
+	}
+}
+
+func TestServeRejectsTooManyListeners(t *testing.T) {
+	s := NewServer(2)
+	for i := 0; i < constants.MaxStreamListeners; i++ {
+		s.subscribe(FormatWAV, "test")
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeWAV(rr, httptest.NewRequest("GET", "/stream.wav", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("status = %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+}
@@ -2,8 +2,15 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"os"
+	"path/filepath"
 	"strconv"
+	"time"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
 )
 
 type Config struct {
@@ -11,6 +18,28 @@ type Config struct {
 	WebSocketPort   string
 	WebPort         string
 	AuthAPIURL      string
+	AudioChannels   int
+	AudioSource     string
+	ProcessCommand  string
+	ProcessFormat   audio.Format
+	MixClients      bool
+	SettingsPath    string
+	Normalize       bool
+	LoudnessTarget  float64
+	Filters         []audio.FilterSpec
+	SilenceHold     time.Duration
+	RecordingDir    string
+	MediaDir        string
+	StreamAddress   string
+	JitterTarget    time.Duration
+	JitterMin       time.Duration
+	JitterMax       time.Duration
+	OpusBitrate     int
+	OpusComplexity  int
+	OpusFEC         bool
+	OpusPacketLoss  int
+	OpusDTX         bool
+	OpusApplication opus.Application
 }
 
 func Load() (*Config, error) {
@@ -19,7 +48,87 @@ func Load() (*Config, error) {
 		WebPort:         getEnvOrDefault("WEB_PORT", "48766"),
 		AuthAPIURL:      getEnvOrDefault("AUTH_API_URL", "https://m0j3mh0nyj.execute-api.ap-northeast-1.amazonaws.com/prod"),
 		DiscordBotToken: os.Getenv("DISCORD_BOT_TOKEN"), // Optional, will be fetched from auth server
+		AudioSource:     getEnvOrDefault("AUDIO_SOURCE", constants.SourceExtension),
+		ProcessCommand:  os.Getenv("PROCESS_COMMAND"),
+		SettingsPath:    os.Getenv("SETTINGS_PATH"),  // Defaults to DefaultSettingsPath()
+		RecordingDir:    os.Getenv("RECORDING_DIR"),  // Defaults to DefaultRecordingDir()
+		MediaDir:        os.Getenv("MEDIA_DIR"),      // Defaults to DefaultMediaDir()
+		StreamAddress:   os.Getenv("STREAM_ADDRESS"), // Optional, serves the listener streams beyond localhost
+	}
+
+	channels, err := strconv.Atoi(getEnvOrDefault("AUDIO_CHANNELS", strconv.Itoa(constants.DefaultChannels)))
+	if err != nil || (channels != constants.MonoChannels && channels != constants.StereoChannels) {
+		return nil, fmt.Errorf("invalid audio channel count: must be 1 (mono) or 2 (stereo)")
+	}
+	config.AudioChannels = channels
+
+	// Extension clients take turns unless mixing is enabled
+	if value := os.Getenv("MIX_EXTENSION_CLIENTS"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("MIX_EXTENSION_CLIENTS must be true or false: %s", value)
+		}
+		config.MixClients = enabled
+	}
+
+	if err := loadLoudness(config); err != nil {
+		return nil, err
+	}
+
+	if value := os.Getenv("DSP_FILTERS"); value != "" {
+		filters, err := audio.ParseFilterSpecs(value)
+		if err == nil {
+			err = audio.NewFilterChain().Set(filters)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("DSP_FILTERS: %v", err)
+		}
+		config.Filters = filters
+	}
+
+	processFormat, err := loadProcessFormat()
+	if err != nil {
+		return nil, err
+	}
+	config.ProcessFormat = processFormat
+
+	jitter := audio.DefaultJitterConfig()
+	for _, setting := range []struct {
+		key    string
+		target *time.Duration
+	}{
+		{"JITTER_TARGET_MS", &jitter.Target},
+		{"JITTER_MIN_MS", &jitter.Min},
+		{"JITTER_MAX_MS", &jitter.Max},
+	} {
+		value, err := getMillisecondsOrDefault(setting.key, *setting.target)
+		if err != nil {
+			return nil, err
+		}
+		*setting.target = value
+	}
+	if err := jitter.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid jitter buffer settings: %v", err)
 	}
+	config.JitterTarget = jitter.Target
+	config.JitterMin = jitter.Min
+	config.JitterMax = jitter.Max
+
+	config.SilenceHold, err = getMillisecondsOrDefault("SILENCE_HOLD_MS", constants.SilenceHoldTime)
+	if err != nil {
+		return nil, err
+	}
+
+	encoder, err := loadEncoderOptions()
+	if err != nil {
+		return nil, err
+	}
+	config.OpusBitrate = encoder.Bitrate
+	config.OpusComplexity = encoder.Complexity
+	config.OpusFEC = encoder.FEC
+	config.OpusPacketLoss = encoder.PacketLossPercent
+	config.OpusDTX = encoder.DTX
+	config.OpusApplication = encoder.Application
 
 	// Validate ports
 	if err := validatePort(config.WebSocketPort); err != nil {
@@ -28,10 +137,39 @@ func Load() (*Config, error) {
 	if err := validatePort(config.WebPort); err != nil {
 		return nil, fmt.Errorf("invalid web port: %v", err)
 	}
+	if config.StreamAddress != "" {
+		_, port, err := net.SplitHostPort(config.StreamAddress)
+		if err == nil {
+			err = validatePort(port)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid STREAM_ADDRESS: %v", err)
+		}
+	}
 
 	return config, nil
 }
 
+// DefaultRecordingDir returns where recordings are saved unless
+// RECORDING_DIR says otherwise.
+func DefaultRecordingDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, constants.RecordingParentDirectory, constants.RecordingDirectory), nil
+}
+
+// DefaultMediaDir returns the directory files can be queued from unless
+// MEDIA_DIR says otherwise.
+func DefaultMediaDir() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, constants.MediaDirectory), nil
+}
+
 func getEnvOrDefault(key, defaultValue string) string {
 	if value := os.Getenv(key); value != "" {
 		return value
@@ -39,6 +177,151 @@ func getEnvOrDefault(key, defaultValue string) string {
 	return defaultValue
 }
 
+// loadProcessFormat reads the PCM format PROCESS_COMMAND writes to stdout,
+// 48kHz stereo s16 unless configured otherwise.
+func loadProcessFormat() (audio.Format, error) {
+	format := audio.PipelineFormat(constants.StereoChannels)
+	for _, setting := range []struct {
+		key    string
+		target *int
+	}{
+		{"PROCESS_SAMPLE_RATE", &format.SampleRate},
+		{"PROCESS_CHANNELS", &format.Channels},
+	} {
+		value := os.Getenv(setting.key)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return format, fmt.Errorf("%s must be a number: %s", setting.key, value)
+		}
+		*setting.target = number
+	}
+	if value := os.Getenv("PROCESS_SAMPLE_FORMAT"); value != "" {
+		encoding, err := audio.ParseEncoding(value)
+		if err != nil {
+			return format, fmt.Errorf("invalid PROCESS_SAMPLE_FORMAT: %v", err)
+		}
+		format.Encoding = encoding
+	}
+
+	if err := format.Validate(); err != nil {
+		return format, fmt.Errorf("invalid process audio format: %v", err)
+	}
+	return format, nil
+}
+
+// loadLoudness reads whether to normalize loudness and the target in LUFS.
+func loadLoudness(config *Config) error {
+	config.LoudnessTarget = audio.DefaultLoudnessTarget
+	if value := os.Getenv("LOUDNESS_NORMALIZE"); value != "" {
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("LOUDNESS_NORMALIZE must be true or false: %s", value)
+		}
+		config.Normalize = enabled
+	}
+	if value := os.Getenv("LOUDNESS_TARGET"); value != "" {
+		target, err := strconv.ParseFloat(value, 64)
+		if err != nil || target < audio.MinLoudnessTarget || target > audio.MaxLoudnessTarget {
+			return fmt.Errorf("LOUDNESS_TARGET must be between %.0f and %.0f LUFS: %s", audio.MinLoudnessTarget, audio.MaxLoudnessTarget, value)
+		}
+		config.LoudnessTarget = target
+	}
+	return nil
+}
+
+func loadEncoderOptions() (opus.Options, error) {
+	options := opus.DefaultOptions()
+	if value := os.Getenv("OPUS_BITRATE"); value != "" {
+		bitrate, err := opus.ParseBitrate(value)
+		if err != nil {
+			return options, fmt.Errorf("invalid OPUS_BITRATE: %v", err)
+		}
+		options.Bitrate = bitrate
+	}
+	for _, setting := range []struct {
+		key    string
+		target *int
+	}{
+		{"OPUS_COMPLEXITY", &options.Complexity},
+		{"OPUS_PACKET_LOSS", &options.PacketLossPercent},
+	} {
+		value := os.Getenv(setting.key)
+		if value == "" {
+			continue
+		}
+		number, err := strconv.Atoi(value)
+		if err != nil {
+			return options, fmt.Errorf("%s must be a number: %s", setting.key, value)
+		}
+		*setting.target = number
+	}
+	for _, setting := range []struct {
+		key    string
+		target *bool
+	}{
+		{"OPUS_FEC", &options.FEC},
+		{"OPUS_DTX", &options.DTX},
+	} {
+		value := os.Getenv(setting.key)
+		if value == "" {
+			continue
+		}
+		enabled, err := strconv.ParseBool(value)
+		if err != nil {
+			return options, fmt.Errorf("%s must be true or false: %s", setting.key, value)
+		}
+		*setting.target = enabled
+	}
+	if value := os.Getenv("OPUS_APPLICATION"); value != "" {
+		application, err := opus.ParseApplication(value)
+		if err != nil {
+			return options, fmt.Errorf("invalid OPUS_APPLICATION: %v", err)
+		}
+		options.Application = application
+	}
+
+	if err := options.Validate(0); err != nil {
+		return options, fmt.Errorf("invalid Opus encoder settings: %v", err)
+	}
+	return options, nil
+}
+
+func getMillisecondsOrDefault(key string, defaultValue time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue, nil
+	}
+	ms, err := strconv.Atoi(value)
+	if err != nil || ms < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number of milliseconds: %s", key, value)
+	}
+	return time.Duration(ms) * time.Millisecond, nil
+}
+
+// JitterConfig returns the configured jitter buffer latency bounds.
+func (c *Config) JitterConfig() audio.JitterConfig {
+	return audio.JitterConfig{
+		Target: c.JitterTarget,
+		Min:    c.JitterMin,
+		Max:    c.JitterMax,
+	}
+}
+
+// EncoderOptions returns the configured Opus encoder parameters.
+func (c *Config) EncoderOptions() opus.Options {
+	return opus.Options{
+		Bitrate:           c.OpusBitrate,
+		Complexity:        c.OpusComplexity,
+		FEC:               c.OpusFEC,
+		PacketLossPercent: c.OpusPacketLoss,
+		DTX:               c.OpusDTX,
+		Application:       c.OpusApplication,
+	}
+}
+
 func validatePort(port string) error {
 	portNum, err := strconv.Atoi(port)
 	if err != nil {
@@ -2,7 +2,12 @@ package config
 
 import (
 	"os"
+	"reflect"
 	"testing"
+	"time"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/opus"
 )
 
 func TestLoad(t *testing.T) {
@@ -20,26 +25,188 @@ func TestLoad(t *testing.T) {
 				WebPort:         "48766",
 				DiscordBotToken: "",
 				AuthAPIURL:      "https://m0j3mh0nyj.execute-api.ap-northeast-1.amazonaws.com/prod",
+				AudioChannels:   2,
+				AudioSource:     "extension",
+				LoudnessTarget:  -16,
+				ProcessFormat:   audio.Format{SampleRate: 48000, Channels: 2, Encoding: audio.EncodingInt16},
+				SilenceHold:     time.Second,
+				JitterTarget:    60 * time.Millisecond,
+				JitterMin:       40 * time.Millisecond,
+				JitterMax:       200 * time.Millisecond,
+				OpusBitrate:     0,
+				OpusComplexity:  10,
+				OpusApplication: opus.ApplicationMusic,
 			},
 			wantErr: false,
 		},
 		{
 			name: "custom values from env",
 			envVars: map[string]string{
-				"WEBSOCKET_PORT":    "9000",
-				"WEB_PORT":          "9001",
-				"DISCORD_BOT_TOKEN": "test-token",
-				"AUTH_API_URL":      "https://custom.auth.com",
-				"DISCORD_CLIENT_ID": "123456789",
+				"WEBSOCKET_PORT":        "9000",
+				"WEB_PORT":              "9001",
+				"DISCORD_BOT_TOKEN":     "test-token",
+				"AUTH_API_URL":          "https://custom.auth.com",
+				"DISCORD_CLIENT_ID":     "123456789",
+				"AUDIO_CHANNELS":        "1",
+				"AUDIO_SOURCE":          "tone",
+				"MIX_EXTENSION_CLIENTS": "true",
+				"LOUDNESS_NORMALIZE":    "true",
+				"LOUDNESS_TARGET":       "-14",
+				"DSP_FILTERS":           "highpass:freq=80;compressor:ratio=3",
+				"SILENCE_HOLD_MS":       "0",
+				"RECORDING_DIR":         "/tmp/recordings",
+				"MEDIA_DIR":             "/tmp/music",
+				"STREAM_ADDRESS":        "0.0.0.0:48767",
+				"JITTER_TARGET_MS":      "100",
+				"JITTER_MIN_MS":         "60",
+				"JITTER_MAX_MS":         "400",
+				"OPUS_BITRATE":          "96000",
+				"OPUS_COMPLEXITY":       "8",
+				"OPUS_FEC":              "true",
+				"OPUS_PACKET_LOSS":      "5",
+				"OPUS_DTX":              "1",
+				"OPUS_APPLICATION":      "voice",
+				"PROCESS_COMMAND":       "ffmpeg -i input.mp3 -f f32le -ar 44100 -ac 1 -",
+				"PROCESS_SAMPLE_RATE":   "44100",
+				"PROCESS_CHANNELS":      "1",
+				"PROCESS_SAMPLE_FORMAT": "f32",
 			},
 			want: &Config{
 				WebSocketPort:   "9000",
 				WebPort:         "9001",
 				DiscordBotToken: "test-token",
 				AuthAPIURL:      "https://custom.auth.com",
+				AudioChannels:   1,
+				AudioSource:     "tone",
+				MixClients:      true,
+				Normalize:       true,
+				LoudnessTarget:  -14,
+				Filters: []audio.FilterSpec{
+					{Type: "highpass", Params: map[string]float64{"freq": 80}},
+					{Type: "compressor", Params: map[string]float64{"ratio": 3}},
+				},
+				RecordingDir:    "/tmp/recordings",
+				MediaDir:        "/tmp/music",
+				StreamAddress:   "0.0.0.0:48767",
+				ProcessCommand:  "ffmpeg -i input.mp3 -f f32le -ar 44100 -ac 1 -",
+				ProcessFormat:   audio.Format{SampleRate: 44100, Channels: 1, Encoding: audio.EncodingFloat32},
+				JitterTarget:    100 * time.Millisecond,
+				JitterMin:       60 * time.Millisecond,
+				JitterMax:       400 * time.Millisecond,
+				OpusBitrate:     96000,
+				OpusComplexity:  8,
+				OpusFEC:         true,
+				OpusPacketLoss:  5,
+				OpusDTX:         true,
+				OpusApplication: opus.ApplicationVoice,
 			},
 			wantErr: false,
 		},
+		{
+			name: "invalid audio channels",
+			envVars: map[string]string{
+				"AUDIO_CHANNELS": "6",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "jitter buffer target outside bounds",
+			envVars: map[string]string{
+				"JITTER_TARGET_MS": "500",
+				"JITTER_MAX_MS":    "200",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "jitter buffer not a number",
+			envVars: map[string]string{
+				"JITTER_MIN_MS": "fast",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "opus bitrate out of range",
+			envVars: map[string]string{
+				"OPUS_BITRATE": "1000000",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "opus bitrate not a number",
+			envVars: map[string]string{
+				"OPUS_BITRATE": "fast",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "opus fec not a boolean",
+			envVars: map[string]string{
+				"OPUS_FEC": "sometimes",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unknown opus application",
+			envVars: map[string]string{
+				"OPUS_APPLICATION": "lowdelay",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "loudness target out of range",
+			envVars: map[string]string{
+				"LOUDNESS_TARGET": "-3",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "negative silence hold",
+			envVars: map[string]string{
+				"SILENCE_HOLD_MS": "-5",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unknown DSP filter",
+			envVars: map[string]string{
+				"DSP_FILTERS": "highpass;reverb:mix=0.5",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "mix extension clients not a boolean",
+			envVars: map[string]string{
+				"MIX_EXTENSION_CLIENTS": "some",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "unsupported process sample format",
+			envVars: map[string]string{
+				"PROCESS_SAMPLE_FORMAT": "s24",
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "process channels out of range",
+			envVars: map[string]string{
+				"PROCESS_CHANNELS": "6",
+			},
+			want:    nil,
+			wantErr: true,
+		},
 		{
 			name:    "load from .env file",
 			envVars: map[string]string{
@@ -84,6 +251,45 @@ func TestLoad(t *testing.T) {
 				if got.AuthAPIURL != tt.want.AuthAPIURL {
 					t.Errorf("Load() AuthAPIURL = %v, want %v", got.AuthAPIURL, tt.want.AuthAPIURL)
 				}
+				if got.AudioSource != tt.want.AudioSource {
+					t.Errorf("Load() AudioSource = %v, want %v", got.AudioSource, tt.want.AudioSource)
+				}
+				if got.ProcessCommand != tt.want.ProcessCommand {
+					t.Errorf("Load() ProcessCommand = %v, want %v", got.ProcessCommand, tt.want.ProcessCommand)
+				}
+				if got.ProcessFormat != tt.want.ProcessFormat {
+					t.Errorf("Load() ProcessFormat = %v, want %v", got.ProcessFormat, tt.want.ProcessFormat)
+				}
+				if got.AudioChannels != tt.want.AudioChannels {
+					t.Errorf("Load() AudioChannels = %v, want %v", got.AudioChannels, tt.want.AudioChannels)
+				}
+				if got.MixClients != tt.want.MixClients {
+					t.Errorf("Load() MixClients = %v, want %v", got.MixClients, tt.want.MixClients)
+				}
+				if got.Normalize != tt.want.Normalize || got.LoudnessTarget != tt.want.LoudnessTarget {
+					t.Errorf("Load() loudness = %v %v, want %v %v", got.Normalize, got.LoudnessTarget, tt.want.Normalize, tt.want.LoudnessTarget)
+				}
+				if got.SilenceHold != tt.want.SilenceHold {
+					t.Errorf("Load() SilenceHold = %v, want %v", got.SilenceHold, tt.want.SilenceHold)
+				}
+				if got.RecordingDir != tt.want.RecordingDir {
+					t.Errorf("Load() RecordingDir = %v, want %v", got.RecordingDir, tt.want.RecordingDir)
+				}
+				if got.MediaDir != tt.want.MediaDir {
+					t.Errorf("Load() MediaDir = %v, want %v", got.MediaDir, tt.want.MediaDir)
+				}
+				if got.StreamAddress != tt.want.StreamAddress {
+					t.Errorf("Load() StreamAddress = %v, want %v", got.StreamAddress, tt.want.StreamAddress)
+				}
+				if !reflect.DeepEqual(got.Filters, tt.want.Filters) {
+					t.Errorf("Load() Filters = %+v, want %+v", got.Filters, tt.want.Filters)
+				}
+				if got.JitterConfig() != tt.want.JitterConfig() {
+					t.Errorf("Load() JitterConfig = %+v, want %+v", got.JitterConfig(), tt.want.JitterConfig())
+				}
+				if got.EncoderOptions() != tt.want.EncoderOptions() {
+					t.Errorf("Load() EncoderOptions = %+v, want %+v", got.EncoderOptions(), tt.want.EncoderOptions())
+				}
 			}
 
 			// Restore original env vars
@@ -0,0 +1,129 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+// Settings are preferences changed at runtime and kept between launches in
+// a JSON file.
+type Settings struct {
+	mu     sync.Mutex
+	path   string
+	guilds map[string]GuildSettings
+}
+
+// GuildSettings are kept separately for each Discord server.
+type GuildSettings struct {
+	Volume int `json:"volume"`
+}
+
+func defaultGuildSettings() GuildSettings {
+	return GuildSettings{Volume: audio.DefaultVolume}
+}
+
+// UnmarshalJSON fills in defaults for fields missing from older files.
+func (g *GuildSettings) UnmarshalJSON(data []byte) error {
+	type plain GuildSettings
+	settings := plain(defaultGuildSettings())
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return err
+	}
+	*g = GuildSettings(settings)
+	return nil
+}
+
+type settingsFile struct {
+	Guilds map[string]GuildSettings `json:"guilds"`
+}
+
+// DefaultSettingsPath returns where settings are stored unless
+// SETTINGS_PATH says otherwise.
+func DefaultSettingsPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, constants.SettingsDirectory, constants.SettingsFileName), nil
+}
+
+// NewSettings returns empty settings saved to path. With an empty path they
+// are only kept in memory.
+func NewSettings(path string) *Settings {
+	return &Settings{
+		path:   path,
+		guilds: make(map[string]GuildSettings),
+	}
+}
+
+// LoadSettings reads settings from path. A missing file gives empty settings.
+func LoadSettings(path string) (*Settings, error) {
+	settings := NewSettings(path)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return settings, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var file settingsFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("invalid settings file %s: %v", path, err)
+	}
+	for id, guild := range file.Guilds {
+		settings.guilds[id] = guild
+	}
+	return settings, nil
+}
+
+// Guild returns a server's settings, or the defaults if none were saved.
+func (s *Settings) Guild(id string) GuildSettings {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if guild, ok := s.guilds[id]; ok {
+		return guild
+	}
+	return defaultGuildSettings()
+}
+
+// UpdateGuild changes a server's settings and saves them.
+func (s *Settings) UpdateGuild(id string, update func(guild *GuildSettings)) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	guild, ok := s.guilds[id]
+	if !ok {
+		guild = defaultGuildSettings()
+	}
+	update(&guild)
+	s.guilds[id] = guild
+	return s.save()
+}
+
+// save writes the settings to a temporary file first so a crash cannot
+// leave a truncated file behind. Callers must hold the mutex.
+func (s *Settings) save() error {
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(settingsFile{Guilds: s.guilds}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), constants.LogDirPermission); err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, data, constants.SettingsFilePermission); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
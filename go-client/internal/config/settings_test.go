@@ -0,0 +1,56 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSettingsPersistPerGuild(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trunecord", "settings.json")
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() on a missing file error = %v", err)
+	}
+	if volume := settings.Guild("1").Volume; volume != 100 {
+		t.Errorf("default volume = %d, want 100", volume)
+	}
+
+	if err := settings.UpdateGuild("1", func(guild *GuildSettings) { guild.Volume = 60 }); err != nil {
+		t.Fatalf("UpdateGuild() error = %v", err)
+	}
+
+	loaded, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if volume := loaded.Guild("1").Volume; volume != 60 {
+		t.Errorf("reloaded volume = %d, want 60", volume)
+	}
+	if volume := loaded.Guild("2").Volume; volume != 100 {
+		t.Errorf("other guild's volume = %d, want the default", volume)
+	}
+}
+
+func TestLoadSettingsDefaultsMissingFields(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.WriteFile(path, []byte(`{"guilds": {"1": {}}}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	settings, err := LoadSettings(path)
+	if err != nil {
+		t.Fatalf("LoadSettings() error = %v", err)
+	}
+	if volume := settings.Guild("1").Volume; volume != 100 {
+		t.Errorf("volume = %d, want the default for a field missing from the file", volume)
+	}
+
+	if err := os.WriteFile(path, []byte(`{"guilds": `), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadSettings(path); err == nil {
+		t.Error("LoadSettings() should reject a corrupt file")
+	}
+}
@@ -17,10 +17,18 @@ const (
 	WebSocketTickerInterval  = 1 * time.Second
 	AudioFrameInterval       = 20 * time.Millisecond
 	StreamingTimeoutDuration = 10 * time.Second
+	LiveClientIdleTimeout    = 5 * time.Second
 	BrowserOpenDelay         = 1 * time.Second
 	VoiceConnectionWaitDelay = 50 * time.Millisecond
 	HttpClientTimeout        = 10 * time.Second
 	InitialStreamingDelay    = 20 * time.Millisecond
+	StreamStopTimeout        = 500 * time.Millisecond
+	SilenceHoldTime          = 1 * time.Second
+	ListenerWriteTimeout     = 5 * time.Second
+	TargetStallTimeout       = 1 * time.Second
+	JitterTargetLatency      = 60 * time.Millisecond
+	JitterMinLatency         = 40 * time.Millisecond
+	JitterMaxLatency         = 200 * time.Millisecond
 )
 
 // Buffer sizes
@@ -33,19 +41,49 @@ const (
 	PCMBufferMultiplier      = 10
 	WebSocketReadBufferSize  = 1024
 	WebSocketWriteBufferSize = 1024
+	// ListenerBufferFrames is how many frames an HTTP stream listener may
+	// fall behind before its oldest ones are dropped.
+	ListenerBufferFrames = 50
+	MaxStreamListeners   = 16
 )
 
 // Audio constants
 const (
-	OpusBitrate  = 128000
-	SampleRate   = 48000
-	MonoChannels = 1
+	OpusBitrate     = 128000
+	SampleRate      = 48000
+	MonoChannels    = 1
+	StereoChannels  = 2
+	DefaultChannels = StereoChannels
+	MaxOpusPacket   = 4000
+	// TrailingSilenceFrames is how many Opus silence frames Discord expects
+	// whenever transmission stops, so the next packets are not interpolated
+	// with the last ones.
+	TrailingSilenceFrames = 5
+
+	OpusMinBitrate    = 6000
+	OpusMaxBitrate    = 510000
+	OpusComplexity    = 10
+	OpusMaxComplexity = 10
 )
 
+// PCMFrameSamples returns the number of interleaved samples in one 20ms frame
+// for the given channel count.
+func PCMFrameSamples(channels int) int {
+	return PCMFrameSize * channels
+}
+
+// PCMFrameBytes returns the size in bytes of one 20ms interleaved PCM frame
+// for the given channel count.
+func PCMFrameBytes(channels int) int {
+	return PCMFrameSizeBytes * channels
+}
+
 // HTTP constants
 const (
 	ContentTypeJSON     = "application/json"
 	ContentTypeHTML     = "text/html; charset=utf-8"
+	ContentTypeOgg      = "audio/ogg"
+	ContentTypeWAV      = "audio/wav"
 	AuthorizationHeader = "Authorization"
 	AcceptHeader        = "Accept"
 	BearerPrefix        = "Bearer "
@@ -72,6 +110,25 @@ const (
 	MessageTypeStreamPause     = "streamPause"
 	MessageTypeStreamResume    = "streamResume"
 	MessageTypeVersionMismatch = "versionMismatch"
+	MessageTypeHandshakeAck    = "handshakeAck"
+	MessageTypeSourceBusy      = "sourceBusy"
+	MessageTypeSourceActive    = "sourceActive"
+	MessageTypeTakeover        = "takeover"
+)
+
+// WebSocket audio encodings negotiated during the handshake
+const (
+	AudioEncodingJSON   = "json"
+	AudioEncodingBinary = "binary"
+	AudioEncodingOpus   = "opus"
+)
+
+// Audio source names
+const (
+	SourceExtension = "extension"
+	SourceFile      = "file"
+	SourceProcess   = "process"
+	SourceTone      = "tone"
 )
 
 // Extension version
@@ -86,6 +143,21 @@ const (
 	LogFileName       = "trunecord.log"
 )
 
+// Settings paths, relative to the user's configuration directory
+const (
+	SettingsDirectory = "trunecord"
+	SettingsFileName  = "settings.json"
+)
+
+// Recording paths, relative to the user's home directory
+const (
+	RecordingParentDirectory = "Music"
+	RecordingDirectory       = "trunecord"
+)
+
+// Directory queued files are played from, relative to the user's home directory
+const MediaDirectory = "Music"
+
 // Application info
 const (
 	ApplicationName  = "trunecord"
@@ -132,6 +204,9 @@ const (
 
 // File permissions
 const (
-	LogDirPermission  = 0755
-	LogFilePermission = 0644
+	LogDirPermission        = 0755
+	LogFilePermission       = 0644
+	SettingsFilePermission  = 0600
+	RecordingDirPermission  = 0755
+	RecordingFilePermission = 0644
 )
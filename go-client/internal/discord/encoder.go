@@ -1,12 +1,42 @@
 package discord
 
+import (
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
 // OpusEncoder is an interface for Opus encoding
 type OpusEncoder interface {
 	Encode(pcm []int16, frameSize, maxBytes int) ([]byte, error)
 	SetBitrate(bitrate int) error
+	SetComplexity(complexity int) error
+	SetInBandFEC(enabled bool) error
+	SetPacketLossPerc(percent int) error
+	SetDTX(enabled bool) error
+	SetApplication(application opus.Application) error
 }
 
 // NewOpusEncoderTest creates a new Opus encoder for testing purposes
 func NewOpusEncoderTest() (OpusEncoder, error) {
-	return newOpusEncoder()
+	return newOpusEncoder(constants.MonoChannels)
+}
+
+// configureEncoder applies every encoder option in turn.
+func configureEncoder(encoder OpusEncoder, options opus.Options) error {
+	if err := encoder.SetApplication(options.Application); err != nil {
+		return err
+	}
+	if err := encoder.SetBitrate(options.Bitrate); err != nil {
+		return err
+	}
+	if err := encoder.SetComplexity(options.Complexity); err != nil {
+		return err
+	}
+	if err := encoder.SetInBandFEC(options.FEC); err != nil {
+		return err
+	}
+	if err := encoder.SetPacketLossPerc(options.PacketLossPercent); err != nil {
+		return err
+	}
+	return encoder.SetDTX(options.DTX)
 }
@@ -3,25 +3,110 @@
 
 package discord
 
-import "layeh.com/gopus"
+// The encoder links the system libopus through pkg-config, as installed by
+// libopus-dev or Homebrew's opus, for access to every encoder CTL.
+
+/*
+#cgo pkg-config: opus
+#include <opus.h>
+
+// cgo cannot call variadic functions, so each CTL gets a wrapper
+static int set_bitrate(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_BITRATE(v)); }
+static int set_complexity(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_COMPLEXITY(v)); }
+static int set_inband_fec(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_INBAND_FEC(v)); }
+static int set_packet_loss_perc(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_PACKET_LOSS_PERC(v)); }
+static int set_dtx(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_DTX(v)); }
+static int set_application(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_APPLICATION(v)); }
+static int set_signal(OpusEncoder *st, opus_int32 v) { return opus_encoder_ctl(st, OPUS_SET_SIGNAL(v)); }
+*/
+import "C"
+
+import (
+	"fmt"
+	"runtime"
+	"unsafe"
+
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
 
 type cgoOpusEncoder struct {
-	encoder *gopus.Encoder
+	// Allocated by libopus and destroyed when the encoder is collected
+	encoder  *C.OpusEncoder
+	channels int
 }
 
-func newOpusEncoder() (OpusEncoder, error) {
-	encoder, err := gopus.NewEncoder(48000, 1, gopus.Audio)
-	if err != nil {
-		return nil, err
+func newOpusEncoder(channels int) (OpusEncoder, error) {
+	var ret C.int
+	encoder := C.opus_encoder_create(C.opus_int32(constants.SampleRate), C.int(channels), C.OPUS_APPLICATION_AUDIO, &ret)
+	if ret != C.OPUS_OK || encoder == nil {
+		return nil, fmt.Errorf("opus encoder init failed: %s", C.GoString(C.opus_strerror(ret)))
 	}
-	return &cgoOpusEncoder{encoder: encoder}, nil
+	e := &cgoOpusEncoder{encoder: encoder, channels: channels}
+	runtime.SetFinalizer(e, func(e *cgoOpusEncoder) {
+		C.opus_encoder_destroy(e.encoder)
+	})
+	return e, nil
 }
 
 func (e *cgoOpusEncoder) Encode(pcm []int16, frameSize, maxBytes int) ([]byte, error) {
-	return e.encoder.Encode(pcm, frameSize, maxBytes)
+	if frameSize <= 0 || len(pcm) != frameSize*e.channels {
+		return nil, fmt.Errorf("expected %d samples, got %d", frameSize*e.channels, len(pcm))
+	}
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("no room for the encoded packet")
+	}
+	data := make([]byte, maxBytes)
+	n := C.opus_encode(e.encoder, (*C.opus_int16)(unsafe.Pointer(&pcm[0])), C.int(frameSize), (*C.uchar)(unsafe.Pointer(&data[0])), C.opus_int32(maxBytes))
+	runtime.KeepAlive(e)
+	if n < 0 {
+		return nil, fmt.Errorf("opus encode failed: %s", C.GoString(C.opus_strerror(n)))
+	}
+	return data[:n], nil
 }
 
-func (e *cgoOpusEncoder) SetBitrate(bitrate int) error {
-	e.encoder.SetBitrate(bitrate)
+func (e *cgoOpusEncoder) ctl(name string, ret C.int) error {
+	runtime.KeepAlive(e)
+	if ret != C.OPUS_OK {
+		return fmt.Errorf("failed to set opus %s: %s", name, C.GoString(C.opus_strerror(ret)))
+	}
 	return nil
 }
+
+func (e *cgoOpusEncoder) SetBitrate(bitrate int) error {
+	return e.ctl("bitrate", C.set_bitrate(e.encoder, C.opus_int32(bitrate)))
+}
+
+func (e *cgoOpusEncoder) SetComplexity(complexity int) error {
+	return e.ctl("complexity", C.set_complexity(e.encoder, C.opus_int32(complexity)))
+}
+
+func (e *cgoOpusEncoder) SetInBandFEC(enabled bool) error {
+	return e.ctl("in-band FEC", C.set_inband_fec(e.encoder, boolToInt(enabled)))
+}
+
+func (e *cgoOpusEncoder) SetPacketLossPerc(percent int) error {
+	return e.ctl("packet loss percentage", C.set_packet_loss_perc(e.encoder, C.opus_int32(percent)))
+}
+
+func (e *cgoOpusEncoder) SetDTX(enabled bool) error {
+	return e.ctl("DTX", C.set_dtx(e.encoder, boolToInt(enabled)))
+}
+
+func (e *cgoOpusEncoder) SetApplication(application opus.Application) error {
+	app, signal := C.opus_int32(C.OPUS_APPLICATION_AUDIO), C.opus_int32(C.OPUS_SIGNAL_MUSIC)
+	if application == opus.ApplicationVoice {
+		app, signal = C.OPUS_APPLICATION_VOIP, C.OPUS_SIGNAL_VOICE
+	}
+	if err := e.ctl("application", C.set_application(e.encoder, app)); err != nil {
+		return err
+	}
+	return e.ctl("signal", C.set_signal(e.encoder, signal))
+}
+
+func boolToInt(b bool) C.opus_int32 {
+	if b {
+		return 1
+	}
+	return 0
+}
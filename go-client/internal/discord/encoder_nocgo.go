@@ -3,18 +3,77 @@
 
 package discord
 
-import "fmt"
+// Without cgo, libopus runs as WebAssembly inside the process. It is slower
+// than the native library but needs no C toolchain.
 
-type noOpusEncoder struct{}
+import (
+	"fmt"
 
-func newOpusEncoder() (OpusEncoder, error) {
-	return nil, fmt.Errorf("this binary was built without audio support. Please download a platform-specific build from https://github.com/cahlchang/trunecord/releases or compile from source with CGO_ENABLED=1")
+	wasmopus "github.com/jj11hh/opus"
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
+type wasmOpusEncoder struct {
+	encoder  *wasmopus.Encoder
+	channels int
+}
+
+func newOpusEncoder(channels int) (OpusEncoder, error) {
+	encoder, err := wasmopus.NewEncoder(constants.SampleRate, channels, wasmopus.AppAudio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create opus encoder: %v", err)
+	}
+	return &wasmOpusEncoder{encoder: encoder, channels: channels}, nil
+}
+
+func (e *wasmOpusEncoder) Encode(pcm []int16, frameSize, maxBytes int) ([]byte, error) {
+	if frameSize <= 0 || len(pcm) != frameSize*e.channels {
+		return nil, fmt.Errorf("expected %d samples, got %d", frameSize*e.channels, len(pcm))
+	}
+	if maxBytes <= 0 {
+		return nil, fmt.Errorf("no room for the encoded packet")
+	}
+	data := make([]byte, maxBytes)
+	n, err := e.encoder.Encode(pcm, data)
+	if err != nil {
+		return nil, err
+	}
+	return data[:n], nil
+}
+
+func (e *wasmOpusEncoder) SetBitrate(bitrate int) error {
+	return e.encoder.SetBitrate(bitrate)
+}
+
+func (e *wasmOpusEncoder) SetComplexity(complexity int) error {
+	return e.encoder.SetComplexity(complexity)
+}
+
+func (e *wasmOpusEncoder) SetInBandFEC(enabled bool) error {
+	return e.encoder.SetInBandFEC(enabled)
+}
+
+func (e *wasmOpusEncoder) SetPacketLossPerc(percent int) error {
+	return e.encoder.SetPacketLossPerc(percent)
 }
 
-func (e *noOpusEncoder) Encode(pcm []int16, frameSize, maxBytes int) ([]byte, error) {
-	return nil, fmt.Errorf("audio streaming is not supported in this build")
+func (e *wasmOpusEncoder) SetDTX(enabled bool) error {
+	return e.encoder.SetDTX(enabled)
 }
 
-func (e *noOpusEncoder) SetBitrate(bitrate int) error {
-	return fmt.Errorf("audio streaming is not supported in this build")
+// SetApplication recreates the encoder because the WebAssembly build only
+// takes the application at creation time. Other settings are reset, which is
+// why configureEncoder applies the application first.
+func (e *wasmOpusEncoder) SetApplication(application opus.Application) error {
+	app := wasmopus.AppAudio
+	if application == opus.ApplicationVoice {
+		app = wasmopus.AppVoIP
+	}
+	encoder, err := wasmopus.NewEncoder(constants.SampleRate, e.channels, app)
+	if err != nil {
+		return fmt.Errorf("failed to create opus encoder: %v", err)
+	}
+	e.encoder = encoder
+	return nil
 }
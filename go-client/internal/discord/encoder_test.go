@@ -2,20 +2,16 @@ package discord
 
 import (
 	"testing"
+
+	"trunecord/internal/opus"
 )
 
 func TestOpusEncoder(t *testing.T) {
 	// Test encoder creation
-	encoder, err := newOpusEncoder()
-
-	// Note: This test will behave differently based on build tags
-	// With CGO: Should create a working encoder
-	// Without CGO: Should return an error or stub encoder
-
+	// With CGO this is native libopus, without it the WebAssembly build
+	encoder, err := newOpusEncoder(1)
 	if err != nil {
-		// This is expected for nocgo builds
-		t.Logf("Encoder creation failed (expected for nocgo builds): %v", err)
-		return
+		t.Fatalf("newOpusEncoder() error = %v", err)
 	}
 
 	if encoder == nil {
@@ -25,8 +21,7 @@ func TestOpusEncoder(t *testing.T) {
 	// Test SetBitrate
 	err = encoder.SetBitrate(128000)
 	if err != nil {
-		// This might fail for nocgo builds
-		t.Logf("SetBitrate failed: %v", err)
+		t.Errorf("SetBitrate failed: %v", err)
 	}
 
 	// Test Encode with dummy data
@@ -37,9 +32,7 @@ func TestOpusEncoder(t *testing.T) {
 
 	encoded, err := encoder.Encode(dummyPCM, 960, 4000)
 	if err != nil {
-		// This is expected for nocgo builds
-		t.Logf("Encode failed (expected for nocgo builds): %v", err)
-		return
+		t.Fatalf("Encode failed: %v", err)
 	}
 
 	if len(encoded) == 0 {
@@ -47,13 +40,35 @@ func TestOpusEncoder(t *testing.T) {
 	}
 }
 
+func TestOpusEncoderStereo(t *testing.T) {
+	encoder, err := newOpusEncoder(2)
+	if err != nil {
+		t.Fatalf("newOpusEncoder() error = %v", err)
+	}
+
+	// 20ms of interleaved stereo at 48kHz: 960 samples per channel
+	dummyPCM := make([]int16, 960*2)
+	for i := 0; i < 960; i++ {
+		dummyPCM[i*2] = int16(i % 1000)
+		dummyPCM[i*2+1] = int16(-(i % 1000))
+	}
+
+	encoded, err := encoder.Encode(dummyPCM, 960, 4000)
+	if err != nil {
+		t.Fatalf("Encode stereo frame failed: %v", err)
+	}
+
+	if len(encoded) == 0 {
+		t.Error("Encode returned empty data for stereo frame")
+	}
+}
+
 func TestNewOpusEncoderTest(t *testing.T) {
 	// Test the public test function
 	encoder, err := NewOpusEncoderTest()
 
 	if err != nil {
-		t.Logf("NewOpusEncoderTest() error (expected for nocgo builds): %v", err)
-		return
+		t.Fatalf("NewOpusEncoderTest() error = %v", err)
 	}
 
 	if encoder == nil {
@@ -63,3 +78,59 @@ func TestNewOpusEncoderTest(t *testing.T) {
 	// Basic interface compliance test
 	var _ OpusEncoder = encoder
 }
+
+func TestConfigureEncoder(t *testing.T) {
+	encoder, err := newOpusEncoder(2)
+	if err != nil {
+		t.Fatalf("newOpusEncoder() error = %v", err)
+	}
+
+	options := opus.Options{
+		Bitrate:           64000,
+		Complexity:        5,
+		FEC:               true,
+		PacketLossPercent: 10,
+		DTX:               true,
+		Application:       opus.ApplicationVoice,
+	}
+	if err := configureEncoder(encoder, options); err != nil {
+		t.Fatalf("configureEncoder() error = %v", err)
+	}
+
+	// With DTX enabled, silence is sent as tiny comfort-noise packets
+	var smallest int
+	silence := make([]int16, 960*2)
+	for i := 0; i < 20; i++ {
+		encoded, err := encoder.Encode(silence, 960, 4000)
+		if err != nil {
+			t.Fatalf("Encode failed: %v", err)
+		}
+		if i == 0 || len(encoded) < smallest {
+			smallest = len(encoded)
+		}
+	}
+	if smallest > 3 {
+		t.Errorf("smallest DTX packet = %d bytes, want at most 3", smallest)
+	}
+
+	if err := encoder.SetComplexity(11); err == nil {
+		t.Error("SetComplexity(11) should be rejected by libopus")
+	}
+}
+
+func TestOpusEncoderRejectsEmptyInput(t *testing.T) {
+	encoder, err := newOpusEncoder(1)
+	if err != nil {
+		t.Fatalf("newOpusEncoder() error = %v", err)
+	}
+
+	if _, err := encoder.Encode(nil, 0, 4000); err == nil {
+		t.Error("Encode() should reject an empty frame")
+	}
+	if _, err := encoder.Encode(make([]int16, 960), 480, 4000); err == nil {
+		t.Error("Encode() should reject a frame size that does not match the samples")
+	}
+	if _, err := encoder.Encode(make([]int16, 960), 960, 0); err == nil {
+		t.Error("Encode() should reject a zero-length output buffer")
+	}
+}
@@ -0,0 +1,113 @@
+package discord
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+// opusSilence is the Opus frame of silence Discord asks for before
+// transmission stops.
+var opusSilence = []byte{0xF8, 0xFF, 0xFE}
+
+// voiceOutput sends packets to a voice sink and keeps the speaking
+// state in step with them. Once the audio has been silent for longer than
+// the hold time it stops transmitting, like Opus DTX, and stops speaking;
+// the next audible frame goes out straight away.
+type voiceOutput struct {
+	sink     VoiceSink
+	hold     time.Duration // 0 transmits silence for as long as it lasts
+	speaking atomic.Bool
+	sending  bool
+	silence  int                 // trailing silence frames still to send
+	quiet    time.Duration       // how long the audio has been silent
+	tap      func(packet []byte) // sees every packet handed to Discord, and silence for ticks without one
+}
+
+func newVoiceOutput(sink VoiceSink, hold time.Duration) *voiceOutput {
+	return &voiceOutput{sink: sink, hold: hold}
+}
+
+// transmit records one frame interval of audio and reports whether it
+// should be sent.
+func (o *voiceOutput) transmit(audible bool) bool {
+	if audible {
+		o.quiet = 0
+		return true
+	}
+	o.quiet += constants.AudioFrameInterval
+	return o.hold <= 0 || o.quiet <= o.hold
+}
+
+// send transmits a packet, speaking first if needed.
+func (o *voiceOutput) send(packet []byte) {
+	o.setSpeaking(true)
+	o.sending = true
+	o.silence = 0
+	o.write(packet)
+}
+
+// idle is called on ticks with no audio at all.
+func (o *voiceOutput) idle() {
+	o.transmit(false)
+	o.pause()
+}
+
+// pause is called on ticks with nothing to transmit. It sends the trailing
+// silence frames one per tick, then stops speaking once the hold time has
+// passed.
+func (o *voiceOutput) pause() {
+	if o.sending {
+		o.silence = constants.TrailingSilenceFrames
+		o.sending = false
+	}
+	if o.silence > 0 {
+		o.write(opusSilence)
+		o.silence--
+		return
+	}
+	// Nothing goes to Discord, but recordings and listeners still get the
+	// tick as silence so they keep wall-clock time
+	if o.tap != nil {
+		o.tap(opusSilence)
+	}
+	if o.hold > 0 && o.quiet > o.hold {
+		o.setSpeaking(false)
+	}
+}
+
+// stop is called on ticks while the stream is paused. It sends the
+// trailing silence frames one per tick, then stops speaking without waiting
+// out the hold time.
+func (o *voiceOutput) stop() {
+	o.pause()
+	if !o.draining() {
+		o.setSpeaking(false)
+	}
+}
+
+// draining reports whether transmission has not yet ended with its trailing
+// silence.
+func (o *voiceOutput) draining() bool {
+	return o.sending || o.silence > 0
+}
+
+func (o *voiceOutput) setSpeaking(speaking bool) {
+	if o.speaking.Load() == speaking {
+		return
+	}
+	o.speaking.Store(speaking)
+	if err := o.sink.Speaking(speaking); err != nil {
+		log.Printf("Failed to set speaking: %v", err)
+	}
+}
+
+func (o *voiceOutput) write(packet []byte) {
+	// Send to the sink (non-blocking); if it is full, skip but don't log
+	// every time
+	if o.sink.Send(packet) && o.tap != nil {
+		o.tap(packet)
+	}
+}
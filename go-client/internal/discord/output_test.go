@@ -0,0 +1,112 @@
+package discord
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+func drainPackets(sink *LoopbackSink) [][]byte {
+	var packets [][]byte
+	for len(sink.Packets()) > 0 {
+		packets = append(packets, <-sink.Packets())
+	}
+	return packets
+}
+
+func TestVoiceOutputStopsSpeakingAfterHold(t *testing.T) {
+	sink := NewLoopbackSink(100)
+	out := newVoiceOutput(sink, 3*constants.AudioFrameInterval)
+
+	if !out.transmit(true) {
+		t.Fatal("transmit() should send audible frames")
+	}
+	out.send([]byte{1})
+	if !out.speaking.Load() {
+		t.Error("sending should start speaking")
+	}
+
+	// Silence is sent until the hold time runs out
+	for i := 0; i < 3; i++ {
+		if !out.transmit(false) {
+			t.Fatalf("transmit() stopped after %d silent frames, want 3 within the hold time", i)
+		}
+		out.send([]byte{0})
+	}
+	if out.transmit(false) {
+		t.Fatal("transmit() should stop once the silence outlasts the hold time")
+	}
+
+	// Then come the trailing silence frames and the speaking state goes off
+	for i := 0; i <= constants.TrailingSilenceFrames; i++ {
+		out.pause()
+	}
+	if out.speaking.Load() || sink.IsSpeaking() {
+		t.Error("speaking should stop after the trailing silence")
+	}
+	packets := drainPackets(sink)
+	if len(packets) != 4+constants.TrailingSilenceFrames || !bytes.Equal(packets[len(packets)-1], opusSilence) {
+		t.Errorf("sent %x, want 4 frames then the trailing silence", packets)
+	}
+
+	// Sound resumes straight away
+	if !out.transmit(true) {
+		t.Fatal("transmit() should send as soon as sound returns")
+	}
+	out.send([]byte{1})
+	if !out.speaking.Load() {
+		t.Error("sending again should resume speaking")
+	}
+}
+
+func TestVoiceOutputTapsSilenceWhileHolding(t *testing.T) {
+	sink := NewLoopbackSink(100)
+	out := newVoiceOutput(sink, constants.AudioFrameInterval)
+	var tapped [][]byte
+	out.tap = func(packet []byte) { tapped = append(tapped, packet) }
+
+	out.transmit(true)
+	out.send([]byte{1})
+	ticks := constants.TrailingSilenceFrames + 10
+	for i := 0; i < ticks; i++ {
+		out.transmit(false)
+		out.pause()
+	}
+
+	if sent := len(drainPackets(sink)); sent != 1+constants.TrailingSilenceFrames {
+		t.Errorf("sent %d packets to the sink, want 1 then the trailing silence", sent)
+	}
+	if len(tapped) != 1+ticks {
+		t.Fatalf("tapped %d packets, want one for each of the %d ticks", len(tapped), 1+ticks)
+	}
+	for _, packet := range tapped[1:] {
+		if !bytes.Equal(packet, opusSilence) {
+			t.Errorf("tapped %x while holding, want the Opus silence frame", packet)
+		}
+	}
+}
+
+func TestVoiceOutputWithoutHold(t *testing.T) {
+	sink := NewLoopbackSink(100)
+	out := newVoiceOutput(sink, 0)
+
+	out.send([]byte{1})
+	for i := 0; i < int(time.Minute/constants.AudioFrameInterval); i++ {
+		if !out.transmit(false) {
+			t.Fatal("transmit() should keep sending silence without a hold time")
+		}
+	}
+
+	// Idle ticks still end transmission with silence but keep speaking
+	for i := 0; i < 2*constants.TrailingSilenceFrames; i++ {
+		out.idle()
+	}
+	if !out.speaking.Load() {
+		t.Error("speaking should not stop without a hold time")
+	}
+	if packets := drainPackets(sink); len(packets) != 1+constants.TrailingSilenceFrames {
+		t.Errorf("sent %d packets, want 1 and the trailing silence", len(packets))
+	}
+}
@@ -0,0 +1,87 @@
+package discord
+
+import (
+	"fmt"
+	"sync"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
+// packetQueue buffers pre-encoded Opus packets between the WebSocket server
+// and the 20ms send loop. Discord advances its RTP timestamp by one 20ms
+// frame per packet, so packets of any other duration are rejected. Packets
+// must also match the stream's channel count, which recordings and HTTP
+// listeners declare up front.
+type packetQueue struct {
+	mu       sync.Mutex
+	packets  [][]byte
+	channels int
+	target   int
+	max      int
+	primed   bool
+	stats    opus.PassthroughStats
+}
+
+// newPacketQueue sizes the queue from the jitter buffer's latency bounds.
+func newPacketQueue(config audio.JitterConfig, channels int) *packetQueue {
+	return &packetQueue{
+		channels: channels,
+		target:   int(config.Target / constants.AudioFrameInterval),
+		max:      int(config.Max / constants.AudioFrameInterval),
+	}
+}
+
+func (q *packetQueue) Push(packet []byte) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	duration, err := opus.PacketDuration(packet)
+	if err == nil && duration != constants.AudioFrameInterval {
+		err = fmt.Errorf("packet holds %v of audio, want %v", duration, constants.AudioFrameInterval)
+	}
+	if channels := opus.PacketChannels(packet); err == nil && channels != q.channels {
+		err = fmt.Errorf("packet has %d channel(s), want %d", channels, q.channels)
+	}
+	if err != nil {
+		q.stats.Rejected++
+		return err
+	}
+
+	q.packets = append(q.packets, packet)
+	if len(q.packets) > q.max {
+		q.packets = q.packets[1:]
+		q.stats.Dropped++
+	}
+	return nil
+}
+
+// Pop returns the next packet, or nil while the queue refills to its target
+// depth after running dry.
+func (q *packetQueue) Pop() []byte {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if !q.primed {
+		if len(q.packets) < q.target || len(q.packets) == 0 {
+			return nil
+		}
+		q.primed = true
+	}
+	if len(q.packets) == 0 {
+		q.primed = false
+		return nil
+	}
+
+	packet := q.packets[0]
+	q.packets = q.packets[1:]
+	q.stats.Forwarded++
+	return packet
+}
+
+func (q *packetQueue) Stats() opus.PassthroughStats {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.stats
+}
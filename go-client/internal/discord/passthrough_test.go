@@ -0,0 +1,84 @@
+package discord
+
+import (
+	"testing"
+	"time"
+
+	"trunecord/internal/audio"
+)
+
+// celt20ms is a minimal 20ms CELT-only Opus packet.
+var celt20ms = []byte{31 << 3, 0xff}
+
+func testPacketQueue() *packetQueue {
+	return newPacketQueue(audio.JitterConfig{
+		Target: 40 * time.Millisecond,
+		Min:    40 * time.Millisecond,
+		Max:    100 * time.Millisecond,
+	}, 1)
+}
+
+func TestPacketQueueRejectsWrongDuration(t *testing.T) {
+	q := testPacketQueue()
+
+	if err := q.Push([]byte{30 << 3, 0xff}); err == nil {
+		t.Error("Push() should reject a 10ms packet")
+	}
+	if err := q.Push(nil); err == nil {
+		t.Error("Push() should reject an empty packet")
+	}
+	if err := q.Push([]byte{31<<3 | 0x4, 0xff}); err == nil {
+		t.Error("Push() should reject a stereo packet on a mono stream")
+	}
+	if err := q.Push(celt20ms); err != nil {
+		t.Errorf("Push() rejected a 20ms packet: %v", err)
+	}
+
+	if stats := q.Stats(); stats.Rejected != 3 {
+		t.Errorf("Rejected = %d, want 3", stats.Rejected)
+	}
+}
+
+func TestPacketQueuePrimesAndDrains(t *testing.T) {
+	q := testPacketQueue()
+
+	q.Push(celt20ms)
+	if q.Pop() != nil {
+		t.Fatal("Pop() should wait for the target depth")
+	}
+
+	q.Push(celt20ms)
+	for i := 0; i < 2; i++ {
+		if q.Pop() == nil {
+			t.Fatalf("Pop() %d returned nil once primed", i)
+		}
+	}
+	if q.Pop() != nil {
+		t.Error("Pop() should return nil when empty")
+	}
+
+	// After running dry the queue primes again
+	q.Push(celt20ms)
+	if q.Pop() != nil {
+		t.Error("Pop() should wait for the target depth after running dry")
+	}
+
+	if stats := q.Stats(); stats.Forwarded != 2 {
+		t.Errorf("Forwarded = %d, want 2", stats.Forwarded)
+	}
+}
+
+func TestPacketQueueDropsOldest(t *testing.T) {
+	q := testPacketQueue()
+
+	for i := 0; i < 7; i++ {
+		q.Push([]byte{31 << 3, byte(i)})
+	}
+
+	if stats := q.Stats(); stats.Dropped != 2 {
+		t.Errorf("Dropped = %d, want 2", stats.Dropped)
+	}
+	if packet := q.Pop(); packet[1] != 2 {
+		t.Errorf("Pop() returned packet %d, want the oldest kept packet 2", packet[1])
+	}
+}
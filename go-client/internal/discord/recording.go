@@ -0,0 +1,100 @@
+package discord
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
+// recording writes the packets sent to Discord into an Ogg Opus file, with a
+// chapter comment for each track that starts while it runs.
+type recording struct {
+	mu       sync.Mutex
+	file     *os.File
+	ogg      *opus.OggWriter
+	path     string
+	chapters int
+	err      error
+}
+
+// startRecording creates a file named after the guild, channel and start
+// time in dir.
+func startRecording(dir, guildID, channelID string, channels int, now time.Time) (*recording, error) {
+	if err := os.MkdirAll(dir, constants.RecordingDirPermission); err != nil {
+		return nil, fmt.Errorf("failed to create recording directory: %v", err)
+	}
+
+	name := fmt.Sprintf("%s-%s-%s.ogg", guildID, channelID, now.Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, constants.RecordingFilePermission)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create recording: %v", err)
+	}
+
+	ogg, err := opus.NewOggWriter(file, channels, constants.ApplicationName+" "+constants.ApplicationVersion)
+	if err != nil {
+		file.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("failed to write recording header: %v", err)
+	}
+	ogg.AddComment("DATE", now.Format(time.RFC3339))
+	return &recording{file: file, ogg: ogg, path: path}, nil
+}
+
+func (r *recording) write(packet []byte) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+	if r.err = r.ogg.WritePacket(packet); r.err != nil {
+		log.Printf("Recording to %s failed: %v", r.path, r.err)
+	}
+}
+
+// chapter marks the start of a track at the current position.
+func (r *recording) chapter(title string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err != nil {
+		return
+	}
+
+	r.chapters++
+	position := r.ogg.Duration()
+	key := fmt.Sprintf("CHAPTER%03d", r.chapters)
+	r.ogg.AddComment(key, fmt.Sprintf("%02d:%02d:%02d.%03d",
+		int(position.Hours()), int(position.Minutes())%60, int(position.Seconds())%60, position.Milliseconds()%1000))
+	r.ogg.AddComment(key+"NAME", title)
+}
+
+// stop finishes the file and returns the final stats. Packets that arrive
+// afterwards are dropped.
+func (r *recording) stop() (opus.RecordingStats, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	err := r.ogg.Close()
+	if closeErr := r.file.Close(); err == nil {
+		err = closeErr
+	}
+	r.err = os.ErrClosed
+	return opus.RecordingStats{Path: r.path, Duration: r.ogg.Duration(), Chapters: r.chapters}, err
+}
+
+func (r *recording) stats() opus.RecordingStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return opus.RecordingStats{
+		Active:   true,
+		Path:     r.path,
+		Duration: r.ogg.Duration(),
+		Chapters: r.chapters,
+	}
+}
@@ -0,0 +1,164 @@
+package discord
+
+import (
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	"github.com/bwmarrin/discordgo"
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
+)
+
+// VoiceSink receives the Opus packets of a stream. A Discord voice
+// connection is one; a file or an in-process loopback can stand in for it.
+type VoiceSink interface {
+	// Ready reports whether packets can be sent yet.
+	Ready() bool
+	// Send queues a packet without blocking and reports whether it was
+	// accepted.
+	Send(packet []byte) bool
+	// Speaking turns the speaking indicator on or off.
+	Speaking(speaking bool) error
+	// Close ends the connection.
+	Close() error
+}
+
+// discordSink sends to a Discord voice channel.
+type discordSink struct {
+	conn *discordgo.VoiceConnection
+}
+
+func (d discordSink) Ready() bool {
+	d.conn.RLock()
+	defer d.conn.RUnlock()
+	return d.conn.Ready
+}
+
+func (d discordSink) Send(packet []byte) bool {
+	select {
+	case d.conn.OpusSend <- packet:
+		return true
+	default:
+		return false
+	}
+}
+
+func (d discordSink) Speaking(speaking bool) error {
+	return d.conn.Speaking(speaking)
+}
+
+func (d discordSink) Close() error {
+	return d.conn.Disconnect()
+}
+
+// FileSink writes the stream to an Ogg Opus file instead of a voice channel,
+// to listen back to exactly what would have been sent.
+type FileSink struct {
+	mu   sync.Mutex
+	file *os.File
+	ogg  *opus.OggWriter
+	err  error
+}
+
+// NewFileSink creates the file at path, replacing any file already there.
+func NewFileSink(path string, channels int) (*FileSink, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, constants.RecordingFilePermission)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s: %v", path, err)
+	}
+	ogg, err := opus.NewOggWriter(file, channels, constants.ApplicationName+" "+constants.ApplicationVersion)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("failed to write %s: %v", path, err)
+	}
+	return &FileSink{file: file, ogg: ogg}, nil
+}
+
+func (f *FileSink) Ready() bool {
+	return true
+}
+
+// Send writes a packet. After a write error every packet is refused.
+func (f *FileSink) Send(packet []byte) bool {
+	if _, err := opus.PacketDuration(packet); err != nil {
+		return false
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == nil {
+		f.err = f.ogg.WritePacket(packet)
+	}
+	return f.err == nil
+}
+
+func (f *FileSink) Speaking(speaking bool) error {
+	return nil
+}
+
+// Close finishes the file. Packets sent afterwards are refused.
+func (f *FileSink) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.err == os.ErrClosed {
+		return nil
+	}
+
+	err := f.ogg.Close()
+	if closeErr := f.file.Close(); err == nil {
+		err = closeErr
+	}
+	f.err = os.ErrClosed
+	return err
+}
+
+// LoopbackSink stands in for a voice channel inside the process. Packets
+// wait in a buffer for whoever reads them and, like Discord's, are dropped
+// when it is full.
+type LoopbackSink struct {
+	packets  chan []byte
+	speaking atomic.Bool
+	closed   atomic.Bool
+}
+
+func NewLoopbackSink(buffer int) *LoopbackSink {
+	return &LoopbackSink{packets: make(chan []byte, buffer)}
+}
+
+// Packets returns the packets sent to the sink.
+func (l *LoopbackSink) Packets() <-chan []byte {
+	return l.packets
+}
+
+// IsSpeaking reports the speaking indicator.
+func (l *LoopbackSink) IsSpeaking() bool {
+	return l.speaking.Load()
+}
+
+func (l *LoopbackSink) Ready() bool {
+	return !l.closed.Load()
+}
+
+func (l *LoopbackSink) Send(packet []byte) bool {
+	if l.closed.Load() {
+		return false
+	}
+	select {
+	case l.packets <- packet:
+		return true
+	default:
+		return false
+	}
+}
+
+func (l *LoopbackSink) Speaking(speaking bool) error {
+	l.speaking.Store(speaking)
+	return nil
+}
+
+func (l *LoopbackSink) Close() error {
+	l.closed.Store(true)
+	return nil
+}
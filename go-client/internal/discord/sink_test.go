@@ -0,0 +1,37 @@
+package discord
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFileSink(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "out.ogg")
+	sink, err := NewFileSink(path, 2)
+	if err != nil {
+		t.Fatalf("NewFileSink() error = %v", err)
+	}
+
+	if !sink.Ready() || !sink.Send(opusSilence) {
+		t.Fatal("a new file sink should accept packets")
+	}
+	if sink.Send(nil) {
+		t.Error("Send() should refuse packets that are not Opus")
+	}
+	if err := sink.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+	if sink.Send(opusSilence) {
+		t.Error("Send() should refuse packets after Close()")
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Count(data, []byte("OggS")) != 3 || !bytes.HasSuffix(data, opusSilence) {
+		t.Errorf("file should hold the Opus headers and a page with the packet, got %d bytes", len(data))
+	}
+}
@@ -5,94 +5,431 @@ import (
 	"fmt"
 	"log"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/bwmarrin/discordgo"
+	"trunecord/internal/audio"
+	"trunecord/internal/broadcast"
 	"trunecord/internal/constants"
+	"trunecord/internal/opus"
 )
 
 type Streamer struct {
 	session     *discordgo.Session
-	voiceConn   *discordgo.VoiceConnection
+	botToken    string // the token the session logged in with
+	targets     *fanOut
+	nextBitrate atomic.Int32 // applied by the stream when the targets change
 	guildID     string
 	channelID   string
 	connected   bool
 	streaming   bool
+	paused      atomic.Bool // the source's player is paused
 	audioBuffer chan []byte
 	stopChannel chan bool
+	streamDone  chan struct{}
+	output      *voiceOutput
+	silenceHold time.Duration
 	mutex       sync.RWMutex
 	encoder     OpusEncoder
+	channels    int
+	jitter      audio.JitterConfig
+	buffer      *audio.JitterBuffer
+	drift       *audio.DriftCompensator
+	options     opus.Options
+	maxBitrate  int
+	passthrough <-chan []byte
+	packets     *packetQueue
+	volume      *audio.Volume
+	loudness    *audio.Normalizer
+	filters     *audio.FilterChain
+	recordDir   string
+	recorder    atomic.Pointer[recording]
+	lastRecord  opus.RecordingStats
+	broadcast   *broadcast.Server
 }
 
 func NewStreamer() *Streamer {
 	return &Streamer{
 		audioBuffer: make(chan []byte, constants.AudioBufferSize),
 		stopChannel: make(chan bool),
+		channels:    constants.DefaultChannels,
+		jitter:      audio.DefaultJitterConfig(),
+		options:     opus.DefaultOptions(),
+		volume:      audio.NewVolume(),
+		loudness:    audio.NewNormalizer(),
+		filters:     audio.NewFilterChain(),
+		silenceHold: constants.SilenceHoldTime,
+		targets:     newFanOut(),
 	}
 }
 
-func (s *Streamer) Connect(botToken, guildID, channelID string) error {
+// SetVolume sets the output volume in percent, from 0 to audio.MaxVolume.
+// It applies to PCM as it is encoded; pre-encoded Opus packets keep their
+// level.
+func (s *Streamer) SetVolume(percent int) error {
+	return s.volume.Set(percent)
+}
+
+func (s *Streamer) GetVolume() int {
+	return s.volume.Get()
+}
+
+// SetLoudness enables or disables loudness normalization toward a target in
+// LUFS. Like the volume, it can change mid-stream and does not apply to
+// pre-encoded Opus packets.
+func (s *Streamer) SetLoudness(enabled bool, target float64) error {
+	return s.loudness.Set(enabled, target)
+}
+
+// GetLoudnessStats reports the loudness of the current or last stream.
+func (s *Streamer) GetLoudnessStats() audio.LoudnessStats {
+	return s.loudness.Stats()
+}
+
+// SetFilters replaces the DSP chain that runs before loudness normalization
+// and the volume. It can change mid-stream.
+func (s *Streamer) SetFilters(specs []audio.FilterSpec) error {
+	return s.filters.Set(specs)
+}
+
+// GetFilters returns the DSP chain with every parameter filled in.
+func (s *Streamer) GetFilters() []audio.FilterSpec {
+	return s.filters.Specs()
+}
+
+// GetLimitedSamples counts samples the volume's soft limiter had to bend.
+func (s *Streamer) GetLimitedSamples() uint64 {
+	return s.volume.Limited()
+}
+
+// SetEncoderOptions sets the Opus parameters used by the next stream. While
+// connected, the bitrate must fit the voice channel's limit.
+func (s *Streamer) SetEncoderOptions(options opus.Options) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := options.Validate(s.maxBitrate); err != nil {
+		return fmt.Errorf("invalid encoder options: %v", err)
+	}
+
+	if s.streaming {
+		return fmt.Errorf("cannot change encoder options while streaming")
+	}
+
+	s.options = options
+	return nil
+}
+
+// SetJitterConfig sets the latency bounds of the jitter buffer used by the
+// next stream.
+func (s *Streamer) SetJitterConfig(config audio.JitterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid jitter buffer configuration: %v", err)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.jitter = config
+	return nil
+}
+
+// SetSilenceHold sets how long the next stream keeps transmitting silence
+// before it stops sending and speaking. 0 keeps transmitting.
+func (s *Streamer) SetSilenceHold(hold time.Duration) error {
+	if hold < 0 {
+		return fmt.Errorf("silence hold must not be negative: %v", hold)
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.silenceHold = hold
+	return nil
+}
+
+// SetRecordingDir sets where recordings are saved.
+func (s *Streamer) SetRecordingDir(dir string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.recordDir = dir
+}
+
+// StartRecording starts writing the packets sent to Discord into a new Ogg
+// Opus file and returns its path. Recording costs no extra encoding.
+func (s *Streamer) StartRecording() (string, error) {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	// Create Discord session
-	session, err := discordgo.New("Bot " + botToken)
+	if !s.connected {
+		return "", fmt.Errorf("not connected to Discord voice channel")
+	}
+	if s.recorder.Load() != nil {
+		return "", fmt.Errorf("already recording")
+	}
+	if s.recordDir == "" {
+		return "", fmt.Errorf("no recording directory configured")
+	}
+
+	rec, err := startRecording(s.recordDir, s.guildID, s.channelID, s.channels, time.Now())
 	if err != nil {
-		return fmt.Errorf("failed to create Discord session: %v", err)
+		return "", err
 	}
+	s.recorder.Store(rec)
+	log.Printf("Recording to %s", rec.path)
+	return rec.path, nil
+}
 
-	// Open connection
-	err = session.Open()
+// StopRecording finishes the recording in progress, if any.
+func (s *Streamer) StopRecording() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.stopRecording()
+}
+
+func (s *Streamer) stopRecording() error {
+	rec := s.recorder.Swap(nil)
+	if rec == nil {
+		return nil
+	}
+	stats, err := rec.stop()
+	s.lastRecord = stats
 	if err != nil {
-		return fmt.Errorf("failed to open Discord connection: %v", err)
+		return fmt.Errorf("failed to finish recording %s: %v", rec.path, err)
+	}
+	log.Printf("Saved recording %s", rec.path)
+	return nil
+}
+
+// GetRecordingStats reports the recording in progress, or else the last one.
+func (s *Streamer) GetRecordingStats() opus.RecordingStats {
+	if rec := s.recorder.Load(); rec != nil {
+		return rec.stats()
 	}
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.lastRecord
+}
 
-	s.session = session
-	s.guildID = guildID
-	s.channelID = channelID
+// MarkTrack adds a chapter named after a track that has just started to
+// the recording in progress.
+func (s *Streamer) MarkTrack(title string) {
+	if rec := s.recorder.Load(); rec != nil {
+		rec.chapter(title)
+	}
+}
+
+// tap passes packets on their way to Discord, and silence for the ticks
+// that send none, to the recording and the stream listeners.
+func (s *Streamer) tap(packet []byte) {
+	s.record(packet)
+	if s.broadcast != nil {
+		s.broadcast.WritePacket(packet)
+	}
+}
+
+func (s *Streamer) record(packet []byte) {
+	if rec := s.recorder.Load(); rec != nil {
+		rec.write(packet)
+	}
+}
+
+// IsSpeaking reports whether the stream is transmitting audio, as shown by
+// Discord's speaking indicator.
+func (s *Streamer) IsSpeaking() bool {
+	s.mutex.RLock()
+	output := s.output
+	s.mutex.RUnlock()
+	return output != nil && output.speaking.Load()
+}
+
+// SetPassthroughChannel sets where pre-encoded Opus packets come from. They
+// are sent to Discord as-is and take precedence over PCM audio.
+func (s *Streamer) SetPassthroughChannel(packets <-chan []byte) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.passthrough = packets
+}
+
+// SetBroadcast sets the HTTP listeners that get the audio sent to Discord.
+func (s *Streamer) SetBroadcast(listeners *broadcast.Server) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.broadcast = listeners
+}
+
+// SetChannels selects mono (1) or stereo (2) encoding. The PCM passed to
+// StartStreaming must be interleaved with the same channel count.
+func (s *Streamer) SetChannels(channels int) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !audio.ValidChannels(channels) {
+		return fmt.Errorf("unsupported channel count: %d", channels)
+	}
+
+	if s.streaming {
+		return fmt.Errorf("cannot change channel count while streaming")
+	}
+
+	s.channels = channels
+	return nil
+}
+
+// Connect joins a voice channel and adds it to the channels the stream goes
+// to. Channels in other guilds can be joined alongside it; joining another
+// channel in the same guild moves there.
+func (s *Streamer) Connect(botToken, guildID, channelID string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	// All channels share one bot session
+	session := s.session
+	if session != nil && botToken != s.botToken {
+		return fmt.Errorf("already connected with a different bot token; disconnect first")
+	}
+	if session == nil {
+		var err error
+		session, err = discordgo.New("Bot " + botToken)
+		if err != nil {
+			return fmt.Errorf("failed to create Discord session: %v", err)
+		}
+
+		// Open connection
+		err = session.Open()
+		if err != nil {
+			return fmt.Errorf("failed to open Discord connection: %v", err)
+		}
+	}
+
+	// Encoding above the channel's bitrate only wastes bandwidth, so it sets
+	// both the automatic bitrate and the limit for manual overrides
+	maxBitrate := 0
+	if channel, err := session.Channel(channelID); err != nil {
+		log.Printf("Failed to look up voice channel bitrate: %v", err)
+	} else {
+		maxBitrate = channel.Bitrate
+		log.Printf("Voice channel bitrate is %d bps", channel.Bitrate)
+	}
 
 	// Join voice channel
 	voiceConn, err := session.ChannelVoiceJoin(guildID, channelID, false, true)
 	if err != nil {
-		s.session.Close()
+		if s.session == nil {
+			session.Close()
+		}
 		return fmt.Errorf("failed to join voice channel: %v", err)
 	}
 
-	s.voiceConn = voiceConn
-	s.connected = true
+	s.session = session
+	s.botToken = botToken
+	if replaced := s.targets.add(guildID, guildID, channelID, maxBitrate, discordSink{voiceConn}); replaced != nil {
+		replaced.Close()
+	}
+	s.updateTargets()
 
 	log.Printf("Connected to Discord voice channel %s in guild %s", channelID, guildID)
 	return nil
 }
 
+// ConnectSink adds a sink other than Discord, such as a FileSink or a
+// LoopbackSink, which is streamed to exactly like a voice channel.
+func (s *Streamer) ConnectSink(id string, sink VoiceSink) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if s.targets.has(id) {
+		return fmt.Errorf("already connected to %s", id)
+	}
+	s.targets.add(id, "", "", 0, sink)
+	s.updateTargets()
+	log.Printf("Connected to %s (%T)", id, sink)
+	return nil
+}
+
+// DisconnectTarget leaves one voice channel, by guild ID, or removes a sink
+// by its ID. Leaving the last one disconnects completely.
+func (s *Streamer) DisconnectTarget(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if !s.targets.has(id) {
+		return fmt.Errorf("not connected to %s", id)
+	}
+	if len(s.targets.stats()) == 1 {
+		s.disconnect()
+		return nil
+	}
+
+	if err := s.targets.remove(id).Close(); err != nil {
+		log.Printf("Failed to close voice connection: %v", err)
+	}
+	s.updateTargets()
+	log.Printf("Disconnected from %s", id)
+	return nil
+}
+
+// Disconnect leaves every voice channel.
 func (s *Streamer) Disconnect() error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
+	s.disconnect()
+	return nil
+}
+
+func (s *Streamer) disconnect() {
 	if s.streaming {
 		s.stopStreaming()
 	}
+	if err := s.stopRecording(); err != nil {
+		log.Printf("%v", err)
+	}
 
-	if s.voiceConn != nil {
-		s.voiceConn.Disconnect()
-		s.voiceConn = nil
+	if err := s.targets.Close(); err != nil {
+		log.Printf("Failed to close voice connection: %v", err)
 	}
 
 	if s.session != nil {
 		s.session.Close()
 		s.session = nil
+		s.botToken = ""
 	}
 
-	s.connected = false
+	s.updateTargets()
 	log.Printf("Disconnected from Discord")
-	return nil
+}
+
+// updateTargets refreshes what follows from the targets: the first one
+// names the stream, and the lowest channel limit caps the bitrate for all.
+// Callers must hold the mutex.
+func (s *Streamer) updateTargets() {
+	targets := s.targets.stats()
+	s.connected = len(targets) > 0
+	s.guildID, s.channelID, s.maxBitrate = "", "", 0
+	for i, target := range targets {
+		if i == 0 {
+			s.guildID, s.channelID = target.GuildID, target.ChannelID
+		}
+		if target.MaxBitrate > 0 && (s.maxBitrate == 0 || target.MaxBitrate < s.maxBitrate) {
+			s.maxBitrate = target.MaxBitrate
+		}
+	}
+	if s.streaming {
+		s.nextBitrate.Store(int32(s.bitrate()))
+	}
+}
+
+// GetTargets reports the voice channels, and other sinks, being streamed
+// to in the order they connected.
+func (s *Streamer) GetTargets() []TargetStats {
+	return s.targets.stats()
 }
 
 func (s *Streamer) StartStreaming(audioChannel <-chan []byte) error {
 	s.mutex.Lock()
 	defer s.mutex.Unlock()
 
-	if !s.connected || s.voiceConn == nil {
+	if !s.connected || len(s.targets.stats()) == 0 {
 		return fmt.Errorf("not connected to Discord voice channel")
 	}
 
@@ -101,25 +438,36 @@ func (s *Streamer) StartStreaming(audioChannel <-chan []byte) error {
 	}
 
 	// Create Opus encoder
-	// 48000 Hz sample rate, 1 channel (mono), Audio application for music
-	encoder, err := newOpusEncoder()
+	// 48000 Hz sample rate, mono or stereo, Audio application for music
+	encoder, err := newOpusEncoder(s.channels)
 	if err != nil {
 		return fmt.Errorf("failed to create opus encoder: %v", err)
 	}
 
-	// Set bitrate for better quality
-	if err := encoder.SetBitrate(constants.OpusBitrate); err != nil {
-		return fmt.Errorf("failed to set opus bitrate: %v", err)
+	options := s.options
+	options.Bitrate = s.bitrate()
+	if err := configureEncoder(encoder, options); err != nil {
+		return fmt.Errorf("failed to configure opus encoder: %v", err)
 	}
 
 	s.encoder = encoder
-
+	s.buffer = audio.NewJitterBuffer(constants.PCMFrameBytes(s.channels), s.jitter)
+	s.drift = audio.NewDriftCompensator(s.channels)
+	s.packets = newPacketQueue(s.jitter, s.channels)
+	s.filters.Reset()
+	s.loudness.Reset()
+
+	s.stopChannel = make(chan bool)
+	s.streamDone = make(chan struct{})
+	s.nextBitrate.Store(0)
+	s.output = newVoiceOutput(s.targets, s.silenceHold)
+	s.output.tap = s.tap
 	s.streaming = true
 
 	// Start audio streaming goroutine
-	go s.streamAudio(audioChannel)
+	go s.streamAudio(audioChannel, s.stopChannel, s.streamDone, s.output, s.channels, s.buffer, s.drift, s.passthrough, s.packets)
 
-	log.Printf("Started audio streaming to Discord")
+	log.Printf("Started audio streaming to Discord (%d channel(s), %d bps)", s.channels, options.Bitrate)
 	return nil
 }
 
@@ -136,36 +484,62 @@ func (s *Streamer) StopStreaming() error {
 	return nil
 }
 
+// Pause fades the stream out, sends the trailing silence and stops speaking,
+// as when the source's player pauses. Audio that arrives until Resume is
+// dropped.
+func (s *Streamer) Pause() {
+	if !s.paused.Swap(true) {
+		log.Printf("Audio paused")
+	}
+}
+
+// Resume undoes Pause; the next audio fades in.
+func (s *Streamer) Resume() {
+	if s.paused.Swap(false) {
+		log.Printf("Audio resumed")
+	}
+}
+
+// IsPaused reports whether the stream is paused.
+func (s *Streamer) IsPaused() bool {
+	return s.paused.Load()
+}
+
+// stopStreaming signals the stream to end and waits for it to fade out and
+// send its trailing silence. Callers must hold the mutex.
 func (s *Streamer) stopStreaming() {
 	s.streaming = false
+	close(s.stopChannel)
+	if s.streamDone == nil {
+		return
+	}
 	select {
-	case s.stopChannel <- true:
-	default:
+	case <-s.streamDone:
+	case <-time.After(constants.StreamStopTimeout):
+		log.Printf("Audio stream did not finish within %v", constants.StreamStopTimeout)
 	}
+	s.streamDone = nil
 }
 
-func (s *Streamer) streamAudio(audioChannel <-chan []byte) {
-	if s.voiceConn == nil {
+func (s *Streamer) streamAudio(audioChannel <-chan []byte, stop <-chan bool, done chan<- struct{}, out *voiceOutput, channels int, buffer *audio.JitterBuffer, drift *audio.DriftCompensator, passthrough <-chan []byte, packets *packetQueue) {
+	defer close(done)
+
+	if out.sink == nil {
 		log.Printf("Voice connection is nil")
 		return
 	}
 
 	// Wait for voice connection to be ready
-	for !s.voiceConn.Ready {
-		time.Sleep(constants.VoiceConnectionWaitDelay)
+	for !out.sink.Ready() {
+		select {
+		case <-stop:
+			return
+		case <-time.After(constants.VoiceConnectionWaitDelay):
+		}
 	}
 
-	// Start speaking
-	s.voiceConn.Speaking(true)
-	defer s.voiceConn.Speaking(false)
-
-	// Discord expects specific samples per frame at 48kHz (20ms)
-	const frameSize = constants.PCMFrameSize
-	const bytesPerSample = constants.PCMBytesPerSample
-	const frameSizeBytes = constants.PCMFrameSizeBytes
-
-	// Larger buffer to prevent underruns
-	pcmBuffer := make([]byte, 0, constants.PCMFrameSizeBytes*constants.PCMBufferMultiplier)
+	// Speaking starts with the first audible frame
+	defer out.setSpeaking(false)
 
 	// Timing control for consistent audio frames
 	ticker := time.NewTicker(constants.AudioFrameInterval)
@@ -174,49 +548,142 @@ func (s *Streamer) streamAudio(audioChannel <-chan []byte) {
 	// Reduce initial delay for lower latency
 	time.Sleep(constants.InitialStreamingDelay)
 
+	rejectLogged := false
+
+	// Audio that starts or stops mid-waveform clicks, so the first frame
+	// after silence fades in and the last one before it fades out
+	faded := true
+	var lastPush time.Time
+
 	for {
 		select {
-		case <-s.stopChannel:
+		case <-stop:
+			s.finishStream(ticker.C, out, buffer, channels, faded)
 			return
 
 		case audioData := <-audioChannel:
-			if !s.streaming || s.encoder == nil {
+			if s.encoder == nil {
 				return
 			}
 
-			// Append new audio data to buffer
-			pcmBuffer = append(pcmBuffer, audioData...)
+			// Micro-resample against clock drift, then let the jitter buffer
+			// absorb bursts and trim excess latency
+			lastPush = time.Now()
+			buffer.Push(drift.Process(audioData, lastPush))
+
+		case packet := <-passthrough:
+			if err := packets.Push(packet); err != nil && !rejectLogged {
+				log.Printf("Rejecting Opus packets from extension: %v", err)
+				rejectLogged = true
+			}
 
 		case <-ticker.C:
-			// Process one frame every 20ms
-			if len(pcmBuffer) >= frameSizeBytes {
-				// Extract one frame
-				frame := pcmBuffer[:frameSizeBytes]
-				pcmBuffer = pcmBuffer[frameSizeBytes:]
-
-				// Convert byte array to int16 array
-				pcm := make([]int16, frameSize)
-				for i := 0; i < frameSize; i++ {
-					pcm[i] = int16(binary.LittleEndian.Uint16(frame[i*2 : (i+1)*2]))
+			if s.paused.Load() {
+				// Fade out what was playing, then send the trailing silence
+				// and stop speaking
+				packets.Pop()
+				frame := buffer.Pop()
+				if !faded && frame != nil {
+					s.encodeFrame(out, s.processFrame(frame, channels, false, true), channels)
+				} else {
+					out.stop()
 				}
+				faded = true
+				continue
+			}
 
-				// Encode to Opus
-				opus, err := s.encoder.Encode(pcm, frameSize, frameSizeBytes)
-				if err != nil {
-					log.Printf("Failed to encode audio: %v", err)
-					continue
-				}
+			// Pre-encoded packets skip the encoder; PCM keeps draining so it
+			// does not pile up behind them
+			if packet := packets.Pop(); packet != nil {
+				buffer.Pop()
+				out.transmit(true)
+				out.send(packet)
+				// Packets cannot be faded; PCM after them fades in
+				faded = true
+				continue
+			}
 
-				// Send to Discord (non-blocking)
-				select {
-				case s.voiceConn.OpusSend <- opus:
-					// Audio sent successfully
-				default:
-					// If channel is full, skip but don't log every time
-				}
+			// Process one frame every 20ms; nil while priming or idle
+			frame := buffer.Pop()
+			if frame == nil {
+				out.idle()
+				faded = true
+				continue
 			}
+
+			stats := buffer.Stats()
+			drift.Update(stats.Depth, stats.Target)
+
+			// A source that stops without pausing has stopped when this is
+			// the last buffered audio and nothing arrived for a while
+			fadeOut := stats.Depth < constants.AudioFrameInterval && time.Since(lastPush) > 2*constants.AudioFrameInterval
+			pcm := s.processFrame(frame, channels, faded, fadeOut)
+			silent := audio.IsSilent(pcm)
+			faded = fadeOut || silent
+			if !out.transmit(!silent) {
+				out.pause()
+				continue
+			}
+			s.encodeFrame(out, pcm, channels)
+		}
+	}
+}
+
+// finishStream fades out the audio still playing and sends the trailing
+// silence frames, one per tick, before the stream ends.
+func (s *Streamer) finishStream(tick <-chan time.Time, out *voiceOutput, buffer *audio.JitterBuffer, channels int, faded bool) {
+	if !faded {
+		<-tick
+		if frame := buffer.Pop(); frame != nil {
+			s.encodeFrame(out, s.processFrame(frame, channels, false, true), channels)
+		}
+	}
+	for out.draining() {
+		<-tick
+		out.pause()
+	}
+}
+
+// processFrame converts a frame of PCM to samples and runs it through the
+// DSP stages, optionally fading it in or out.
+func (s *Streamer) processFrame(frame []byte, channels int, fadeIn, fadeOut bool) []int16 {
+	// Convert byte array to interleaved int16 array
+	pcm := make([]int16, len(frame)/2)
+	for i := range pcm {
+		pcm[i] = int16(binary.LittleEndian.Uint16(frame[i*2 : (i+1)*2]))
+	}
+	s.filters.Process(pcm, channels)
+	s.loudness.Process(pcm, channels)
+	s.volume.Apply(pcm, channels)
+	if fadeIn {
+		audio.FadeIn(pcm, channels)
+	}
+	if fadeOut {
+		audio.FadeOut(pcm, channels)
+	}
+	// WAV listeners also hear the silence held back from Discord
+	if s.broadcast != nil {
+		s.broadcast.WritePCM(pcm)
+	}
+	return pcm
+}
+
+func (s *Streamer) encodeFrame(out *voiceOutput, pcm []int16, channels int) {
+	if bitrate := s.nextBitrate.Swap(0); bitrate > 0 {
+		if err := s.encoder.SetBitrate(int(bitrate)); err != nil {
+			log.Printf("Failed to change bitrate: %v", err)
+		} else {
+			log.Printf("Bitrate changed to %d bps for the connected channels", bitrate)
 		}
 	}
+
+	// Encode to Opus (frame size is samples per channel at 48kHz)
+	packet, err := s.encoder.Encode(pcm, len(pcm)/channels, constants.MaxOpusPacket)
+	if err != nil {
+		log.Printf("Failed to encode audio: %v", err)
+		return
+	}
+	out.send(packet)
 }
 
 func (s *Streamer) IsConnected() bool {
@@ -231,6 +698,88 @@ func (s *Streamer) IsStreaming() bool {
 	return s.streaming
 }
 
+// GetBufferStats reports the jitter buffer of the current or last stream.
+func (s *Streamer) GetBufferStats() audio.JitterStats {
+	s.mutex.RLock()
+	buffer := s.buffer
+	target := s.jitter.Target
+	s.mutex.RUnlock()
+
+	if buffer == nil {
+		return audio.JitterStats{Target: target}
+	}
+	return buffer.Stats()
+}
+
+// GetDriftStats reports the clock drift estimate of the current or last stream.
+func (s *Streamer) GetDriftStats() audio.DriftStats {
+	s.mutex.RLock()
+	drift := s.drift
+	s.mutex.RUnlock()
+
+	if drift == nil {
+		return audio.DriftStats{}
+	}
+	return drift.Stats()
+}
+
+// GetPassthroughStats reports Opus passthrough packets of the current or last
+// stream.
+func (s *Streamer) GetPassthroughStats() opus.PassthroughStats {
+	s.mutex.RLock()
+	packets := s.packets
+	s.mutex.RUnlock()
+
+	if packets == nil {
+		return opus.PassthroughStats{}
+	}
+	return packets.Stats()
+}
+
+func (s *Streamer) GetEncoderOptions() opus.Options {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.options
+}
+
+// bitrate picks the encoder bitrate: the voice channel's own bitrate in auto
+// mode, otherwise the configured bitrate capped to the channel's limit.
+// Callers must hold the mutex.
+func (s *Streamer) bitrate() int {
+	switch {
+	case s.options.Bitrate == opus.BitrateAuto && s.maxBitrate > 0:
+		return s.maxBitrate
+	case s.options.Bitrate == opus.BitrateAuto:
+		return constants.OpusBitrate
+	case s.maxBitrate > 0 && s.options.Bitrate > s.maxBitrate:
+		return s.maxBitrate
+	default:
+		return s.options.Bitrate
+	}
+}
+
+// GetBitrate reports the bitrate the encoder runs at, or will run at once
+// streaming starts.
+func (s *Streamer) GetBitrate() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.bitrate()
+}
+
+// GetMaxBitrate reports the lowest bitrate limit of the connected voice
+// channels, or 0 when unknown.
+func (s *Streamer) GetMaxBitrate() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.maxBitrate
+}
+
+func (s *Streamer) GetChannels() int {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.channels
+}
+
 func (s *Streamer) GetGuildID() string {
 	s.mutex.RLock()
 	defer s.mutex.RUnlock()
@@ -1,8 +1,19 @@
 package discord
 
 import (
+	"bytes"
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+	"trunecord/internal/opus"
 )
 
 func TestNewStreamer(t *testing.T) {
@@ -63,6 +74,21 @@ func TestStreamer_IsStreaming(t *testing.T) {
 	}
 }
 
+func TestStreamer_ConnectRejectsOtherBotToken(t *testing.T) {
+	streamer := NewStreamer()
+	session := &discordgo.Session{}
+	streamer.session = session
+	streamer.botToken = "first"
+
+	err := streamer.Connect("second", "guild", "channel")
+	if err == nil || !strings.Contains(err.Error(), "different bot token") {
+		t.Errorf("Connect() error = %v, want a bot token mismatch", err)
+	}
+	if streamer.session != session || streamer.botToken != "first" {
+		t.Error("a rejected Connect() should keep the existing session")
+	}
+}
+
 func TestStreamer_GetGuildID(t *testing.T) {
 	streamer := NewStreamer()
 	testGuildID := "test-guild-123"
@@ -99,6 +125,157 @@ func TestStreamer_GetChannelID(t *testing.T) {
 	}
 }
 
+func TestStreamer_SetChannels(t *testing.T) {
+	streamer := NewStreamer()
+
+	if streamer.GetChannels() != constants.DefaultChannels {
+		t.Errorf("GetChannels() = %d, want %d by default", streamer.GetChannels(), constants.DefaultChannels)
+	}
+
+	if err := streamer.SetChannels(1); err != nil {
+		t.Fatalf("SetChannels(1) returned error: %v", err)
+	}
+	if streamer.GetChannels() != 1 {
+		t.Errorf("GetChannels() = %d, want 1", streamer.GetChannels())
+	}
+
+	if err := streamer.SetChannels(6); err == nil {
+		t.Error("SetChannels(6) should return error")
+	}
+
+	streamer.mutex.Lock()
+	streamer.streaming = true
+	streamer.mutex.Unlock()
+
+	if err := streamer.SetChannels(1); err == nil {
+		t.Error("SetChannels() should return error while streaming")
+	}
+}
+
+func TestStreamer_SetVolume(t *testing.T) {
+	streamer := NewStreamer()
+
+	if streamer.GetVolume() != 100 {
+		t.Errorf("GetVolume() = %d, want 100 by default", streamer.GetVolume())
+	}
+
+	// Unlike the channel count, the volume can change mid-stream
+	streamer.mutex.Lock()
+	streamer.streaming = true
+	streamer.mutex.Unlock()
+
+	if err := streamer.SetVolume(150); err != nil {
+		t.Fatalf("SetVolume(150) returned error: %v", err)
+	}
+	if streamer.GetVolume() != 150 {
+		t.Errorf("GetVolume() = %d, want 150", streamer.GetVolume())
+	}
+	if err := streamer.SetVolume(-1); err == nil {
+		t.Error("SetVolume(-1) should return error")
+	}
+}
+
+func TestStreamer_SetLoudness(t *testing.T) {
+	streamer := NewStreamer()
+
+	if stats := streamer.GetLoudnessStats(); stats.Enabled || stats.Target != audio.DefaultLoudnessTarget {
+		t.Errorf("GetLoudnessStats() = %+v, want normalization off at the default target", stats)
+	}
+	if err := streamer.SetLoudness(true, -23); err != nil {
+		t.Fatalf("SetLoudness() returned error: %v", err)
+	}
+	if stats := streamer.GetLoudnessStats(); !stats.Enabled || stats.Target != -23 {
+		t.Errorf("GetLoudnessStats() = %+v, want normalization on at -23 LUFS", stats)
+	}
+	if err := streamer.SetLoudness(true, 0); err == nil {
+		t.Error("SetLoudness() should reject a target above MaxLoudnessTarget")
+	}
+}
+
+func TestStreamer_SetJitterConfig(t *testing.T) {
+	streamer := NewStreamer()
+
+	if got := streamer.GetBufferStats().Target; got != audio.DefaultJitterConfig().Target {
+		t.Errorf("GetBufferStats().Target = %v, want default %v", got, audio.DefaultJitterConfig().Target)
+	}
+
+	config := audio.JitterConfig{Target: 100 * time.Millisecond, Min: 40 * time.Millisecond, Max: 300 * time.Millisecond}
+	if err := streamer.SetJitterConfig(config); err != nil {
+		t.Fatalf("SetJitterConfig() returned error: %v", err)
+	}
+	if got := streamer.GetBufferStats().Target; got != config.Target {
+		t.Errorf("GetBufferStats().Target = %v, want %v", got, config.Target)
+	}
+
+	config.Target = time.Second
+	if err := streamer.SetJitterConfig(config); err == nil {
+		t.Error("SetJitterConfig() should reject a target above the maximum")
+	}
+}
+
+func TestStreamer_SetEncoderOptions(t *testing.T) {
+	streamer := NewStreamer()
+
+	options := opus.DefaultOptions()
+	options.Bitrate = 128000
+	options.FEC = true
+	if err := streamer.SetEncoderOptions(options); err != nil {
+		t.Fatalf("SetEncoderOptions() returned error: %v", err)
+	}
+	if got := streamer.GetEncoderOptions(); got != options {
+		t.Errorf("GetEncoderOptions() = %+v, want %+v", got, options)
+	}
+
+	// Once connected, the channel's bitrate limit applies
+	streamer.mutex.Lock()
+	streamer.maxBitrate = 96000
+	streamer.mutex.Unlock()
+
+	if err := streamer.SetEncoderOptions(options); err == nil {
+		t.Error("SetEncoderOptions() should reject a bitrate above the channel limit")
+	}
+
+	options.Bitrate = 96000
+	if err := streamer.SetEncoderOptions(options); err != nil {
+		t.Errorf("SetEncoderOptions() within the channel limit returned error: %v", err)
+	}
+
+	streamer.mutex.Lock()
+	streamer.streaming = true
+	streamer.mutex.Unlock()
+
+	if err := streamer.SetEncoderOptions(options); err == nil {
+		t.Error("SetEncoderOptions() should return error while streaming")
+	}
+}
+
+func TestStreamer_GetBitrate(t *testing.T) {
+	tests := []struct {
+		name       string
+		bitrate    int
+		maxBitrate int
+		want       int
+	}{
+		{name: "auto without channel", bitrate: opus.BitrateAuto, want: 128000},
+		{name: "auto matches channel", bitrate: opus.BitrateAuto, maxBitrate: 96000, want: 96000},
+		{name: "boosted channel", bitrate: opus.BitrateAuto, maxBitrate: 384000, want: 384000},
+		{name: "manual override", bitrate: 64000, maxBitrate: 96000, want: 64000},
+		{name: "override capped to channel", bitrate: 128000, maxBitrate: 64000, want: 64000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			streamer := NewStreamer()
+			streamer.options.Bitrate = tt.bitrate
+			streamer.maxBitrate = tt.maxBitrate
+
+			if got := streamer.GetBitrate(); got != tt.want {
+				t.Errorf("GetBitrate() = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
 func TestStreamer_StartStreamingErrors(t *testing.T) {
 	streamer := NewStreamer()
 
@@ -113,7 +290,7 @@ func TestStreamer_StartStreamingErrors(t *testing.T) {
 	streamer.mutex.Lock()
 	streamer.connected = true
 	streamer.streaming = true
-	// Note: voiceConn would be nil in this test, but that's ok for error testing
+	// Note: sink would be nil in this test, but that's ok for error testing
 	streamer.mutex.Unlock()
 
 	err = streamer.StartStreaming(audioChannel)
@@ -170,5 +347,309 @@ func TestStreamer_AudioBuffering(t *testing.T) {
 	}
 }
 
-// Note: Testing Connect() and actual streaming would require mocking Discord API,
-// which is complex. These tests focus on the basic functionality and state management.
+// recordingEncoder keeps the PCM it is given and returns a marker packet.
+type recordingEncoder struct {
+	OpusEncoder
+	frames [][]int16
+}
+
+func (e *recordingEncoder) Encode(pcm []int16, frameSize, maxBytes int) ([]byte, error) {
+	e.frames = append(e.frames, append([]int16(nil), pcm...))
+	return []byte{1}, nil
+}
+
+func TestStreamer_FadesAndSendsTrailingSilence(t *testing.T) {
+	streamer := NewStreamer()
+	encoder := &recordingEncoder{}
+	streamer.encoder = encoder
+	sink := NewLoopbackSink(100)
+
+	jitter := audio.DefaultJitterConfig()
+	frames := make(chan []byte)
+	stop := make(chan bool)
+	done := make(chan struct{})
+	go streamer.streamAudio(frames, stop, done, newVoiceOutput(sink, constants.SilenceHoldTime), 1, audio.NewJitterBuffer(constants.PCMFrameBytes(1), jitter), audio.NewDriftCompensator(1), nil, newPacketQueue(jitter, 1))
+
+	frame := make([]byte, constants.PCMFrameBytes(1))
+	for i := 0; i < len(frame); i += 2 {
+		frame[i], frame[i+1] = 0x10, 0x27 // 10000
+	}
+	for i := 0; i < 10; i++ {
+		frames <- frame
+		time.Sleep(constants.AudioFrameInterval)
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not finish after stop")
+	}
+
+	if len(encoder.frames) < 2 {
+		t.Fatalf("encoded %d frames, want at least 2", len(encoder.frames))
+	}
+	first, last := encoder.frames[0], encoder.frames[len(encoder.frames)-1]
+	if first[0] > 100 || first[len(first)-1] != 10000 {
+		t.Errorf("first frame runs from %d to %d, want a fade in", first[0], first[len(first)-1])
+	}
+	if last[len(last)-1] != 0 {
+		t.Errorf("last frame ends at %d, want a fade out to silence", last[len(last)-1])
+	}
+
+	packets := drainPackets(sink)
+	if len(packets) < constants.TrailingSilenceFrames {
+		t.Fatalf("sent %d packets, want the stream followed by silence", len(packets))
+	}
+	for _, packet := range packets[len(packets)-constants.TrailingSilenceFrames:] {
+		if !bytes.Equal(packet, opusSilence) {
+			t.Errorf("trailing packet = %x, want the Opus silence frame", packet)
+		}
+	}
+}
+
+func TestStreamer_PauseFadesOutAndStopsSpeaking(t *testing.T) {
+	streamer := NewStreamer()
+	encoder := &recordingEncoder{}
+	streamer.encoder = encoder
+	sink := NewLoopbackSink(100)
+
+	jitter := audio.DefaultJitterConfig()
+	frames := make(chan []byte)
+	stop := make(chan bool)
+	done := make(chan struct{})
+	go streamer.streamAudio(frames, stop, done, newVoiceOutput(sink, constants.SilenceHoldTime), 1, audio.NewJitterBuffer(constants.PCMFrameBytes(1), jitter), audio.NewDriftCompensator(1), nil, newPacketQueue(jitter, 1))
+
+	frame := make([]byte, constants.PCMFrameBytes(1))
+	for i := 0; i < len(frame); i += 2 {
+		frame[i], frame[i+1] = 0x10, 0x27 // 10000
+	}
+	play := func(n int) {
+		for i := 0; i < n; i++ {
+			frames <- frame
+			time.Sleep(constants.AudioFrameInterval)
+		}
+	}
+
+	// Audio keeps arriving while paused, so only the pause can end it
+	play(10)
+	streamer.Pause()
+	play(10)
+	if sink.IsSpeaking() {
+		t.Error("pausing should stop speaking")
+	}
+	packets := drainPackets(sink)
+	if len(packets) <= constants.TrailingSilenceFrames {
+		t.Fatalf("sent %d packets, want the stream followed by silence", len(packets))
+	}
+	tail := len(packets) - constants.TrailingSilenceFrames
+	for _, packet := range packets[tail:] {
+		if !bytes.Equal(packet, opusSilence) {
+			t.Errorf("packet after pausing = %x, want the Opus silence frame", packet)
+		}
+	}
+	if bytes.Equal(packets[tail-1], opusSilence) {
+		t.Error("pausing should send the trailing silence only once")
+	}
+
+	streamer.Resume()
+	play(5)
+	if !sink.IsSpeaking() {
+		t.Error("resuming should speak again")
+	}
+	close(stop)
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("stream did not finish after stop")
+	}
+
+	// The pause leaves a faded tail, and the audio after it fades back in
+	paused := -1
+	for i, pcm := range encoder.frames[:len(encoder.frames)-1] {
+		if pcm[len(pcm)-1] == 0 {
+			paused = i
+			break
+		}
+	}
+	if paused < 0 {
+		t.Fatal("no frame faded out when pausing")
+	}
+	if resumed := encoder.frames[paused+1]; resumed[0] > 100 || resumed[len(resumed)-1] != 10000 {
+		t.Errorf("first frame after resuming runs from %d to %d, want a fade in", resumed[0], resumed[len(resumed)-1])
+	}
+}
+
+func TestStreamer_Recording(t *testing.T) {
+	streamer := NewStreamer()
+	if _, err := streamer.StartRecording(); err == nil {
+		t.Error("StartRecording() should fail while disconnected")
+	}
+
+	dir := t.TempDir()
+	streamer.SetRecordingDir(dir)
+	streamer.mutex.Lock()
+	streamer.connected = true
+	streamer.guildID, streamer.channelID = "guild", "channel"
+	streamer.mutex.Unlock()
+
+	path, err := streamer.StartRecording()
+	if err != nil {
+		t.Fatalf("StartRecording() error = %v", err)
+	}
+	if filepath.Dir(path) != dir || !strings.HasPrefix(filepath.Base(path), "guild-channel-") {
+		t.Errorf("StartRecording() path = %s, want a guild-channel file in %s", path, dir)
+	}
+	if _, err := streamer.StartRecording(); err == nil {
+		t.Error("StartRecording() should fail while already recording")
+	}
+
+	// Only packets that reach Discord are recorded
+	out := newVoiceOutput(NewLoopbackSink(60), 0)
+	out.tap = streamer.record
+	streamer.MarkTrack("Opening")
+	for i := 0; i < 100; i++ {
+		out.send(opusSilence)
+	}
+	streamer.MarkTrack("Second Song")
+
+	if stats := streamer.GetRecordingStats(); !stats.Active || stats.Chapters != 2 {
+		t.Errorf("GetRecordingStats() = %+v, want an active recording with 2 chapters", stats)
+	}
+	if err := streamer.StopRecording(); err != nil {
+		t.Fatalf("StopRecording() error = %v", err)
+	}
+	stats := streamer.GetRecordingStats()
+	if stats.Active || stats.Path != path || stats.Duration != 1200*time.Millisecond-opus.OggPreSkip*time.Second/48000 {
+		t.Errorf("GetRecordingStats() after stop = %+v, want the finished 1.2s recording", stats)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.HasPrefix(data, []byte("OggS")) ||
+		!bytes.Contains(data, []byte("CHAPTER001NAME=Opening")) || !bytes.Contains(data, []byte("CHAPTER002=00:00:01.193")) {
+		t.Error("recording should be an Ogg stream with a chapter comment for each track")
+	}
+}
+
+func TestStreamer_StreamsToSink(t *testing.T) {
+	streamer := NewStreamer()
+	if err := streamer.SetChannels(constants.StereoChannels); err != nil {
+		t.Fatalf("SetChannels() error = %v", err)
+	}
+	sink := NewLoopbackSink(200)
+	if err := streamer.ConnectSink("loopback", sink); err != nil {
+		t.Fatalf("ConnectSink() error = %v", err)
+	}
+	if err := streamer.ConnectSink("loopback", NewLoopbackSink(1)); err == nil {
+		t.Error("ConnectSink() should fail for an ID already connected")
+	}
+
+	frames := make(chan []byte)
+	if err := streamer.StartStreaming(frames); err != nil {
+		t.Fatalf("StartStreaming() error = %v", err)
+	}
+
+	// Half a second of a 440Hz tone
+	streamTone(frames, 25)
+	if !sink.IsSpeaking() {
+		t.Error("sink should be speaking while the tone plays")
+	}
+
+	// The sink stops speaking when the stream stops
+	streamer.StopStreaming()
+	waitSpeaking(t, streamer.targets, "loopback", false)
+	packets := drainPackets(sink)
+	if len(packets) < 15+constants.TrailingSilenceFrames {
+		t.Fatalf("sink got %d packets, want most of the tone and the trailing silence", len(packets))
+	}
+	for i, packet := range packets {
+		if duration, err := opus.PacketDuration(packet); err != nil || duration != constants.AudioFrameInterval {
+			t.Fatalf("packet %d is not a 20ms Opus packet: %v", i, err)
+		}
+	}
+	for _, packet := range packets[len(packets)-constants.TrailingSilenceFrames:] {
+		if !bytes.Equal(packet, opusSilence) {
+			t.Errorf("trailing packet = %x, want the Opus silence frame", packet)
+		}
+	}
+
+	streamer.Disconnect()
+	if sink.Ready() || streamer.IsConnected() {
+		t.Error("Disconnect() should close the sink")
+	}
+}
+
+// streamTone sends n frames of a stereo tone at the pace of a live source.
+func streamTone(frames chan<- []byte, n int) {
+	for i := 0; i < n; i++ {
+		frame := make([]byte, constants.PCMFrameBytes(2))
+		for j := 0; j < constants.PCMFrameSize; j++ {
+			sample := int16(8000 * math.Sin(2*math.Pi*440*float64(i*constants.PCMFrameSize+j)/constants.SampleRate))
+			binary.LittleEndian.PutUint16(frame[j*4:], uint16(sample))
+			binary.LittleEndian.PutUint16(frame[j*4+2:], uint16(sample))
+		}
+		frames <- frame
+		time.Sleep(constants.AudioFrameInterval)
+	}
+}
+
+func TestStreamer_FansOutToTargets(t *testing.T) {
+	streamer := NewStreamer()
+	streamer.SetChannels(constants.StereoChannels)
+	first, second := NewLoopbackSink(200), NewLoopbackSink(200)
+	streamer.ConnectSink("first", first)
+
+	frames := make(chan []byte)
+	if err := streamer.StartStreaming(frames); err != nil {
+		t.Fatalf("StartStreaming() error = %v", err)
+	}
+	streamTone(frames, 10)
+
+	// Targets join and leave without interrupting the stream
+	if err := streamer.ConnectSink("second", second); err != nil {
+		t.Fatalf("ConnectSink() error = %v", err)
+	}
+	streamTone(frames, 10)
+	if err := streamer.DisconnectTarget("first"); err != nil {
+		t.Fatalf("DisconnectTarget() error = %v", err)
+	}
+	if first.Ready() || !streamer.IsStreaming() {
+		t.Error("DisconnectTarget() should close only that target")
+	}
+	streamTone(frames, 10)
+
+	targets := streamer.GetTargets()
+	if len(targets) != 1 || targets[0].ID != "second" || !targets[0].Ready || !targets[0].Speaking || targets[0].Sent < 15 {
+		t.Errorf("GetTargets() = %+v, want the second target speaking", targets)
+	}
+	if got := len(first.Packets()); got < 15 {
+		t.Errorf("first target got %d packets, want those sent while it was connected", got)
+	}
+	if err := streamer.DisconnectTarget("first"); err == nil {
+		t.Error("DisconnectTarget() should fail for a target that is not connected")
+	}
+
+	// Leaving the last target disconnects
+	streamer.DisconnectTarget("second")
+	if streamer.IsConnected() || streamer.IsStreaming() || second.Ready() {
+		t.Error("leaving the last target should stop streaming and disconnect")
+	}
+}
+
+func TestStreamer_TargetsShareLowestBitrate(t *testing.T) {
+	streamer := NewStreamer()
+	streamer.mutex.Lock()
+	streamer.targets.add("g1", "g1", "c1", 96000, NewLoopbackSink(1))
+	streamer.targets.add("g2", "g2", "c2", 64000, NewLoopbackSink(1))
+	streamer.updateTargets()
+	streamer.mutex.Unlock()
+
+	if got := streamer.GetMaxBitrate(); got != 64000 {
+		t.Errorf("GetMaxBitrate() = %d, want the lower channel limit 64000", got)
+	}
+	if streamer.GetGuildID() != "g1" || streamer.GetChannelID() != "c1" || !streamer.IsConnected() {
+		t.Errorf("primary target = %s/%s, want the first to connect", streamer.GetGuildID(), streamer.GetChannelID())
+	}
+}
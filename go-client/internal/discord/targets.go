@@ -0,0 +1,281 @@
+package discord
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// TargetStats describes one voice channel, or other sink, that a stream
+// goes to.
+type TargetStats struct {
+	ID         string // the guild ID for Discord voice channels
+	GuildID    string
+	ChannelID  string
+	MaxBitrate int // the channel's bitrate limit, 0 if unknown
+	Connected  time.Time
+	Ready      bool
+	Speaking   bool   // whether the target is meant to be speaking
+	Error      string // why the last speaking change failed, "" once one succeeds
+	Sent       uint64
+	Dropped    uint64
+	LastSent   time.Time
+}
+
+// target is one sink of a fanOut. Speaking changes are voice gateway
+// writes, so each target applies them in its own goroutine, where a slow
+// gateway holds up only its own channel.
+type target struct {
+	stats    TargetStats // the fields set when connecting
+	sink     VoiceSink
+	sent     atomic.Uint64
+	dropped  atomic.Uint64
+	lastSent atomic.Int64          // UnixNano, 0 before the first packet
+	want     atomic.Bool           // the speaking state asked for
+	speaking atomic.Bool           // the speaking state the sink has taken
+	failure  atomic.Pointer[error] // the last speaking change's error, nil once one succeeds
+	wake     chan struct{}
+	done     chan struct{}
+}
+
+func newTarget(stats TargetStats, sink VoiceSink) *target {
+	t := &target{
+		stats: stats,
+		sink:  sink,
+		wake:  make(chan struct{}, 1),
+		done:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+// fanOut is the sink a stream writes to. It passes every packet on to each
+// target that is ready, so one encoder feeds several voice channels, and
+// targets can join and leave mid-stream. The target list is replaced, never
+// modified, so sending works on a snapshot without holding the lock.
+type fanOut struct {
+	mu       sync.Mutex
+	targets  []*target // in the order they connected
+	speaking bool
+}
+
+func newFanOut() *fanOut {
+	return &fanOut{}
+}
+
+func (f *fanOut) snapshot() ([]*target, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.targets, f.speaking
+}
+
+// add connects a target, replacing one with the same ID. It returns the
+// replaced sink if it is not the new one, for the caller to close.
+func (f *fanOut) add(id, guildID, channelID string, maxBitrate int, sink VoiceSink) VoiceSink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	t := newTarget(TargetStats{ID: id, GuildID: guildID, ChannelID: channelID, MaxBitrate: maxBitrate, Connected: time.Now()}, sink)
+	targets := make([]*target, 0, len(f.targets)+1)
+	var replaced *target
+	for _, existing := range f.targets {
+		if existing.stats.ID == id {
+			replaced = existing
+			targets = append(targets, t)
+			continue
+		}
+		targets = append(targets, existing)
+	}
+	if replaced == nil {
+		targets = append(targets, t)
+	}
+	f.targets = targets
+
+	if replaced == nil {
+		return nil
+	}
+	close(replaced.done)
+	if replaced.sink == sink {
+		return nil
+	}
+	return replaced.sink
+}
+
+// has reports whether a target with the ID is connected.
+func (f *fanOut) has(id string) bool {
+	targets, _ := f.snapshot()
+	for _, t := range targets {
+		if t.stats.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+// remove disconnects a target and returns its sink, or nil if there is no
+// such target.
+func (f *fanOut) remove(id string) VoiceSink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for i, t := range f.targets {
+		if t.stats.ID != id {
+			continue
+		}
+		targets := make([]*target, 0, len(f.targets)-1)
+		targets = append(targets, f.targets[:i]...)
+		f.targets = append(targets, f.targets[i+1:]...)
+		close(t.done)
+		return t.sink
+	}
+	return nil
+}
+
+// removeAll disconnects every target and returns their sinks.
+func (f *fanOut) removeAll() []VoiceSink {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	sinks := make([]VoiceSink, len(f.targets))
+	for i, t := range f.targets {
+		close(t.done)
+		sinks[i] = t.sink
+	}
+	f.targets = nil
+	return sinks
+}
+
+func (f *fanOut) stats() []TargetStats {
+	targets, _ := f.snapshot()
+
+	stats := make([]TargetStats, len(targets))
+	for i, t := range targets {
+		stats[i] = t.stats
+		stats[i].Ready = t.sink.Ready()
+		stats[i].Speaking = t.want.Load()
+		if err := t.failure.Load(); err != nil {
+			stats[i].Error = (*err).Error()
+		}
+		stats[i].Sent = t.sent.Load()
+		stats[i].Dropped = t.dropped.Load()
+		if sent := t.lastSent.Load(); sent != 0 {
+			stats[i].LastSent = time.Unix(0, sent)
+		}
+	}
+	return stats
+}
+
+// Ready reports whether any target can be sent to.
+func (f *fanOut) Ready() bool {
+	targets, _ := f.snapshot()
+	for _, t := range targets {
+		if t.sink.Ready() {
+			return true
+		}
+	}
+	return false
+}
+
+// Send passes a packet on to every ready target and reports whether any
+// accepted it. A target is only sent to once it has taken the stream's
+// speaking state, so one that became ready mid-stream, or whose gateway is
+// slow, starts speaking before its first packet.
+func (f *fanOut) Send(packet []byte) bool {
+	targets, speaking := f.snapshot()
+
+	sent := false
+	for _, t := range targets {
+		if !t.sink.Ready() {
+			continue
+		}
+		if t.speaking.Load() != speaking {
+			t.setSpeaking(speaking)
+			if speaking {
+				continue
+			}
+		}
+		if !t.sink.Send(packet) {
+			t.dropped.Add(1)
+			continue
+		}
+		t.sent.Add(1)
+		t.lastSent.Store(time.Now().UnixNano())
+		sent = true
+	}
+	return sent
+}
+
+// Speaking asks every ready target to change its speaking state. The
+// changes are made in the background, so a slow gateway holds up no one;
+// the error joins those of the targets whose last change failed, which
+// their stats also report.
+func (f *fanOut) Speaking(speaking bool) error {
+	f.mu.Lock()
+	f.speaking = speaking
+	targets := f.targets
+	f.mu.Unlock()
+
+	var errs []error
+	for _, t := range targets {
+		if !t.sink.Ready() {
+			continue
+		}
+		t.setSpeaking(speaking)
+		if err := t.failure.Load(); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", t.stats.ID, *err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Close disconnects and closes every target.
+func (f *fanOut) Close() error {
+	var err error
+	for _, sink := range f.removeAll() {
+		if closeErr := sink.Close(); closeErr != nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+func (t *target) setSpeaking(speaking bool) {
+	t.want.Store(speaking)
+	select {
+	case t.wake <- struct{}{}:
+	default:
+	}
+}
+
+// run applies speaking changes until the target is removed. Send asks
+// again on every packet until a change is taken, so only the first failure
+// in a row is logged.
+func (t *target) run() {
+	failing := false
+	for {
+		select {
+		case <-t.done:
+			return
+		case <-t.wake:
+		}
+
+		want := t.want.Load()
+		if want == t.speaking.Load() {
+			continue
+		}
+		if err := t.sink.Speaking(want); err != nil {
+			if !failing {
+				log.Printf("Failed to set speaking on %s: %v", t.stats.ID, err)
+			}
+			failing = true
+			t.failure.Store(&err)
+			continue
+		}
+		failing = false
+		t.failure.Store(nil)
+		t.speaking.Store(want)
+	}
+}
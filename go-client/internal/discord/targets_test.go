@@ -0,0 +1,151 @@
+package discord
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// gatedSink is a loopback sink that is not ready until opened.
+type gatedSink struct {
+	*LoopbackSink
+	open bool
+}
+
+func (g *gatedSink) Ready() bool {
+	return g.open
+}
+
+// stuckSink is a sink whose speaking updates never finish, like a voice
+// gateway that stopped responding.
+type stuckSink struct {
+	*LoopbackSink
+}
+
+func (s stuckSink) Speaking(speaking bool) error {
+	select {}
+}
+
+// failingSink is a sink whose speaking updates are refused.
+type failingSink struct {
+	*LoopbackSink
+}
+
+func (f failingSink) Speaking(speaking bool) error {
+	return errors.New("gateway closed")
+}
+
+// waitSpeaking waits until a target has taken a speaking state.
+func waitSpeaking(t *testing.T, f *fanOut, id string, want bool) {
+	t.Helper()
+	targets, _ := f.snapshot()
+	for _, target := range targets {
+		if target.stats.ID != id {
+			continue
+		}
+		for deadline := time.Now().Add(time.Second); target.speaking.Load() != want; {
+			if time.Now().After(deadline) {
+				t.Fatalf("target %s speaking = %v, want %v", id, !want, want)
+			}
+			time.Sleep(time.Millisecond)
+		}
+		return
+	}
+	t.Fatalf("no target %s", id)
+}
+
+func TestFanOut(t *testing.T) {
+	f := newFanOut()
+	full := NewLoopbackSink(1)
+	late := &gatedSink{LoopbackSink: NewLoopbackSink(10)}
+	f.add("a", "", "", 0, full)
+	f.add("b", "", "", 0, late)
+
+	f.Speaking(true)
+	waitSpeaking(t, f, "a", true)
+	if late.IsSpeaking() {
+		t.Error("Speaking() should reach only the ready targets")
+	}
+	if !f.Send([]byte{1}) || f.Send([]byte{2}) {
+		t.Error("Send() should report whether any target took the packet")
+	}
+
+	// A target that becomes ready starts speaking before its first packet
+	late.open = true
+	f.Send([]byte{3})
+	if len(late.Packets()) != 0 {
+		t.Error("a target should not be sent to before it speaks")
+	}
+	waitSpeaking(t, f, "b", true)
+	f.Send([]byte{4})
+	if !late.IsSpeaking() || len(late.Packets()) != 1 {
+		t.Errorf("late target speaking = %v with %d packets, want speaking with 1", late.IsSpeaking(), len(late.Packets()))
+	}
+
+	stats := f.stats()
+	if len(stats) != 2 || stats[0].Sent != 1 || stats[0].Dropped != 3 || stats[1].Sent != 1 || stats[1].Dropped != 0 {
+		t.Errorf("stats() = %+v, want a: 1 sent 3 dropped, b: 1 sent", stats)
+	}
+
+	if replaced := f.add("a", "", "", 0, NewLoopbackSink(1)); replaced != full {
+		t.Error("add() should return the sink it replaces")
+	}
+	if replaced := f.add("b", "", "", 0, late); replaced != nil {
+		t.Error("add() should not return a sink that is added again")
+	}
+	if f.remove("b") != late || f.remove("b") != nil || len(f.stats()) != 1 {
+		t.Error("remove() should return the sink once")
+	}
+}
+
+func TestFanOutStuckTarget(t *testing.T) {
+	f := newFanOut()
+	healthy := NewLoopbackSink(10)
+	f.add("healthy", "", "", 0, healthy)
+	f.add("stuck", "", "", 0, stuckSink{NewLoopbackSink(10)})
+
+	f.Speaking(true)
+	waitSpeaking(t, f, "healthy", true)
+
+	done := make(chan struct{})
+	go func() {
+		for i := 0; i < 5; i++ {
+			f.Send([]byte{byte(i)})
+		}
+		f.remove("stuck")
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("a stuck target held up the others")
+	}
+
+	if len(healthy.Packets()) != 5 {
+		t.Errorf("healthy target got %d packets, want 5", len(healthy.Packets()))
+	}
+}
+
+func TestFanOutReportsSpeakingFailures(t *testing.T) {
+	f := newFanOut()
+	f.add("healthy", "", "", 0, NewLoopbackSink(10))
+	f.add("broken", "", "", 0, failingSink{NewLoopbackSink(10)})
+
+	f.Speaking(true)
+	waitSpeaking(t, f, "healthy", true)
+	for deadline := time.Now().Add(time.Second); f.stats()[1].Error == ""; {
+		if time.Now().After(deadline) {
+			t.Fatal("stats() should report the failed speaking change")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	if stats := f.stats(); stats[0].Error != "" || stats[1].Error != "gateway closed" {
+		t.Errorf("stats() errors = %q and %q, want only the broken target's", stats[0].Error, stats[1].Error)
+	}
+
+	err := f.Speaking(false)
+	if err == nil || !strings.Contains(err.Error(), "broken: gateway closed") || strings.Contains(err.Error(), "healthy") {
+		t.Errorf("Speaking() error = %v, want the broken target's failure", err)
+	}
+}
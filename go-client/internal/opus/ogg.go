@@ -0,0 +1,262 @@
+package opus
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math/rand"
+	"time"
+)
+
+const (
+	// OggPreSkip is the encoder delay of libopus at 48kHz, which players
+	// discard from the start of the stream.
+	OggPreSkip = 312
+
+	oggSampleRate = 48000
+	// oggPageDuration bounds how much audio one page holds, and so how much
+	// is lost if the writer is never closed.
+	oggPageDuration = time.Second
+	oggMaxSegments  = 255
+	// oggTagsSize reserves room in the comment header so comments added
+	// while writing can be filled in when the stream is closed.
+	oggTagsSize = 8192
+
+	oggFlagBOS = 0x02
+	oggFlagEOS = 0x04
+)
+
+var oggCRCTable = func() [256]uint32 {
+	var table [256]uint32
+	for i := range table {
+		crc := uint32(i) << 24
+		for j := 0; j < 8; j++ {
+			if crc&0x80000000 != 0 {
+				crc = crc<<1 ^ 0x04c11db7
+			} else {
+				crc <<= 1
+			}
+		}
+		table[i] = crc
+	}
+	return table
+}()
+
+// OggWriter muxes Opus packets into an Ogg Opus stream (RFC 7845). The
+// stream is playable while it is being written; Close writes the comments
+// into the header and marks the end of the stream.
+type OggWriter struct {
+	w          io.Writer
+	seeker     io.Seeker // nil for streams that cannot be rewritten
+	serial     uint32
+	sequence   uint32
+	granule    int64
+	pending    [][]byte
+	pendingDur time.Duration
+	segments   int
+	tagsOffset int64
+	vendor     string
+	comments   []string
+}
+
+// NewOggWriter writes the Opus headers for a stream of the given channel
+// count and returns a writer for its packets. Comments can only be added
+// when w is also an io.Seeker.
+func NewOggWriter(w io.Writer, channels int, vendor string) (*OggWriter, error) {
+	o := &OggWriter{
+		w:      w,
+		serial: rand.Uint32(),
+		vendor: vendor,
+	}
+	o.seeker, _ = w.(io.Seeker)
+
+	head := make([]byte, 19)
+	copy(head, "OpusHead")
+	head[8] = 1 // version
+	head[9] = byte(channels)
+	binary.LittleEndian.PutUint16(head[10:], OggPreSkip)
+	binary.LittleEndian.PutUint32(head[12:], oggSampleRate)
+	if err := o.writePage([][]byte{head}, 0, oggFlagBOS); err != nil {
+		return nil, err
+	}
+
+	if o.seeker != nil {
+		offset, err := o.seeker.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return nil, err
+		}
+		o.tagsOffset = offset
+	}
+	tags, _ := o.tags()
+	if err := o.writePage([][]byte{tags}, 0, 0); err != nil {
+		return nil, err
+	}
+	return o, nil
+}
+
+// AddComment adds a NAME=value comment, written to the header on Close.
+func (o *OggWriter) AddComment(name, value string) {
+	o.comments = append(o.comments, name+"="+value)
+}
+
+// Duration returns how much audio has been written, after the pre-skip.
+func (o *OggWriter) Duration() time.Duration {
+	samples := o.granule + oggSamples(o.pendingDur) - OggPreSkip
+	if samples < 0 {
+		return 0
+	}
+	return time.Duration(samples) * time.Second / oggSampleRate
+}
+
+// WritePacket queues one Opus packet, writing a page once a second of audio
+// has been queued.
+func (o *OggWriter) WritePacket(packet []byte) error {
+	duration, err := PacketDuration(packet)
+	if err != nil {
+		return err
+	}
+
+	segments := len(packet)/255 + 1
+	if o.segments+segments > oggMaxSegments {
+		if err := o.flush(0); err != nil {
+			return err
+		}
+	}
+	o.pending = append(o.pending, append([]byte(nil), packet...))
+	o.pendingDur += duration
+	o.segments += segments
+	if o.pendingDur >= oggPageDuration {
+		return o.flush(0)
+	}
+	return nil
+}
+
+// Flush writes the queued packets as a page straight away, for streams that
+// are read while they are written.
+func (o *OggWriter) Flush() error {
+	return o.flush(0)
+}
+
+// Close writes the last page and fills in the comment header. It does not
+// close the underlying writer.
+func (o *OggWriter) Close() error {
+	if err := o.flush(oggFlagEOS); err != nil {
+		return err
+	}
+	if o.seeker == nil {
+		if len(o.comments) > 0 {
+			return fmt.Errorf("comments cannot be written to a stream that cannot seek")
+		}
+		return nil
+	}
+
+	tags, dropped := o.tags()
+	if _, err := o.seeker.Seek(o.tagsOffset, io.SeekStart); err != nil {
+		return err
+	}
+	// The header page keeps its size and sequence number
+	o.sequence = 1
+	if err := o.writePage([][]byte{tags}, 0, 0); err != nil {
+		return err
+	}
+	if _, err := o.seeker.Seek(0, io.SeekEnd); err != nil {
+		return err
+	}
+	if dropped > 0 {
+		return fmt.Errorf("%d comments did not fit in the header", dropped)
+	}
+	return nil
+}
+
+// flush writes the queued packets as one page. With the EOS flag it writes
+// a page even when nothing is queued.
+func (o *OggWriter) flush(flags byte) error {
+	if len(o.pending) == 0 && flags&oggFlagEOS == 0 {
+		return nil
+	}
+	o.granule += oggSamples(o.pendingDur)
+	err := o.writePage(o.pending, o.granule, flags)
+	o.pending = nil
+	o.pendingDur = 0
+	o.segments = 0
+	return err
+}
+
+// tags builds the comment header, padded to oggTagsSize when it can be
+// rewritten, leaving out the comments that do not fit. It returns how many
+// were left out.
+func (o *OggWriter) tags() ([]byte, int) {
+	size := 8 + 4 + len(o.vendor) + 4
+	count := 0
+	for _, comment := range o.comments {
+		if size+4+len(comment) > oggTagsSize {
+			break
+		}
+		size += 4 + len(comment)
+		count++
+	}
+
+	tags := make([]byte, 0, oggTagsSize)
+	tags = append(tags, "OpusTags"...)
+	tags = binary.LittleEndian.AppendUint32(tags, uint32(len(o.vendor)))
+	tags = append(tags, o.vendor...)
+	tags = binary.LittleEndian.AppendUint32(tags, uint32(count))
+	for _, comment := range o.comments[:count] {
+		tags = binary.LittleEndian.AppendUint32(tags, uint32(len(comment)))
+		tags = append(tags, comment...)
+	}
+	// Zero padding after the comments is allowed and ignored by readers
+	if o.seeker != nil {
+		tags = tags[:oggTagsSize]
+	}
+	return tags, len(o.comments) - count
+}
+
+func oggSamples(d time.Duration) int64 {
+	return int64(d * oggSampleRate / time.Second)
+}
+
+func (o *OggWriter) writePage(packets [][]byte, granule int64, flags byte) error {
+	var lacing []byte
+	bodySize := 0
+	for _, packet := range packets {
+		for n := len(packet); ; n -= 255 {
+			if n < 255 {
+				lacing = append(lacing, byte(n))
+				break
+			}
+			lacing = append(lacing, 255)
+		}
+		bodySize += len(packet)
+	}
+
+	page := make([]byte, 27, 27+len(lacing)+bodySize)
+	copy(page, "OggS")
+	page[5] = flags
+	binary.LittleEndian.PutUint64(page[6:], uint64(granule))
+	binary.LittleEndian.PutUint32(page[14:], o.serial)
+	binary.LittleEndian.PutUint32(page[18:], o.sequence)
+	page[26] = byte(len(lacing))
+	page = append(page, lacing...)
+	for _, packet := range packets {
+		page = append(page, packet...)
+	}
+
+	var crc uint32
+	for _, b := range page {
+		crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+	}
+	binary.LittleEndian.PutUint32(page[22:], crc)
+
+	o.sequence++
+	_, err := o.w.Write(page)
+	return err
+}
+
+// RecordingStats describes the recording of what is sent to Discord.
+type RecordingStats struct {
+	Active   bool
+	Path     string
+	Duration time.Duration
+	Chapters int
+}
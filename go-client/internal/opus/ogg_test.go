@@ -0,0 +1,176 @@
+package opus
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type oggPage struct {
+	flags    byte
+	granule  int64
+	sequence uint32
+	packets  [][]byte
+}
+
+// readOggPages splits a stream into pages, checking each page's CRC.
+func readOggPages(t *testing.T, data []byte) []oggPage {
+	t.Helper()
+	var pages []oggPage
+	for len(data) > 0 {
+		if len(data) < 27 || string(data[:4]) != "OggS" {
+			t.Fatalf("page %d: missing capture pattern", len(pages))
+		}
+		segments := int(data[26])
+		size := 27 + segments
+		for _, lace := range data[27 : 27+segments] {
+			size += int(lace)
+		}
+
+		page := append([]byte(nil), data[:size]...)
+		want := binary.LittleEndian.Uint32(page[22:])
+		binary.LittleEndian.PutUint32(page[22:], 0)
+		var crc uint32
+		for _, b := range page {
+			crc = crc<<8 ^ oggCRCTable[byte(crc>>24)^b]
+		}
+		if crc != want {
+			t.Fatalf("page %d: CRC = %08x, want %08x", len(pages), crc, want)
+		}
+
+		p := oggPage{
+			flags:    page[5],
+			granule:  int64(binary.LittleEndian.Uint64(page[6:])),
+			sequence: binary.LittleEndian.Uint32(page[18:]),
+		}
+		body := page[27+segments:]
+		var packet []byte
+		for _, lace := range page[27 : 27+segments] {
+			packet = append(packet, body[:lace]...)
+			body = body[lace:]
+			if lace < 255 {
+				p.packets = append(p.packets, packet)
+				packet = nil
+			}
+		}
+		pages = append(pages, p)
+		data = data[size:]
+	}
+	return pages
+}
+
+func TestOggWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.ogg")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := NewOggWriter(file, 2, "trunecord test")
+	if err != nil {
+		t.Fatalf("NewOggWriter() error = %v", err)
+	}
+
+	// 1.5s of 20ms CELT packets, one of them large enough to span segments
+	packet := []byte{31<<3 | 0x4, 0xaa, 0xbb}
+	for i := 0; i < 75; i++ {
+		if i == 10 {
+			if err := w.WritePacket(append(packet, make([]byte, 600)...)); err != nil {
+				t.Fatalf("WritePacket() error = %v", err)
+			}
+			continue
+		}
+		if err := w.WritePacket(packet); err != nil {
+			t.Fatalf("WritePacket() error = %v", err)
+		}
+	}
+	if got, want := w.Duration(), 1500*time.Millisecond-OggPreSkip*time.Second/48000; got != want {
+		t.Errorf("Duration() = %v, want %v", got, want)
+	}
+	w.AddComment("CHAPTER001", "00:00:00.000")
+	w.AddComment("CHAPTER001NAME", "First")
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pages := readOggPages(t, data)
+	if len(pages) != 4 {
+		t.Fatalf("got %d pages, want headers, a full second and the rest", len(pages))
+	}
+	for i, page := range pages {
+		if page.sequence != uint32(i) {
+			t.Errorf("page %d has sequence %d", i, page.sequence)
+		}
+	}
+
+	head := pages[0]
+	if head.flags != oggFlagBOS || len(head.packets) != 1 || !bytes.HasPrefix(head.packets[0], []byte("OpusHead")) || head.packets[0][9] != 2 {
+		t.Errorf("first page = %+v, want a BOS page with a stereo OpusHead", head)
+	}
+
+	tags := string(pages[1].packets[0])
+	if !strings.HasPrefix(tags, "OpusTags") || !strings.Contains(tags, "trunecord test") ||
+		!strings.Contains(tags, "CHAPTER001=00:00:00.000") || !strings.Contains(tags, "CHAPTER001NAME=First") {
+		t.Errorf("comment header = %q, want the vendor and chapter comments", strings.TrimRight(tags, "\x00"))
+	}
+
+	if pages[2].granule != 48000 || len(pages[2].packets) != 50 || len(pages[2].packets[10]) != 603 {
+		t.Errorf("second audio page ends at %d with %d packets, want 48000 and 50", pages[2].granule, len(pages[2].packets))
+	}
+	last := pages[3]
+	if last.flags != oggFlagEOS || last.granule != 72000 || len(last.packets) != 25 {
+		t.Errorf("last page = flags %x granule %d with %d packets, want EOS at 72000 with 25", last.flags, last.granule, len(last.packets))
+	}
+}
+
+func TestOggWriterStreams(t *testing.T) {
+	var buf bytes.Buffer
+	w, err := NewOggWriter(&buf, 1, "trunecord test")
+	if err != nil {
+		t.Fatalf("NewOggWriter() error = %v", err)
+	}
+	packet := []byte{31 << 3, 0xaa}
+	w.WritePacket(packet)
+	w.WritePacket(packet)
+	if err := w.Flush(); err != nil {
+		t.Fatalf("Flush() error = %v", err)
+	}
+
+	pages := readOggPages(t, buf.Bytes())
+	if len(pages) != 3 || len(pages[2].packets) != 2 || pages[2].granule != 1920 {
+		t.Fatalf("got %d pages, want the headers and a page with both packets", len(pages))
+	}
+	if size := len(pages[1].packets[0]); size >= oggTagsSize {
+		t.Errorf("comment header is %d bytes, want it unpadded when it cannot be rewritten", size)
+	}
+
+	w.AddComment("TITLE", "live")
+	if err := w.Close(); err == nil {
+		t.Error("Close() should report comments that cannot be written")
+	}
+}
+
+func TestOggWriterRejectsInvalidPackets(t *testing.T) {
+	file, err := os.Create(filepath.Join(t.TempDir(), "test.ogg"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	w, err := NewOggWriter(file, 1, "trunecord test")
+	if err != nil {
+		t.Fatalf("NewOggWriter() error = %v", err)
+	}
+	if err := w.WritePacket(nil); err == nil {
+		t.Error("WritePacket() should reject an empty packet")
+	}
+}
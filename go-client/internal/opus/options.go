@@ -0,0 +1,86 @@
+package opus
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"trunecord/internal/constants"
+)
+
+// Application selects how the encoder tunes itself for the content.
+type Application string
+
+const (
+	// ApplicationMusic favours fidelity for music and mixed content.
+	ApplicationMusic Application = "music"
+	// ApplicationVoice favours speech intelligibility.
+	ApplicationVoice Application = "voice"
+)
+
+// ParseApplication accepts "music" or "voice" in any case.
+func ParseApplication(name string) (Application, error) {
+	switch app := Application(strings.ToLower(strings.TrimSpace(name))); app {
+	case ApplicationMusic, ApplicationVoice:
+		return app, nil
+	default:
+		return "", fmt.Errorf("unknown application %q: must be music or voice", name)
+	}
+}
+
+// BitrateAuto lets the streamer match the voice channel's bitrate.
+const BitrateAuto = 0
+
+// ParseBitrate accepts a bitrate in bits per second or "auto".
+func ParseBitrate(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	if strings.EqualFold(value, "auto") {
+		return BitrateAuto, nil
+	}
+	bitrate, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("bitrate must be a number or auto: %s", value)
+	}
+	return bitrate, nil
+}
+
+// Options are the tunable Opus encoder parameters.
+type Options struct {
+	Bitrate           int         `json:"bitrate"`
+	Complexity        int         `json:"complexity"`
+	FEC               bool        `json:"fec"`
+	PacketLossPercent int         `json:"packetLossPercent"`
+	DTX               bool        `json:"dtx"`
+	Application       Application `json:"application"`
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Bitrate:     BitrateAuto,
+		Complexity:  constants.OpusComplexity,
+		Application: ApplicationMusic,
+	}
+}
+
+// Validate checks the options against libopus limits and, when maxBitrate is
+// positive, against the bitrate allowed by the target voice channel.
+func (o Options) Validate(maxBitrate int) error {
+	if o.Bitrate != BitrateAuto {
+		if o.Bitrate < constants.OpusMinBitrate || o.Bitrate > constants.OpusMaxBitrate {
+			return fmt.Errorf("bitrate must be auto or between %d and %d bps: %d", constants.OpusMinBitrate, constants.OpusMaxBitrate, o.Bitrate)
+		}
+		if maxBitrate > 0 && o.Bitrate > maxBitrate {
+			return fmt.Errorf("bitrate %d bps exceeds the voice channel limit of %d bps", o.Bitrate, maxBitrate)
+		}
+	}
+	if o.Complexity < 0 || o.Complexity > constants.OpusMaxComplexity {
+		return fmt.Errorf("complexity must be between 0 and %d: %d", constants.OpusMaxComplexity, o.Complexity)
+	}
+	if o.PacketLossPercent < 0 || o.PacketLossPercent > 100 {
+		return fmt.Errorf("packet loss must be between 0 and 100 percent: %d", o.PacketLossPercent)
+	}
+	if _, err := ParseApplication(string(o.Application)); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package opus
+
+import "testing"
+
+func TestDefaultOptionsAreValid(t *testing.T) {
+	if err := DefaultOptions().Validate(0); err != nil {
+		t.Errorf("DefaultOptions().Validate() error = %v", err)
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name       string
+		modify     func(o *Options)
+		maxBitrate int
+		wantErr    bool
+	}{
+		{name: "defaults", modify: func(o *Options) {}},
+		{name: "voice with fec", modify: func(o *Options) {
+			o.Application = ApplicationVoice
+			o.FEC = true
+			o.PacketLossPercent = 10
+			o.DTX = true
+		}},
+		{name: "auto bitrate", modify: func(o *Options) { o.Bitrate = BitrateAuto }, maxBitrate: 64000},
+		{name: "bitrate too low", modify: func(o *Options) { o.Bitrate = 1000 }, wantErr: true},
+		{name: "bitrate too high", modify: func(o *Options) { o.Bitrate = 600000 }, wantErr: true},
+		{name: "within channel limit", modify: func(o *Options) { o.Bitrate = 96000 }, maxBitrate: 96000},
+		{name: "above channel limit", modify: func(o *Options) { o.Bitrate = 128000 }, maxBitrate: 96000, wantErr: true},
+		{name: "complexity out of range", modify: func(o *Options) { o.Complexity = 11 }, wantErr: true},
+		{name: "negative packet loss", modify: func(o *Options) { o.PacketLossPercent = -1 }, wantErr: true},
+		{name: "unknown application", modify: func(o *Options) { o.Application = "lowdelay" }, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := DefaultOptions()
+			tt.modify(&options)
+			err := options.Validate(tt.maxBitrate)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Validate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestParseBitrate(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "auto", want: BitrateAuto},
+		{value: "AUTO", want: BitrateAuto},
+		{value: "96000", want: 96000},
+		{value: "96k", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseBitrate(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseBitrate(%q) = %d, %v; want %d, wantErr %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseApplication(t *testing.T) {
+	if app, err := ParseApplication(" Voice "); err != nil || app != ApplicationVoice {
+		t.Errorf("ParseApplication(\" Voice \") = %q, %v", app, err)
+	}
+	if _, err := ParseApplication("speech"); err == nil {
+		t.Error("ParseApplication(\"speech\") should fail")
+	}
+}
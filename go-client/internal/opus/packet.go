@@ -0,0 +1,67 @@
+package opus
+
+import (
+	"fmt"
+	"time"
+)
+
+// frameDurations maps the configuration number in a packet's TOC byte to the
+// duration of each frame (RFC 6716, section 3.1).
+var frameDurations = [32]time.Duration{
+	// SILK-only
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond, 40 * time.Millisecond, 60 * time.Millisecond,
+	// Hybrid
+	10 * time.Millisecond, 20 * time.Millisecond,
+	10 * time.Millisecond, 20 * time.Millisecond,
+	// CELT-only
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+	2500 * time.Microsecond, 5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond,
+}
+
+// PacketDuration returns how much audio an Opus packet holds, read from its
+// TOC byte and, for code 3 packets, its frame count byte.
+func PacketDuration(packet []byte) (time.Duration, error) {
+	if len(packet) == 0 {
+		return 0, fmt.Errorf("empty opus packet")
+	}
+
+	toc := packet[0]
+	frames := 1
+	switch toc & 0x3 {
+	case 1, 2:
+		frames = 2
+	case 3:
+		if len(packet) < 2 {
+			return 0, fmt.Errorf("opus packet missing frame count")
+		}
+		frames = int(packet[1] & 0x3f)
+		if frames == 0 {
+			return 0, fmt.Errorf("opus packet has no frames")
+		}
+	}
+
+	duration := frameDurations[toc>>3] * time.Duration(frames)
+	if duration > 120*time.Millisecond {
+		return 0, fmt.Errorf("opus packet too long: %v", duration)
+	}
+	return duration, nil
+}
+
+// PacketChannels reports whether a packet was encoded as mono (1) or stereo (2).
+func PacketChannels(packet []byte) int {
+	if len(packet) > 0 && packet[0]&0x4 != 0 {
+		return 2
+	}
+	return 1
+}
+
+// PassthroughStats counts pre-encoded packets sent to Discord as-is.
+type PassthroughStats struct {
+	Forwarded uint64
+	Rejected  uint64
+	Dropped   uint64
+}
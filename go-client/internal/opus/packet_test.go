@@ -0,0 +1,46 @@
+package opus
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPacketDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		packet  []byte
+		want    time.Duration
+		wantErr bool
+	}{
+		{name: "CELT 20ms", packet: []byte{31<<3 | 0, 0xff}, want: 20 * time.Millisecond},
+		{name: "SILK 20ms", packet: []byte{1<<3 | 0, 0xff}, want: 20 * time.Millisecond},
+		{name: "hybrid 10ms", packet: []byte{12<<3 | 0, 0xff}, want: 10 * time.Millisecond},
+		{name: "two CELT 10ms frames", packet: []byte{30<<3 | 1, 0xff}, want: 20 * time.Millisecond},
+		{name: "SILK 60ms", packet: []byte{3<<3 | 0, 0xff}, want: 60 * time.Millisecond},
+		{name: "four 2.5ms frames", packet: []byte{16<<3 | 3, 4, 0xff}, want: 10 * time.Millisecond},
+		{name: "code 3 over 120ms", packet: []byte{3<<3 | 3, 3, 0xff}, wantErr: true},
+		{name: "code 3 without count", packet: []byte{31<<3 | 3}, wantErr: true},
+		{name: "empty", packet: nil, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := PacketDuration(tt.packet)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PacketDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("PacketDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPacketChannels(t *testing.T) {
+	if got := PacketChannels([]byte{31<<3 | 0x4}); got != 2 {
+		t.Errorf("PacketChannels(stereo) = %d, want 2", got)
+	}
+	if got := PacketChannels([]byte{31 << 3}); got != 1 {
+		t.Errorf("PacketChannels(mono) = %d, want 1", got)
+	}
+}
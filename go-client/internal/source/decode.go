@@ -0,0 +1,241 @@
+package source
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/jfreymuth/oggvorbis"
+	"github.com/mewkiz/flac"
+)
+
+// decoder reads a local audio file as interleaved float32 samples in the
+// range [-1, 1]. Read returns io.EOF once the file is exhausted.
+type decoder interface {
+	SampleRate() int
+	Channels() int
+	Read(samples []float32) (int, error)
+	Close() error
+}
+
+// openDecoder picks a decoder from the file extension.
+func openDecoder(path string) (decoder, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var dec decoder
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".wav", ".wave":
+		dec, err = newWAVDecoder(file)
+	case ".ogg", ".oga":
+		dec, err = newVorbisDecoder(file)
+	case ".flac":
+		dec, err = newFLACDecoder(file)
+	default:
+		err = fmt.Errorf("unsupported file type: %s", filepath.Ext(path))
+	}
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	return dec, nil
+}
+
+// readFull reads until samples is full or the decoder fails.
+func readFull(dec decoder, samples []float32) (int, error) {
+	total := 0
+	for total < len(samples) {
+		n, err := dec.Read(samples[total:])
+		total += n
+		if err != nil {
+			return total, err
+		}
+		if n == 0 {
+			return total, io.ErrNoProgress
+		}
+	}
+	return total, nil
+}
+
+const (
+	wavFormatPCM        = 1
+	wavFormatFloat      = 3
+	wavFormatExtensible = 0xFFFE
+)
+
+type wavDecoder struct {
+	file       *os.File
+	data       io.Reader
+	sampleRate int
+	channels   int
+	bits       int
+	float      bool
+	buf        []byte
+}
+
+func newWAVDecoder(file *os.File) (*wavDecoder, error) {
+	var header [12]byte
+	if _, err := io.ReadFull(file, header[:]); err != nil {
+		return nil, fmt.Errorf("invalid WAV file: %v", err)
+	}
+	if string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return nil, errors.New("invalid WAV file: missing RIFF/WAVE header")
+	}
+
+	d := &wavDecoder{file: file}
+	haveFormat := false
+	for {
+		var chunk [8]byte
+		if _, err := io.ReadFull(file, chunk[:]); err != nil {
+			return nil, errors.New("invalid WAV file: no data chunk")
+		}
+		size := int64(binary.LittleEndian.Uint32(chunk[4:]))
+
+		switch string(chunk[0:4]) {
+		case "fmt ":
+			if size < 16 {
+				return nil, errors.New("invalid WAV file: short fmt chunk")
+			}
+			body := make([]byte, size)
+			if _, err := io.ReadFull(file, body); err != nil {
+				return nil, fmt.Errorf("invalid WAV file: %v", err)
+			}
+			formatTag := binary.LittleEndian.Uint16(body[0:])
+			if formatTag == wavFormatExtensible && size >= 26 {
+				formatTag = binary.LittleEndian.Uint16(body[24:])
+			}
+			d.channels = int(binary.LittleEndian.Uint16(body[2:]))
+			d.sampleRate = int(binary.LittleEndian.Uint32(body[4:]))
+			d.bits = int(binary.LittleEndian.Uint16(body[14:]))
+
+			switch {
+			case formatTag == wavFormatPCM && (d.bits == 8 || d.bits == 16 || d.bits == 24 || d.bits == 32):
+			case formatTag == wavFormatFloat && d.bits == 32:
+				d.float = true
+			default:
+				return nil, fmt.Errorf("unsupported WAV encoding: format %d, %d bits", formatTag, d.bits)
+			}
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return nil, errors.New("invalid WAV file: data before fmt chunk")
+			}
+			d.data = io.LimitReader(file, size)
+			return d, nil
+		default:
+			// Chunks are padded to an even size
+			if _, err := file.Seek(size+size%2, io.SeekCurrent); err != nil {
+				return nil, fmt.Errorf("invalid WAV file: %v", err)
+			}
+		}
+	}
+}
+
+func (d *wavDecoder) SampleRate() int { return d.sampleRate }
+func (d *wavDecoder) Channels() int   { return d.channels }
+func (d *wavDecoder) Close() error    { return d.file.Close() }
+
+func (d *wavDecoder) Read(samples []float32) (int, error) {
+	width := d.bits / 8
+	// Only read whole frames so channels stay aligned
+	frames := len(samples) / d.channels
+	size := frames * d.channels * width
+	if cap(d.buf) < size {
+		d.buf = make([]byte, size)
+	}
+	buf := d.buf[:size]
+
+	n, err := io.ReadFull(d.data, buf)
+	if err == io.ErrUnexpectedEOF {
+		err = io.EOF
+	}
+	n -= n % (d.channels * width)
+
+	count := n / width
+	for i := 0; i < count; i++ {
+		b := buf[i*width:]
+		switch {
+		case d.float:
+			samples[i] = math.Float32frombits(binary.LittleEndian.Uint32(b))
+		case width == 1:
+			samples[i] = float32(int(b[0])-128) / 128
+		case width == 2:
+			samples[i] = float32(int16(binary.LittleEndian.Uint16(b))) / 32768
+		case width == 3:
+			v := int32(b[0]) | int32(b[1])<<8 | int32(int8(b[2]))<<16
+			samples[i] = float32(v) / (1 << 23)
+		default:
+			samples[i] = float32(int32(binary.LittleEndian.Uint32(b))) / (1 << 31)
+		}
+	}
+	return count, err
+}
+
+type vorbisDecoder struct {
+	*oggvorbis.Reader
+	file *os.File
+}
+
+func newVorbisDecoder(file *os.File) (*vorbisDecoder, error) {
+	reader, err := oggvorbis.NewReader(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Ogg Vorbis file: %v", err)
+	}
+	return &vorbisDecoder{Reader: reader, file: file}, nil
+}
+
+func (d *vorbisDecoder) Close() error { return d.file.Close() }
+
+type flacDecoder struct {
+	stream  *flac.Stream
+	file    *os.File
+	scale   float32
+	buf     []float32
+	pending []float32
+}
+
+func newFLACDecoder(file *os.File) (*flacDecoder, error) {
+	stream, err := flac.New(file)
+	if err != nil {
+		return nil, fmt.Errorf("invalid FLAC file: %v", err)
+	}
+	return &flacDecoder{
+		stream: stream,
+		file:   file,
+		scale:  float32(int64(1) << (stream.Info.BitsPerSample - 1)),
+	}, nil
+}
+
+func (d *flacDecoder) SampleRate() int { return int(d.stream.Info.SampleRate) }
+func (d *flacDecoder) Channels() int   { return int(d.stream.Info.NChannels) }
+func (d *flacDecoder) Close() error    { return d.file.Close() }
+
+func (d *flacDecoder) Read(samples []float32) (int, error) {
+	if len(d.pending) == 0 {
+		frame, err := d.stream.ParseNext()
+		if err != nil {
+			return 0, err
+		}
+
+		channels := len(frame.Subframes)
+		blockSize := frame.Subframes[0].NSamples
+		d.buf = d.buf[:0]
+		for i := 0; i < blockSize; i++ {
+			for ch := 0; ch < channels; ch++ {
+				d.buf = append(d.buf, float32(frame.Subframes[ch].Samples[i])/d.scale)
+			}
+		}
+		d.pending = d.buf
+	}
+
+	n := copy(samples, d.pending)
+	d.pending = d.pending[n:]
+	return n, nil
+}
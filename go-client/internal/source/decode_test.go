@@ -0,0 +1,175 @@
+package source
+
+import (
+	"encoding/binary"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mewkiz/flac"
+	"github.com/mewkiz/flac/frame"
+	"github.com/mewkiz/flac/meta"
+)
+
+// writeWAV writes 16-bit PCM samples as a WAV file, with a LIST chunk before
+// the data so the parser has to skip it.
+func writeWAV(t *testing.T, path string, sampleRate, channels int, samples []int16) {
+	t.Helper()
+	data := make([]byte, len(samples)*2)
+	for i, s := range samples {
+		binary.LittleEndian.PutUint16(data[i*2:], uint16(s))
+	}
+
+	var buf []byte
+	le32 := func(v int) { buf = binary.LittleEndian.AppendUint32(buf, uint32(v)) }
+	le16 := func(v int) { buf = binary.LittleEndian.AppendUint16(buf, uint16(v)) }
+
+	buf = append(buf, "RIFF"...)
+	le32(4 + 24 + 12 + 8 + len(data))
+	buf = append(buf, "WAVE"...)
+	buf = append(buf, "fmt "...)
+	le32(16)
+	le16(wavFormatPCM)
+	le16(channels)
+	le32(sampleRate)
+	le32(sampleRate * channels * 2)
+	le16(channels * 2)
+	le16(16)
+	buf = append(buf, "LIST"...)
+	le32(3)
+	buf = append(buf, "abc\x00"...)
+	buf = append(buf, "data"...)
+	le32(len(data))
+	buf = append(buf, data...)
+
+	if err := os.WriteFile(path, buf, 0o644); err != nil {
+		t.Fatalf("failed to write %s: %v", path, err)
+	}
+}
+
+func readAll(t *testing.T, dec decoder) []float32 {
+	t.Helper()
+	var out []float32
+	buf := make([]float32, 100)
+	for {
+		n, err := readFull(dec, buf)
+		out = append(out, buf[:n]...)
+		if err == io.EOF {
+			return out
+		}
+		if err != nil {
+			t.Fatalf("Read() error = %v", err)
+		}
+	}
+}
+
+func TestWAVDecoder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tone.wav")
+	writeWAV(t, path, 44100, 2, []int16{16384, -16384, 0, 32767, -32768, 8192})
+
+	dec, err := openDecoder(path)
+	if err != nil {
+		t.Fatalf("openDecoder() error = %v", err)
+	}
+	defer dec.Close()
+
+	if dec.SampleRate() != 44100 || dec.Channels() != 2 {
+		t.Fatalf("format = %dHz/%dch, want 44100Hz/2ch", dec.SampleRate(), dec.Channels())
+	}
+
+	want := []float32{0.5, -0.5, 0, 32767.0 / 32768, -1, 0.25}
+	got := readAll(t, dec)
+	if len(got) != len(want) {
+		t.Fatalf("decoded %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("sample %d = %f, want %f", i, got[i], want[i])
+		}
+	}
+}
+
+func TestFLACDecoder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "tone.flac")
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make([]int32, 4096)
+	for i := range samples {
+		samples[i] = int32(16000 * math.Sin(float64(i)/10))
+	}
+	info := &meta.StreamInfo{
+		BlockSizeMin:  4096,
+		BlockSizeMax:  4096,
+		SampleRate:    48000,
+		NChannels:     1,
+		BitsPerSample: 16,
+	}
+	enc, err := flac.NewEncoder(file, info)
+	if err != nil {
+		t.Fatalf("NewEncoder() error = %v", err)
+	}
+	err = enc.WriteFrame(&frame.Frame{
+		Header: frame.Header{
+			HasFixedBlockSize: true,
+			BlockSize:         4096,
+			SampleRate:        48000,
+			Channels:          frame.ChannelsMono,
+			BitsPerSample:     16,
+		},
+		Subframes: []*frame.Subframe{{
+			SubHeader: frame.SubHeader{Pred: frame.PredVerbatim},
+			Samples:   samples,
+			NSamples:  len(samples),
+		}},
+	})
+	if err != nil {
+		t.Fatalf("WriteFrame() error = %v", err)
+	}
+	if err := enc.Close(); err != nil {
+		t.Fatalf("Close() error = %v", err)
+	}
+
+	dec, err := openDecoder(path)
+	if err != nil {
+		t.Fatalf("openDecoder() error = %v", err)
+	}
+	defer dec.Close()
+
+	if dec.SampleRate() != 48000 || dec.Channels() != 1 {
+		t.Fatalf("format = %dHz/%dch, want 48000Hz/1ch", dec.SampleRate(), dec.Channels())
+	}
+	got := readAll(t, dec)
+	if len(got) != len(samples) {
+		t.Fatalf("decoded %d samples, want %d", len(got), len(samples))
+	}
+	for i, s := range samples {
+		if want := float32(s) / 32768; got[i] != want {
+			t.Fatalf("sample %d = %f, want %f", i, got[i], want)
+		}
+	}
+}
+
+func TestOpenDecoderRejectsUnsupportedFiles(t *testing.T) {
+	dir := t.TempDir()
+
+	mp3 := filepath.Join(dir, "song.mp3")
+	os.WriteFile(mp3, []byte("ID3"), 0o644)
+	if _, err := openDecoder(mp3); err == nil {
+		t.Error("openDecoder() should reject unsupported extensions")
+	}
+
+	fake := filepath.Join(dir, "fake.wav")
+	os.WriteFile(fake, []byte("not a wav file"), 0o644)
+	if _, err := openDecoder(fake); err == nil {
+		t.Error("openDecoder() should reject a malformed WAV file")
+	}
+
+	if _, err := openDecoder(filepath.Join(dir, "missing.flac")); err == nil {
+		t.Error("openDecoder() should fail for a missing file")
+	}
+}
@@ -0,0 +1,355 @@
+package source
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+// QueueItem is a file waiting in, or playing from, the file source's queue.
+type QueueItem struct {
+	ID   string `json:"id"`
+	Path string `json:"path"`
+	Name string `json:"name"`
+}
+
+// FileSource plays local WAV, Ogg Vorbis and FLAC files from a queue in real
+// time. Stopping the source pauses the current file; starting it resumes.
+type FileSource struct {
+	mu        sync.Mutex
+	channels  int
+	mediaDir  string
+	frames    chan []byte
+	queue     []QueueItem
+	nextID    int
+	current   *QueueItem
+	decoder   decoder
+	converter *audio.Converter
+	samples   []float32
+	stop      chan struct{}
+	done      chan struct{}
+	onTrack   func(item QueueItem)
+}
+
+func NewFileSource(channels int) *FileSource {
+	return &FileSource{
+		channels: channels,
+		frames:   make(chan []byte, constants.AudioBufferSize),
+	}
+}
+
+func (f *FileSource) Name() string {
+	return constants.SourceFile
+}
+
+func (f *FileSource) Format() audio.Format {
+	return audio.PipelineFormat(f.channels)
+}
+
+func (f *FileSource) Frames() <-chan []byte {
+	return f.frames
+}
+
+func (f *FileSource) Start() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.stop != nil {
+		return nil
+	}
+	f.stop = make(chan struct{})
+	f.done = make(chan struct{})
+	go f.play(f.stop, f.done)
+	return nil
+}
+
+func (f *FileSource) Stop() error {
+	f.mu.Lock()
+	stop, done := f.stop, f.done
+	f.stop, f.done = nil, nil
+	f.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+// SetTrackListener sets a function called whenever a file starts playing.
+func (f *FileSource) SetTrackListener(listener func(item QueueItem)) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.onTrack = listener
+}
+
+// SetMediaDir sets the directory files are queued from. Add rejects files
+// outside it, and resolves relative paths against it.
+func (f *FileSource) SetMediaDir(dir string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mediaDir = dir
+}
+
+// Add checks that path is in the media directory and can be decoded, and
+// appends it to the queue.
+func (f *FileSource) Add(path string) (QueueItem, error) {
+	name := filepath.Base(path)
+	path, err := f.resolve(path)
+	if err != nil {
+		return QueueItem{}, err
+	}
+	dec, err := openDecoder(path)
+	if err != nil {
+		return QueueItem{}, err
+	}
+	err = f.inputFormat(dec).Validate()
+	dec.Close()
+	if err != nil {
+		return QueueItem{}, err
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextID++
+	item := QueueItem{
+		ID:   strconv.Itoa(f.nextID),
+		Path: path,
+		Name: name,
+	}
+	f.queue = append(f.queue, item)
+	return item, nil
+}
+
+// Remove drops a queued file. Removing the file that is playing skips it.
+func (f *FileSource) Remove(id string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.current != nil && f.current.ID == id {
+		f.closeCurrent()
+		return nil
+	}
+	index := f.indexOf(id)
+	if index < 0 {
+		return fmt.Errorf("queue item not found: %s", id)
+	}
+	f.queue = append(f.queue[:index], f.queue[index+1:]...)
+	return nil
+}
+
+// Move places a queued file at index, clamped to the queue bounds.
+func (f *FileSource) Move(id string, index int) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	from := f.indexOf(id)
+	if from < 0 {
+		return fmt.Errorf("queue item not found: %s", id)
+	}
+	if index < 0 {
+		index = 0
+	} else if index >= len(f.queue) {
+		index = len(f.queue) - 1
+	}
+
+	item := f.queue[from]
+	f.queue = append(f.queue[:from], f.queue[from+1:]...)
+	f.queue = append(f.queue[:index], append([]QueueItem{item}, f.queue[index:]...)...)
+	return nil
+}
+
+// Skip stops the current file; the next queued file starts on the next tick.
+func (f *FileSource) Skip() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.closeCurrent()
+}
+
+// Queue returns the files waiting to play, in order.
+func (f *FileSource) Queue() []QueueItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]QueueItem(nil), f.queue...)
+}
+
+// NowPlaying returns the current file, or nil when nothing is playing.
+func (f *FileSource) NowPlaying() *QueueItem {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.current == nil {
+		return nil
+	}
+	item := *f.current
+	return &item
+}
+
+func (f *FileSource) play(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(constants.AudioFrameInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			chunk, started := f.nextChunk()
+			f.notify(started)
+			if chunk == nil {
+				continue
+			}
+			select {
+			case f.frames <- chunk:
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// nextChunk decodes one frame interval of the current file, moving on to
+// the next queued file when it ends, and returns the files it started. The
+// chunk is nil when the queue is empty.
+func (f *FileSource) nextChunk() ([]byte, []QueueItem) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var started []QueueItem
+	for {
+		if f.decoder == nil {
+			if !f.openNext() {
+				return nil, started
+			}
+			started = append(started, *f.current)
+		}
+
+		converter, name := f.converter, f.current.Name
+		n, err := readFull(f.decoder, f.samples)
+		if err != nil {
+			if err != io.EOF {
+				log.Printf("Failed to decode %s: %v", name, err)
+			}
+			f.closeCurrent()
+		}
+		if n == 0 {
+			continue
+		}
+
+		chunk, err := converter.Convert(audio.EncodeFloat32(f.samples[:n], audio.EncodingFloat32))
+		if err != nil {
+			log.Printf("Failed to convert %s: %v", name, err)
+			f.closeCurrent()
+			continue
+		}
+		return chunk, started
+	}
+}
+
+// notify tells the track listener about started files, outside the lock so
+// the listener may call back into the source.
+func (f *FileSource) notify(started []QueueItem) {
+	if len(started) == 0 {
+		return
+	}
+	f.mu.Lock()
+	listener := f.onTrack
+	f.mu.Unlock()
+	if listener == nil {
+		return
+	}
+	for _, item := range started {
+		listener(item)
+	}
+}
+
+// openNext opens the head of the queue, skipping files that fail to open.
+func (f *FileSource) openNext() bool {
+	for len(f.queue) > 0 {
+		item := f.queue[0]
+		f.queue = f.queue[1:]
+
+		dec, err := openDecoder(item.Path)
+		if err != nil {
+			log.Printf("Failed to open %s: %v", item.Path, err)
+			continue
+		}
+		input := f.inputFormat(dec)
+		converter, err := audio.NewConverter(input, audio.PipelineFormat(f.channels))
+		if err != nil {
+			log.Printf("Cannot play %s: %v", item.Path, err)
+			dec.Close()
+			continue
+		}
+
+		f.current = &item
+		f.decoder = dec
+		f.converter = converter
+		frameSamples := input.SampleRate * input.Channels * int(constants.AudioFrameInterval/time.Millisecond) / 1000
+		f.samples = make([]float32, frameSamples)
+		log.Printf("Playing %s (%s)", item.Name, input)
+		return true
+	}
+	return false
+}
+
+func (f *FileSource) closeCurrent() {
+	if f.decoder != nil {
+		f.decoder.Close()
+	}
+	f.current = nil
+	f.decoder = nil
+	f.converter = nil
+}
+
+// resolve turns path into a clean absolute path with symlinks followed, and
+// checks that it is inside the media directory.
+func (f *FileSource) resolve(path string) (string, error) {
+	f.mu.Lock()
+	mediaDir := f.mediaDir
+	f.mu.Unlock()
+	if mediaDir == "" {
+		return "", fmt.Errorf("no media directory is configured")
+	}
+
+	root, err := filepath.EvalSymlinks(mediaDir)
+	if err != nil {
+		return "", fmt.Errorf("media directory unavailable: %v", err)
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(root, path)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Clean(path))
+	if err != nil {
+		return "", err
+	}
+	if rel, err := filepath.Rel(root, resolved); err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", fmt.Errorf("%s is outside the media directory %s", path, mediaDir)
+	}
+	return resolved, nil
+}
+
+func (f *FileSource) indexOf(id string) int {
+	for i, item := range f.queue {
+		if item.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+func (f *FileSource) inputFormat(dec decoder) audio.Format {
+	return audio.Format{
+		SampleRate: dec.SampleRate(),
+		Channels:   dec.Channels(),
+		Encoding:   audio.EncodingFloat32,
+	}
+}
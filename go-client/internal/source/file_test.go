@@ -0,0 +1,185 @@
+package source
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeConstantWAV writes a 48kHz mono file holding value for the duration.
+func writeConstantWAV(t *testing.T, dir, name string, value int16, duration time.Duration) string {
+	t.Helper()
+	samples := make([]int16, int(duration.Seconds()*48000))
+	for i := range samples {
+		samples[i] = value
+	}
+	path := filepath.Join(dir, name)
+	writeWAV(t, path, 48000, 1, samples)
+	return path
+}
+
+func queueIDs(f *FileSource) []string {
+	var ids []string
+	for _, item := range f.Queue() {
+		ids = append(ids, item.ID)
+	}
+	return ids
+}
+
+func TestFileSourceQueue(t *testing.T) {
+	dir := t.TempDir()
+	f := NewFileSource(1)
+	f.SetMediaDir(dir)
+
+	var ids []string
+	for _, name := range []string{"a.wav", "b.wav", "c.wav"} {
+		item, err := f.Add(writeConstantWAV(t, dir, name, 0, 20*time.Millisecond))
+		if err != nil {
+			t.Fatalf("Add(%s) error = %v", name, err)
+		}
+		if item.Name != name {
+			t.Errorf("item name = %q, want %q", item.Name, name)
+		}
+		ids = append(ids, item.ID)
+	}
+
+	if _, err := f.Add(filepath.Join(dir, "missing.wav")); err == nil {
+		t.Error("Add() should reject a file that cannot be opened")
+	}
+
+	if err := f.Move(ids[2], 0); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if got := queueIDs(f); got[0] != ids[2] || got[1] != ids[0] || got[2] != ids[1] {
+		t.Errorf("queue after Move(c, 0) = %v", got)
+	}
+
+	if err := f.Move(ids[2], 99); err != nil {
+		t.Fatalf("Move() error = %v", err)
+	}
+	if got := queueIDs(f); got[2] != ids[2] {
+		t.Errorf("Move() past the end should place the item last, got %v", got)
+	}
+
+	if err := f.Remove(ids[0]); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if got := queueIDs(f); len(got) != 2 || got[0] != ids[1] {
+		t.Errorf("queue after Remove(a) = %v", got)
+	}
+
+	if err := f.Remove("missing"); err == nil {
+		t.Error("Remove() should fail for an unknown item")
+	}
+}
+
+func TestFileSourceAddStaysInMediaDir(t *testing.T) {
+	dir, outside := t.TempDir(), t.TempDir()
+	f := NewFileSource(1)
+	f.SetMediaDir(dir)
+	inside := writeConstantWAV(t, dir, "inside.wav", 0, 20*time.Millisecond)
+	secret := writeConstantWAV(t, outside, "secret.wav", 0, 20*time.Millisecond)
+
+	if item, err := f.Add("inside.wav"); err != nil || filepath.Base(item.Path) != "inside.wav" {
+		t.Errorf("Add(relative) = %v, %v, want the file in the media directory", item, err)
+	}
+	if _, err := f.Add(inside); err != nil {
+		t.Errorf("Add(absolute) error = %v", err)
+	}
+	for _, path := range []string{
+		secret,
+		filepath.Join(dir, "..", filepath.Base(outside), "secret.wav"),
+	} {
+		if _, err := f.Add(path); err == nil {
+			t.Errorf("Add(%s) should reject a file outside the media directory", path)
+		}
+	}
+
+	link := filepath.Join(dir, "link.wav")
+	if err := os.Symlink(secret, link); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+	if _, err := f.Add(link); err == nil {
+		t.Error("Add() should reject a symlink that leads out of the media directory")
+	}
+}
+
+func TestFileSourcePlaysQueueInRealTime(t *testing.T) {
+	dir := t.TempDir()
+	f := NewFileSource(2)
+	f.SetMediaDir(dir)
+	f.Add(writeConstantWAV(t, dir, "first.wav", 1000, 40*time.Millisecond))
+	f.Add(writeConstantWAV(t, dir, "second.wav", 2000, 40*time.Millisecond))
+	started := make(chan string, 2)
+	// The listener may call back into the source
+	f.SetTrackListener(func(item QueueItem) { f.Queue(); started <- item.Name })
+
+	if err := f.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer f.Stop()
+
+	start := time.Now()
+	var values []int16
+	for len(values) < 4 {
+		select {
+		case chunk := <-f.Frames():
+			// 20ms of 48kHz stereo 16-bit PCM, upmixed from mono
+			if len(chunk) != 3840 {
+				t.Fatalf("chunk size = %d, want 3840", len(chunk))
+			}
+			values = append(values, int16(uint16(chunk[0])|uint16(chunk[1])<<8))
+		case <-time.After(time.Second):
+			t.Fatalf("only %d chunks played", len(values))
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 60*time.Millisecond {
+		t.Errorf("80ms of audio played in %v, want real-time pacing", elapsed)
+	}
+	want := []int16{1000, 1000, 2000, 2000}
+	for i := range want {
+		if values[i] != want[i] {
+			t.Errorf("chunk %d = %d, want %d", i, values[i], want[i])
+		}
+	}
+	if len(f.Queue()) != 0 {
+		t.Errorf("queue should be empty after playback, got %v", f.Queue())
+	}
+	if first, second := <-started, <-started; first != "first.wav" || second != "second.wav" {
+		t.Errorf("track listener saw %s and %s, want first.wav then second.wav", first, second)
+	}
+}
+
+func TestFileSourceSkip(t *testing.T) {
+	dir := t.TempDir()
+	f := NewFileSource(1)
+	f.SetMediaDir(dir)
+	f.Add(writeConstantWAV(t, dir, "long.wav", 1000, 10*time.Second))
+	f.Add(writeConstantWAV(t, dir, "next.wav", 2000, time.Second))
+
+	f.Start()
+	defer f.Stop()
+
+	<-f.Frames()
+	if playing := f.NowPlaying(); playing == nil || playing.Name != "long.wav" {
+		t.Fatalf("NowPlaying() = %v, want long.wav", playing)
+	}
+
+	f.Skip()
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case chunk := <-f.Frames():
+			if int16(uint16(chunk[0])|uint16(chunk[1])<<8) == 2000 {
+				if playing := f.NowPlaying(); playing == nil || playing.Name != "next.wav" {
+					t.Errorf("NowPlaying() = %v, want next.wav", playing)
+				}
+				return
+			}
+		case <-deadline:
+			t.Fatal("Skip() did not move on to the next file")
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package source
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+const (
+	// processRestartMin is the first restart delay; it doubles after each
+	// quick exit up to processRestartMax.
+	processRestartMin = time.Second
+	processRestartMax = 30 * time.Second
+	// processStableRun resets the backoff once the process has stayed up
+	// this long.
+	processStableRun = 30 * time.Second
+)
+
+// ProcessSource runs a command and streams the raw PCM it writes to stdout,
+// for example `ffmpeg -i <input> -f s16le -ar 48000 -ac 2 -`, a
+// librespot pipe backend, or `cat` on an MPD FIFO. The process is restarted
+// with backoff whenever it exits, and its stderr goes to the log.
+type ProcessSource struct {
+	mu      sync.Mutex
+	command []string
+	format  audio.Format
+	frames  chan []byte
+	stop    chan struct{}
+	done    chan struct{}
+}
+
+func NewProcessSource(command string, format audio.Format) (*ProcessSource, error) {
+	args, err := splitCommand(command)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) == 0 {
+		return nil, errors.New("no command configured")
+	}
+	if err := format.Validate(); err != nil {
+		return nil, err
+	}
+	return &ProcessSource{
+		command: args,
+		format:  format,
+		frames:  make(chan []byte, constants.AudioBufferSize),
+	}, nil
+}
+
+func (p *ProcessSource) Name() string {
+	return constants.SourceProcess
+}
+
+func (p *ProcessSource) Format() audio.Format {
+	return p.format
+}
+
+func (p *ProcessSource) Frames() <-chan []byte {
+	return p.frames
+}
+
+func (p *ProcessSource) Start() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.stop != nil {
+		return nil
+	}
+	p.stop = make(chan struct{})
+	p.done = make(chan struct{})
+	go p.supervise(p.stop, p.done)
+	return nil
+}
+
+// Stop kills the process and waits for it to exit.
+func (p *ProcessSource) Stop() error {
+	p.mu.Lock()
+	stop, done := p.stop, p.done
+	p.stop, p.done = nil, nil
+	p.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+func (p *ProcessSource) supervise(stop, done chan struct{}) {
+	defer close(done)
+
+	backoff := processRestartMin
+	for {
+		started := time.Now()
+		err := p.run(stop)
+
+		select {
+		case <-stop:
+			return
+		default:
+		}
+
+		if time.Since(started) >= processStableRun {
+			backoff = processRestartMin
+		}
+		if err != nil {
+			log.Printf("Audio process %s exited: %v; restarting in %v", p.command[0], err, backoff)
+		} else {
+			log.Printf("Audio process %s exited; restarting in %v", p.command[0], backoff)
+		}
+
+		select {
+		case <-stop:
+			return
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+		if backoff > processRestartMax {
+			backoff = processRestartMax
+		}
+	}
+}
+
+// run starts the process once and reads its output until it exits or stop
+// is closed.
+func (p *ProcessSource) run(stop <-chan struct{}) error {
+	cmd := exec.Command(p.command[0], p.command[1:]...)
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	log.Printf("Started audio process %s (pid %d, %s)", p.command[0], cmd.Process.Pid, p.format)
+
+	stderrDone := make(chan struct{})
+	go func() {
+		defer close(stderrDone)
+		scanner := bufio.NewScanner(stderr)
+		for scanner.Scan() {
+			log.Printf("[%s] %s", p.command[0], scanner.Text())
+		}
+	}()
+
+	exited := make(chan struct{})
+	go func() {
+		select {
+		case <-stop:
+			cmd.Process.Kill()
+		case <-exited:
+		}
+	}()
+
+	p.read(stdout, stop)
+
+	// Without stdout there is nothing left to stream, even if the process
+	// is still running
+	cmd.Process.Kill()
+	<-stderrDone
+	err = cmd.Wait()
+	close(exited)
+	return err
+}
+
+// read forwards one frame interval of PCM per tick. Pacing the reads keeps
+// processes that decode faster than real time, like ffmpeg without -re,
+// blocked on the pipe instead of racing ahead.
+func (p *ProcessSource) read(stdout io.Reader, stop <-chan struct{}) {
+	frameBytes := p.format.FrameBytes()
+	chunkBytes := p.format.SampleRate * int(constants.AudioFrameInterval/time.Millisecond) / 1000 * frameBytes
+
+	ticker := time.NewTicker(constants.AudioFrameInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		chunk := make([]byte, chunkBytes)
+		n, err := io.ReadFull(stdout, chunk)
+		if n -= n % frameBytes; n > 0 {
+			select {
+			case p.frames <- chunk[:n]:
+			case <-stop:
+				return
+			}
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// splitCommand splits a command line on whitespace, honouring single and
+// double quotes so paths with spaces can be passed.
+func splitCommand(command string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+
+	for _, r := range command {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '\'' || r == '"':
+			quote = r
+			inArg = true
+		case r == ' ' || r == '\t' || r == '\n':
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+	if quote != 0 {
+		return nil, fmt.Errorf("unterminated quote in command: %s", command)
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
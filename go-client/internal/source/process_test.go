@@ -0,0 +1,129 @@
+package source
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"trunecord/internal/audio"
+)
+
+// TestHelperProcess is not a real test; ProcessSource runs the test binary
+// with it as a stand-in for ffmpeg.
+func TestHelperProcess(t *testing.T) {
+	mode := os.Getenv("TRUNECORD_HELPER_PROCESS")
+	if mode == "" {
+		return
+	}
+
+	fmt.Fprintln(os.Stderr, "helper started")
+	switch mode {
+	case "pcm":
+		// 40ms of 48kHz mono s16 with every sample set to 7
+		pcm := make([]byte, 1920*2)
+		for i := 0; i < len(pcm); i += 2 {
+			binary.LittleEndian.PutUint16(pcm[i:], 7)
+		}
+		os.Stdout.Write(pcm)
+	case "hang":
+		time.Sleep(time.Minute)
+	}
+	os.Exit(0)
+}
+
+func newHelperSource(t *testing.T, mode string) *ProcessSource {
+	t.Helper()
+	t.Setenv("TRUNECORD_HELPER_PROCESS", mode)
+	p, err := NewProcessSource(os.Args[0]+" -test.run=^TestHelperProcess$", audio.PipelineFormat(1))
+	if err != nil {
+		t.Fatalf("NewProcessSource() error = %v", err)
+	}
+	return p
+}
+
+func TestProcessSourceStreamsStdoutAndRestarts(t *testing.T) {
+	p := newHelperSource(t, "pcm")
+	if err := p.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	defer p.Stop()
+
+	// Two 20ms chunks per run; the third arrives after the restart backoff
+	for i := 0; i < 3; i++ {
+		select {
+		case chunk := <-p.Frames():
+			if len(chunk) != 1920 || binary.LittleEndian.Uint16(chunk) != 7 {
+				t.Fatalf("chunk %d = %d bytes starting with %d, want 1920 bytes of 7", i, len(chunk), binary.LittleEndian.Uint16(chunk))
+			}
+		case <-time.After(3 * time.Second):
+			t.Fatalf("chunk %d never arrived", i)
+		}
+	}
+}
+
+func TestProcessSourceStopKillsProcess(t *testing.T) {
+	p := newHelperSource(t, "hang")
+	p.Start()
+	time.Sleep(100 * time.Millisecond)
+
+	stopped := make(chan struct{})
+	go func() {
+		p.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Stop() did not kill the process")
+	}
+}
+
+func TestNewProcessSourceValidates(t *testing.T) {
+	if _, err := NewProcessSource("   ", audio.PipelineFormat(2)); err == nil {
+		t.Error("NewProcessSource() should reject an empty command")
+	}
+	if _, err := NewProcessSource("ffmpeg", audio.Format{SampleRate: 48000, Channels: 6}); err == nil {
+		t.Error("NewProcessSource() should reject an unsupported format")
+	}
+}
+
+func TestSplitCommand(t *testing.T) {
+	tests := []struct {
+		command string
+		want    []string
+		wantErr bool
+	}{
+		{
+			command: "ffmpeg -i input.mp3 -f s16le -",
+			want:    []string{"ffmpeg", "-i", "input.mp3", "-f", "s16le", "-"},
+		},
+		{
+			command: `ffmpeg -i "/music/My Song.flac"  -f s16le '-'`,
+			want:    []string{"ffmpeg", "-i", "/music/My Song.flac", "-f", "s16le", "-"},
+		},
+		{
+			command: `librespot --name "" --backend pipe`,
+			want:    []string{"librespot", "--name", "", "--backend", "pipe"},
+		},
+		{
+			command: `cat "/tmp/mpd.fifo`,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.Fields(tt.command)[0], func(t *testing.T) {
+			got, err := splitCommand(tt.command)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitCommand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("splitCommand() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
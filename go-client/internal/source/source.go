@@ -0,0 +1,210 @@
+package source
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"sync"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+// AudioSource is an input that produces PCM for the streamer. Frames must
+// return the same channel for the lifetime of the source.
+type AudioSource interface {
+	Name() string
+	Start() error
+	Stop() error
+	Format() audio.Format
+	Frames() <-chan []byte
+}
+
+// PacketSource is a source that can also deliver pre-encoded Opus packets,
+// which go to the streamer as they are while the source is active.
+type PacketSource interface {
+	AudioSource
+	Packets() <-chan []byte
+}
+
+// Manager forwards the PCM of the one active source to the streamer,
+// converting it to the pipeline format when needed.
+type Manager struct {
+	mu       sync.Mutex
+	sources  map[string]AudioSource
+	active   AudioSource
+	stop     chan struct{}
+	stopped  chan struct{} // closed when the active source's forwarder returns
+	output   chan []byte
+	packets  chan []byte
+	channels int
+}
+
+func NewManager(channels int) *Manager {
+	return &Manager{
+		sources:  make(map[string]AudioSource),
+		output:   make(chan []byte, constants.AudioBufferSize),
+		packets:  make(chan []byte, constants.AudioBufferSize),
+		channels: channels,
+	}
+}
+
+func (m *Manager) Register(src AudioSource) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sources[src.Name()] = src
+}
+
+// Sources lists the registered source names in alphabetical order.
+func (m *Manager) Sources() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.sources))
+	for name := range m.sources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Active returns the name of the active source, or "" when none is.
+func (m *Manager) Active() string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.active == nil {
+		return ""
+	}
+	return m.active.Name()
+}
+
+// Select stops the active source and starts the named one.
+func (m *Manager) Select(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	src, ok := m.sources[name]
+	if !ok {
+		return fmt.Errorf("unknown audio source: %s", name)
+	}
+	if src == m.active {
+		return nil
+	}
+
+	var converter *audio.Converter
+	output := audio.PipelineFormat(m.channels)
+	if src.Format() != output {
+		var err error
+		converter, err = audio.NewConverter(src.Format(), output)
+		if err != nil {
+			return fmt.Errorf("cannot convert audio from %s: %v", name, err)
+		}
+	}
+
+	m.stopActive()
+	if err := src.Start(); err != nil {
+		return fmt.Errorf("failed to start audio source %s: %v", name, err)
+	}
+
+	m.active = src
+	m.stop = make(chan struct{})
+	m.stopped = make(chan struct{})
+	go m.forward(src, converter, m.stop, m.stopped)
+
+	log.Printf("Audio source: %s (%s)", name, src.Format())
+	return nil
+}
+
+// Stop stops the active source, leaving none selected.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.stopActive()
+}
+
+// stopActive stops the active source and throws away whatever it left
+// queued, so none of its audio plays after the next source starts.
+func (m *Manager) stopActive() {
+	if m.active == nil {
+		return
+	}
+	close(m.stop)
+	<-m.stopped
+	if err := m.active.Stop(); err != nil {
+		log.Printf("Failed to stop audio source %s: %v", m.active.Name(), err)
+	}
+	m.active = nil
+	drain(m.output)
+	drain(m.packets)
+}
+
+// Frames delivers the active source's audio in the pipeline format.
+func (m *Manager) Frames() <-chan []byte {
+	return m.output
+}
+
+// Packets delivers the active source's pre-encoded Opus packets.
+func (m *Manager) Packets() <-chan []byte {
+	return m.packets
+}
+
+func (m *Manager) forward(src AudioSource, converter *audio.Converter, stop <-chan struct{}, stopped chan<- struct{}) {
+	defer close(stopped)
+	frames := src.Frames()
+	var packets <-chan []byte
+	if packetSource, ok := src.(PacketSource); ok {
+		packets = packetSource.Packets()
+	}
+	for {
+		select {
+		case <-stop:
+			return
+		case packet := <-packets:
+			pushLatest(m.packets, packet)
+		case frame, ok := <-frames:
+			if !ok {
+				return
+			}
+			if converter != nil {
+				converted, err := converter.Convert(frame)
+				if err != nil {
+					log.Printf("Failed to convert audio from %s: %v", src.Name(), err)
+					continue
+				}
+				frame = converted
+			}
+			if len(frame) == 0 {
+				continue
+			}
+
+			pushLatest(m.output, frame)
+		}
+	}
+}
+
+func drain(buffer chan []byte) {
+	for {
+		select {
+		case <-buffer:
+		default:
+			return
+		}
+	}
+}
+
+// pushLatest queues data, dropping the oldest entry when the streamer has
+// fallen behind so latency stays bounded.
+func pushLatest(buffer chan []byte, data []byte) {
+	select {
+	case buffer <- data:
+	default:
+		select {
+		case <-buffer:
+		default:
+		}
+		select {
+		case buffer <- data:
+		default:
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package source
+
+import (
+	"testing"
+	"time"
+
+	"trunecord/internal/audio"
+)
+
+type fakeSource struct {
+	name    string
+	format  audio.Format
+	frames  chan []byte
+	started bool
+	stopped bool
+}
+
+func newFakeSource(name string, format audio.Format) *fakeSource {
+	return &fakeSource{name: name, format: format, frames: make(chan []byte, 10)}
+}
+
+func (f *fakeSource) Name() string          { return f.name }
+func (f *fakeSource) Start() error          { f.started = true; f.stopped = false; return nil }
+func (f *fakeSource) Stop() error           { f.stopped = true; f.started = false; return nil }
+func (f *fakeSource) Format() audio.Format  { return f.format }
+func (f *fakeSource) Frames() <-chan []byte { return f.frames }
+
+func receive(t *testing.T, m *Manager) []byte {
+	t.Helper()
+	select {
+	case frame := <-m.Frames():
+		return frame
+	case <-time.After(time.Second):
+		t.Fatal("no frame forwarded")
+		return nil
+	}
+}
+
+func TestManagerSelectSwitchesSources(t *testing.T) {
+	m := NewManager(1)
+	first := newFakeSource("first", audio.PipelineFormat(1))
+	second := newFakeSource("second", audio.PipelineFormat(1))
+	m.Register(first)
+	m.Register(second)
+
+	if names := m.Sources(); len(names) != 2 || names[0] != "first" || names[1] != "second" {
+		t.Fatalf("Sources() = %v, want [first second]", names)
+	}
+
+	if err := m.Select("first"); err != nil {
+		t.Fatalf("Select(first) error = %v", err)
+	}
+	if !first.started || m.Active() != "first" {
+		t.Fatalf("first source should be active and started")
+	}
+
+	first.frames <- []byte{1, 0}
+	if frame := receive(t, m); frame[0] != 1 {
+		t.Errorf("forwarded frame = %v, want first source audio", frame)
+	}
+
+	if err := m.Select("second"); err != nil {
+		t.Fatalf("Select(second) error = %v", err)
+	}
+	if !first.stopped || !second.started || m.Active() != "second" {
+		t.Fatal("switching should stop the first source and start the second")
+	}
+
+	second.frames <- []byte{2, 0}
+	if frame := receive(t, m); frame[0] != 2 {
+		t.Errorf("forwarded frame = %v, want second source audio", frame)
+	}
+
+	if err := m.Select("missing"); err == nil {
+		t.Error("Select() should reject an unknown source")
+	}
+	if m.Active() != "second" {
+		t.Error("a failed Select() should keep the active source")
+	}
+}
+
+func TestManagerSelectDropsPendingFrames(t *testing.T) {
+	m := NewManager(1)
+	first := &fakePacketSource{newFakeSource("first", audio.PipelineFormat(1)), make(chan []byte, 10)}
+	second := newFakeSource("second", audio.PipelineFormat(1))
+	m.Register(first)
+	m.Register(second)
+
+	m.Select("first")
+	for i := 0; i < 3; i++ {
+		first.frames <- []byte{1, 0}
+	}
+	first.packets <- []byte{1}
+	deadline := time.Now().Add(time.Second)
+	for len(m.Frames()) < 3 || len(m.Packets()) < 1 {
+		if time.Now().After(deadline) {
+			t.Fatal("first source's audio was not forwarded")
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	if err := m.Select("second"); err != nil {
+		t.Fatalf("Select(second) error = %v", err)
+	}
+	second.frames <- []byte{2, 0}
+	if frame := receive(t, m); frame[0] != 2 {
+		t.Errorf("first frame after switching = %v, want second source audio", frame)
+	}
+	if len(m.Packets()) != 0 {
+		t.Error("switching should drop the first source's pending packets")
+	}
+}
+
+func TestManagerConvertsSourceFormat(t *testing.T) {
+	m := NewManager(2)
+	src := newFakeSource("mono", audio.PipelineFormat(1))
+	m.Register(src)
+
+	if err := m.Select("mono"); err != nil {
+		t.Fatalf("Select() error = %v", err)
+	}
+
+	src.frames <- audio.Int16ToBytes([]int16{100, -100})
+	got := audio.BytesToInt16(receive(t, m))
+	want := []int16{100, 100, -100, -100}
+	if len(got) != len(want) {
+		t.Fatalf("forwarded %d samples, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("forwarded samples = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestManagerStop(t *testing.T) {
+	m := NewManager(1)
+	src := newFakeSource("only", audio.PipelineFormat(1))
+	m.Register(src)
+	m.Select("only")
+
+	m.Stop()
+	if !src.stopped || m.Active() != "" {
+		t.Error("Stop() should stop the active source")
+	}
+}
+
+type fakePacketSource struct {
+	*fakeSource
+	packets chan []byte
+}
+
+func (f *fakePacketSource) Packets() <-chan []byte { return f.packets }
+
+func TestManagerForwardsPacketsOfActiveSource(t *testing.T) {
+	m := NewManager(1)
+	packets := &fakePacketSource{newFakeSource("packets", audio.PipelineFormat(1)), make(chan []byte, 10)}
+	pcm := newFakeSource("pcm", audio.PipelineFormat(1))
+	m.Register(packets)
+	m.Register(pcm)
+
+	m.Select("packets")
+	packets.packets <- []byte{1}
+	select {
+	case packet := <-m.Packets():
+		if packet[0] != 1 {
+			t.Errorf("forwarded packet = %v, want the source's", packet)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no packet forwarded")
+	}
+
+	// Packets of a source that is not selected stay where they are
+	m.Select("pcm")
+	packets.packets <- []byte{2}
+	select {
+	case packet := <-m.Packets():
+		t.Errorf("forwarded packet %v from an inactive source", packet)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
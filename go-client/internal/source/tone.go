@@ -0,0 +1,228 @@
+package source
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+// TonePattern selects the test signal the tone source generates.
+type TonePattern string
+
+const (
+	// ToneSweep glides logarithmically from 20Hz to 20kHz every 10 seconds.
+	ToneSweep TonePattern = "sweep"
+	// ToneLeftRight plays 1kHz in the left channel, then the right, then
+	// pauses, one second each.
+	ToneLeftRight TonePattern = "leftright"
+	// ToneBeep plays a 100ms 1kHz beep at the start of every second.
+	ToneBeep TonePattern = "beep"
+)
+
+const (
+	// DefaultToneLevel is loud enough to judge levels without clipping
+	// anything downstream.
+	DefaultToneLevel = -12.0
+	MinToneLevel     = -60.0
+	MaxToneLevel     = 0.0
+
+	toneFrequency     = 1000.0
+	toneSweepStart    = 20.0
+	toneSweepEnd      = 20000.0
+	toneSweepPeriod   = 10 * time.Second
+	toneBeepLength    = 100 * time.Millisecond
+	toneSegmentLength = time.Second
+	// toneRamp fades segments in and out so they do not click.
+	toneRamp = 5 * time.Millisecond
+	// toneCycle is a multiple of every pattern's period, so the position
+	// can wrap around without a discontinuity.
+	toneCycle = 30 * constants.SampleRate
+)
+
+func ParseTonePattern(name string) (TonePattern, error) {
+	switch pattern := TonePattern(strings.ToLower(strings.TrimSpace(name))); pattern {
+	case ToneSweep, ToneLeftRight, ToneBeep:
+		return pattern, nil
+	}
+	return "", fmt.Errorf("unknown tone pattern: %s (must be sweep, leftright or beep)", name)
+}
+
+// ToneSource generates test signals in real time for checking a voice
+// connection's levels, channel mapping and latency.
+type ToneSource struct {
+	mu       sync.Mutex
+	channels int
+	pattern  TonePattern
+	level    float64
+	position int
+	phase    float64
+	frames   chan []byte
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+func NewToneSource(channels int) *ToneSource {
+	return &ToneSource{
+		channels: channels,
+		pattern:  ToneSweep,
+		level:    DefaultToneLevel,
+		frames:   make(chan []byte, constants.AudioBufferSize),
+	}
+}
+
+func (t *ToneSource) Name() string {
+	return constants.SourceTone
+}
+
+func (t *ToneSource) Format() audio.Format {
+	return audio.PipelineFormat(t.channels)
+}
+
+func (t *ToneSource) Frames() <-chan []byte {
+	return t.frames
+}
+
+// SetTone switches the pattern and its peak level in dBFS, restarting the
+// pattern from the beginning.
+func (t *ToneSource) SetTone(pattern TonePattern, level float64) error {
+	if _, err := ParseTonePattern(string(pattern)); err != nil {
+		return err
+	}
+	if level < MinToneLevel || level > MaxToneLevel || math.IsNaN(level) {
+		return fmt.Errorf("tone level must be between %.0f and %.0f dBFS: %g", MinToneLevel, MaxToneLevel, level)
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.pattern = pattern
+	t.level = level
+	t.position = 0
+	t.phase = 0
+	return nil
+}
+
+// Tone returns the current pattern and level.
+func (t *ToneSource) Tone() (TonePattern, float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.pattern, t.level
+}
+
+func (t *ToneSource) Start() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.stop != nil {
+		return nil
+	}
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	go t.play(t.stop, t.done)
+	return nil
+}
+
+func (t *ToneSource) Stop() error {
+	t.mu.Lock()
+	stop, done := t.stop, t.done
+	t.stop, t.done = nil, nil
+	t.mu.Unlock()
+
+	if stop != nil {
+		close(stop)
+		<-done
+	}
+	return nil
+}
+
+func (t *ToneSource) play(stop, done chan struct{}) {
+	defer close(done)
+
+	ticker := time.NewTicker(constants.AudioFrameInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			select {
+			case t.frames <- t.generate(constants.PCMFrameSize):
+			case <-stop:
+				return
+			}
+		}
+	}
+}
+
+// generate renders the next frames of the pattern as 16-bit PCM.
+func (t *ToneSource) generate(frames int) []byte {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	amplitude := math.Pow(10, t.level/20)
+	pcm := make([]byte, frames*t.channels*2)
+	for i := 0; i < frames; i++ {
+		elapsed := time.Duration(t.position) * time.Second / constants.SampleRate
+		t.position = (t.position + 1) % toneCycle
+
+		frequency := toneFrequency
+		left, right := 1.0, 1.0
+		switch t.pattern {
+		case ToneSweep:
+			progress := float64(elapsed%toneSweepPeriod) / float64(toneSweepPeriod)
+			frequency = toneSweepStart * math.Pow(toneSweepEnd/toneSweepStart, progress)
+		case ToneLeftRight:
+			offset := elapsed % toneSegmentLength
+			gain := envelope(offset, toneSegmentLength)
+			switch elapsed % (3 * toneSegmentLength) / toneSegmentLength {
+			case 0:
+				left, right = gain, 0
+			case 1:
+				left, right = 0, gain
+			default:
+				left, right = 0, 0
+			}
+		case ToneBeep:
+			offset := elapsed % time.Second
+			gain := 0.0
+			if offset < toneBeepLength {
+				gain = envelope(offset, toneBeepLength)
+			}
+			left, right = gain, gain
+		}
+
+		t.phase += 2 * math.Pi * frequency / constants.SampleRate
+		if t.phase > 2*math.Pi {
+			t.phase -= 2 * math.Pi
+		}
+		sample := amplitude * math.Sin(t.phase)
+
+		if t.channels == 1 {
+			binary.LittleEndian.PutUint16(pcm[i*2:], uint16(toInt16(sample*math.Max(left, right))))
+			continue
+		}
+		binary.LittleEndian.PutUint16(pcm[i*4:], uint16(toInt16(sample*left)))
+		binary.LittleEndian.PutUint16(pcm[i*4+2:], uint16(toInt16(sample*right)))
+	}
+	return pcm
+}
+
+// envelope ramps a segment of the given length in and out.
+func envelope(offset, length time.Duration) float64 {
+	if offset < toneRamp {
+		return float64(offset) / float64(toneRamp)
+	}
+	if remaining := length - offset; remaining < toneRamp {
+		return float64(remaining) / float64(toneRamp)
+	}
+	return 1
+}
+
+func toInt16(v float64) int16 {
+	return audio.FloatToInt16(float32(v))
+}
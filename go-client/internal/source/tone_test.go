@@ -0,0 +1,126 @@
+package source
+
+import (
+	"encoding/binary"
+	"math"
+	"testing"
+	"time"
+
+	"trunecord/internal/constants"
+)
+
+// peaks returns the peak absolute sample of each channel.
+func peaks(pcm []byte, channels int) []float64 {
+	out := make([]float64, channels)
+	for i := 0; i+1 < len(pcm); i += 2 {
+		ch := (i / 2) % channels
+		v := math.Abs(float64(int16(binary.LittleEndian.Uint16(pcm[i:]))) / 32768)
+		out[ch] = math.Max(out[ch], v)
+	}
+	return out
+}
+
+func zeroCrossings(pcm []byte, channels int) int {
+	crossings := 0
+	previous := int16(0)
+	for i := 0; i+1 < len(pcm); i += 2 * channels {
+		v := int16(binary.LittleEndian.Uint16(pcm[i:]))
+		if (previous < 0) != (v < 0) {
+			crossings++
+		}
+		previous = v
+	}
+	return crossings
+}
+
+// framesAt renders one frame interval starting at offset into the pattern.
+func framesAt(t *ToneSource, offset time.Duration) []byte {
+	t.generate(int(offset.Seconds() * constants.SampleRate))
+	return t.generate(constants.PCMFrameSize)
+}
+
+func TestToneSourceLevel(t *testing.T) {
+	tone := NewToneSource(1)
+	if err := tone.SetTone(ToneBeep, -6); err != nil {
+		t.Fatalf("SetTone() error = %v", err)
+	}
+
+	peak := peaks(framesAt(tone, 40*time.Millisecond), 1)[0]
+	if want := math.Pow(10, -6.0/20); math.Abs(peak-want) > 0.01 {
+		t.Errorf("peak = %f, want %f for -6 dBFS", peak, want)
+	}
+}
+
+func TestToneSourceLeftRight(t *testing.T) {
+	tone := NewToneSource(2)
+	tone.SetTone(ToneLeftRight, DefaultToneLevel)
+
+	left := peaks(framesAt(tone, 500*time.Millisecond), 2)
+	if left[0] < 0.2 || left[1] != 0 {
+		t.Errorf("first second peaks = %v, want only the left channel", left)
+	}
+
+	tone.SetTone(ToneLeftRight, DefaultToneLevel)
+	right := peaks(framesAt(tone, 1500*time.Millisecond), 2)
+	if right[0] != 0 || right[1] < 0.2 {
+		t.Errorf("second second peaks = %v, want only the right channel", right)
+	}
+
+	tone.SetTone(ToneLeftRight, DefaultToneLevel)
+	silent := peaks(framesAt(tone, 2500*time.Millisecond), 2)
+	if silent[0] != 0 || silent[1] != 0 {
+		t.Errorf("third second peaks = %v, want silence", silent)
+	}
+}
+
+func TestToneSourceBeep(t *testing.T) {
+	tone := NewToneSource(1)
+	tone.SetTone(ToneBeep, DefaultToneLevel)
+
+	if peak := peaks(tone.generate(constants.PCMFrameSize), 1)[0]; peak < 0.2 {
+		t.Errorf("beep peak = %f, want the beep at the start of the second", peak)
+	}
+	if peak := peaks(framesAt(tone, 400*time.Millisecond), 1)[0]; peak != 0 {
+		t.Errorf("peak between beeps = %f, want silence", peak)
+	}
+}
+
+func TestToneSourceSweepRises(t *testing.T) {
+	tone := NewToneSource(1)
+	tone.SetTone(ToneSweep, DefaultToneLevel)
+
+	low := zeroCrossings(framesAt(tone, time.Second), 1)
+	tone.SetTone(ToneSweep, DefaultToneLevel)
+	high := zeroCrossings(framesAt(tone, 8*time.Second), 1)
+	if high <= low*10 {
+		t.Errorf("zero crossings at 1s = %d, at 8s = %d, want the sweep to rise", low, high)
+	}
+}
+
+func TestToneSourceSetToneValidates(t *testing.T) {
+	tone := NewToneSource(2)
+	if err := tone.SetTone("noise", DefaultToneLevel); err == nil {
+		t.Error("SetTone() should reject an unknown pattern")
+	}
+	if err := tone.SetTone(ToneSweep, 3); err == nil {
+		t.Error("SetTone() should reject a level above 0 dBFS")
+	}
+	if pattern, level := tone.Tone(); pattern != ToneSweep || level != DefaultToneLevel {
+		t.Errorf("Tone() = %s %f, want the defaults after failed SetTone()", pattern, level)
+	}
+}
+
+func TestToneSourcePlaysInRealTime(t *testing.T) {
+	tone := NewToneSource(2)
+	tone.Start()
+	defer tone.Stop()
+
+	select {
+	case chunk := <-tone.Frames():
+		if len(chunk) != constants.PCMFrameBytes(2) {
+			t.Errorf("chunk size = %d, want %d", len(chunk), constants.PCMFrameBytes(2))
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no tone generated")
+	}
+}
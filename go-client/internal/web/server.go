@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"html/template"
+	"io"
 	"log"
 	"net"
 	"net/http"
@@ -11,11 +12,18 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
+	"trunecord/internal/audio"
 	"trunecord/internal/auth"
+	"trunecord/internal/broadcast"
 	"trunecord/internal/browser"
 	"trunecord/internal/config"
 	"trunecord/internal/constants"
+	"trunecord/internal/discord"
+	"trunecord/internal/opus"
+	"trunecord/internal/source"
+	"trunecord/internal/websocket"
 )
 
 type Server struct {
@@ -25,6 +33,13 @@ type Server struct {
 	tokenData        *auth.TokenData
 	streamer         DiscordStreamer
 	wsServer         WebSocketServer
+	sources          SourceManager
+	fileQueue        FileQueue
+	tone             ToneGenerator
+	streams          StreamOutput
+	toneMu           sync.Mutex
+	toneReturn       string
+	settings         *config.Settings
 	browserOpener    *browser.Opener
 	config           *config.Config
 	versionStatus    *VersionStatus
@@ -35,15 +50,68 @@ type Server struct {
 type DiscordStreamer interface {
 	Connect(botToken, guildID, channelID string) error
 	Disconnect() error
+	DisconnectTarget(guildID string) error
+	GetTargets() []discord.TargetStats
 	IsConnected() bool
 	IsStreaming() bool
 	GetGuildID() string
 	GetChannelID() string
+	GetBufferStats() audio.JitterStats
+	GetDriftStats() audio.DriftStats
+	SetEncoderOptions(options opus.Options) error
+	GetEncoderOptions() opus.Options
+	GetBitrate() int
+	GetMaxBitrate() int
+	GetPassthroughStats() opus.PassthroughStats
+	SetVolume(percent int) error
+	GetVolume() int
+	GetLimitedSamples() uint64
+	SetLoudness(enabled bool, target float64) error
+	GetLoudnessStats() audio.LoudnessStats
+	IsSpeaking() bool
+	SetFilters(specs []audio.FilterSpec) error
+	GetFilters() []audio.FilterSpec
+	StartRecording() (string, error)
+	StopRecording() error
+	GetRecordingStats() opus.RecordingStats
 }
 
 type WebSocketServer interface {
 	IsStreaming() bool
 	IsConnected() bool
+	GetIngestStats() audio.IngestStats
+	GetMixerInputs() []audio.MixerInput
+	SetInputGain(id string, gain float64) error
+	SetInputMuted(id string, muted bool) error
+	GetMixerClipped() uint64
+	GetClients() []websocket.ClientInfo
+	TakeOver(id string) error
+}
+
+type SourceManager interface {
+	Sources() []string
+	Active() string
+	Select(name string) error
+}
+
+type FileQueue interface {
+	Add(path string) (source.QueueItem, error)
+	Remove(id string) error
+	Move(id string, index int) error
+	Skip()
+	Queue() []source.QueueItem
+	NowPlaying() *source.QueueItem
+}
+
+type ToneGenerator interface {
+	SetTone(pattern source.TonePattern, level float64) error
+	Tone() (source.TonePattern, float64)
+}
+
+type StreamOutput interface {
+	ServeOgg(w http.ResponseWriter, r *http.Request)
+	ServeWAV(w http.ResponseWriter, r *http.Request)
+	Listeners() []broadcast.ListenerStats
 }
 
 type PageData struct {
@@ -87,6 +155,34 @@ func NewServer(port string, authClient *auth.Client, streamer DiscordStreamer, w
 	}
 }
 
+// SetSourceManager enables listing and switching audio sources from the web UI.
+func (s *Server) SetSourceManager(sources SourceManager) {
+	s.sources = sources
+}
+
+// SetFileQueue enables managing the file source's play queue from the web UI.
+func (s *Server) SetFileQueue(queue FileQueue) {
+	s.fileQueue = queue
+}
+
+// SetStreamOutput enables /stream.ogg and /stream.wav for listening outside
+// Discord.
+func (s *Server) SetStreamOutput(streams StreamOutput) {
+	s.streams = streams
+}
+
+// SetSettings enables restoring and saving per-guild settings such as the
+// volume.
+func (s *Server) SetSettings(settings *config.Settings) {
+	s.settings = settings
+}
+
+// SetToneGenerator enables the diagnostics tone. It plays through the source
+// manager, so SetSourceManager must be called as well.
+func (s *Server) SetToneGenerator(tone ToneGenerator) {
+	s.tone = tone
+}
+
 func (s *Server) refreshVersionStatus() error {
 	if s.authClient == nil {
 		return fmt.Errorf("auth client not configured")
@@ -158,7 +254,18 @@ func (s *Server) Start() error {
 	mux.HandleFunc("/api/connect", s.handleConnect)
 	mux.HandleFunc("/api/disconnect", s.handleDisconnect)
 	mux.HandleFunc("/api/status", s.handleStatus)
+	mux.HandleFunc("/api/sources", s.handleSources)
+	mux.HandleFunc("/api/queue", s.handleQueue)
+	mux.HandleFunc("/api/diagnostics/tone", s.handleTone)
+	mux.HandleFunc("/api/mixer", s.handleMixer)
+	mux.HandleFunc("/api/clients", s.handleClients)
+	mux.HandleFunc("/api/volume", s.handleVolume)
+	mux.HandleFunc("/api/loudness", s.handleLoudness)
+	mux.HandleFunc("/api/filters", s.handleFilters)
+	mux.HandleFunc("/api/recording", s.handleRecording)
 	mux.HandleFunc("/api/channels/", s.handleChannels)
+	mux.HandleFunc("/stream.ogg", s.handleStream)
+	mux.HandleFunc("/stream.wav", s.handleStream)
 
 	// Static files
 	mux.Handle("/static/", http.StripPrefix("/static/", http.FileServer(http.Dir("web/static/"))))
@@ -290,8 +397,9 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 	}
 
 	var req struct {
-		GuildID   string `json:"guildId"`
-		ChannelID string `json:"channelId"`
+		GuildID   string          `json:"guildId"`
+		ChannelID string          `json:"channelId"`
+		Encoder   json.RawMessage `json:"encoder,omitempty"`
 	}
 
 	err := json.NewDecoder(r.Body).Decode(&req)
@@ -300,6 +408,21 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Encoder options are optional; fields left out keep their current values
+	var encoderOptions *opus.Options
+	if len(req.Encoder) > 0 {
+		options := s.streamer.GetEncoderOptions()
+		if err := json.Unmarshal(req.Encoder, &options); err != nil {
+			http.Error(w, "Invalid encoder options", http.StatusBadRequest)
+			return
+		}
+		if err := options.Validate(0); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid encoder options: %v", err), http.StatusBadRequest)
+			return
+		}
+		encoderOptions = &options
+	}
+
 	if s.tokenData == nil {
 		http.Error(w, "Not authenticated", http.StatusUnauthorized)
 		return
@@ -321,6 +444,9 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 		botToken = fetchedToken
 	}
 
+	// Channels joined alongside others share the stream's volume and encoder
+	first := !s.streamer.IsConnected()
+
 	// Connect to Discord voice channel
 	err = s.streamer.Connect(botToken, req.GuildID, req.ChannelID)
 	if err != nil {
@@ -334,12 +460,36 @@ func (s *Server) handleConnect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// The channel's bitrate limit is only known once connected. Options that
+	// cannot be applied leave the connection up with the current ones.
+	var warning string
+	if encoderOptions != nil && *encoderOptions != s.streamer.GetEncoderOptions() {
+		if !first {
+			warning = "Encoder options are kept while other channels are connected"
+		} else if err := s.streamer.SetEncoderOptions(*encoderOptions); err != nil {
+			warning = fmt.Sprintf("Encoder options not applied: %v", err)
+		}
+		if warning != "" {
+			log.Printf("%s (guild %s)", warning, req.GuildID)
+		}
+	}
+
+	if s.settings != nil && first {
+		volume := s.settings.Guild(req.GuildID).Volume
+		if err := s.streamer.SetVolume(volume); err != nil {
+			log.Printf("Ignoring saved volume for guild %s: %v", req.GuildID, err)
+		}
+	}
+
 	log.Printf("Successfully connected to Discord voice channel %s in guild %s", req.ChannelID, req.GuildID)
 
 	response := map[string]interface{}{
 		"success": true,
 		"message": "Connected to Discord voice channel",
 	}
+	if warning != "" {
+		response["warning"] = warning
+	}
 
 	w.Header().Set("Content-Type", constants.ContentTypeJSON)
 	json.NewEncoder(w).Encode(response)
@@ -355,8 +505,21 @@ func (s *Server) handleDisconnect(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// Disconnect from Discord
-	err := s.streamer.Disconnect()
+	// Without a guild ID every voice channel is left
+	var req struct {
+		GuildID string `json:"guildId"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		return
+	}
+
+	var err error
+	if req.GuildID != "" {
+		err = s.streamer.DisconnectTarget(req.GuildID)
+	} else {
+		err = s.streamer.Disconnect()
+	}
 	if err != nil {
 		log.Printf("Failed to disconnect from Discord: %v", err)
 		response := map[string]interface{}{
@@ -399,8 +562,10 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		"authenticated":    s.tokenData != nil,
 		"discordConnected": discordConnected, // Explicit Discord status
 		"wsConnected":      chromeConnected,  // Explicit WebSocket status
+		"speaking":         s.streamer.IsSpeaking(),
 	}
 	status["clientVersion"] = constants.ApplicationVersion
+	status["encoder"] = s.streamer.GetEncoderOptions()
 
 	if versionStatus, errMsg := s.versionStatusSnapshot(); versionStatus != nil {
 		status["updates"] = versionStatus
@@ -412,15 +577,646 @@ func (s *Server) handleStatus(w http.ResponseWriter, r *http.Request) {
 		status["guilds"] = s.tokenData.Guilds
 	}
 
+	ingest := s.wsServer.GetIngestStats()
+	status["ingest"] = map[string]interface{}{
+		"received":  ingest.Received,
+		"lost":      ingest.Lost,
+		"late":      ingest.Late,
+		"concealed": ingest.Concealed,
+	}
+
+	status["volume"] = map[string]interface{}{
+		"percent": s.streamer.GetVolume(),
+		"limited": s.streamer.GetLimitedSamples(),
+	}
+	status["loudness"] = loudnessStatus(s.streamer.GetLoudnessStats())
+	status["filters"] = s.streamer.GetFilters()
+	status["recording"] = recordingStatus(s.streamer.GetRecordingStats())
+
+	clients := s.wsServer.GetClients()
+	status["clients"] = clients
+	for _, client := range clients {
+		if client.Live {
+			status["liveClient"] = client.ID
+			break
+		}
+	}
+
+	status["mixer"] = map[string]interface{}{
+		"inputs":  s.wsServer.GetMixerInputs(),
+		"clipped": s.wsServer.GetMixerClipped(),
+	}
+
+	if s.sources != nil {
+		status["source"] = s.sources.Active()
+	}
+
+	targets := []map[string]interface{}{}
+	for _, target := range s.streamer.GetTargets() {
+		targets = append(targets, map[string]interface{}{
+			"id":         target.ID,
+			"guildId":    target.GuildID,
+			"channelId":  target.ChannelID,
+			"maxBitrate": target.MaxBitrate,
+			"connected":  target.Connected,
+			"ready":      target.Ready,
+			"speaking":   target.Speaking,
+			"sent":       target.Sent,
+			"dropped":    target.Dropped,
+			"health":     targetHealth(target),
+			"error":      target.Error,
+		})
+	}
+	status["targets"] = targets
+
+	if s.streams != nil {
+		listeners := []map[string]interface{}{}
+		for _, listener := range s.streams.Listeners() {
+			listeners = append(listeners, map[string]interface{}{
+				"id":        listener.ID,
+				"format":    listener.Format,
+				"address":   listener.Address,
+				"connected": listener.Connected,
+				"dropped":   listener.Dropped,
+			})
+		}
+		status["listeners"] = listeners
+	}
+
 	if discordConnected {
 		status["currentGuild"] = s.streamer.GetGuildID()
 		status["currentChannel"] = s.streamer.GetChannelID()
+
+		buffer := s.streamer.GetBufferStats()
+		status["buffer"] = map[string]interface{}{
+			"depthMs":       buffer.Depth.Milliseconds(),
+			"targetMs":      buffer.Target.Milliseconds(),
+			"underruns":     buffer.Underruns,
+			"trimmedFrames": buffer.Trimmed,
+		}
+
+		status["bitrate"] = map[string]interface{}{
+			"current":      s.streamer.GetBitrate(),
+			"channelLimit": s.streamer.GetMaxBitrate(),
+			"auto":         s.streamer.GetEncoderOptions().Bitrate == opus.BitrateAuto,
+		}
+
+		passthrough := s.streamer.GetPassthroughStats()
+		status["passthrough"] = map[string]interface{}{
+			"forwarded": passthrough.Forwarded,
+			"rejected":  passthrough.Rejected,
+			"dropped":   passthrough.Dropped,
+		}
+
+		drift := s.streamer.GetDriftStats()
+		status["drift"] = map[string]interface{}{
+			"estimatePpm":   drift.DriftPPM,
+			"correctionPpm": drift.CorrectionPPM,
+		}
 	}
 
 	w.Header().Set("Content-Type", constants.ContentTypeJSON)
 	json.NewEncoder(w).Encode(status)
 }
 
+func (s *Server) handleSources(w http.ResponseWriter, r *http.Request) {
+	if s.sources == nil {
+		http.Error(w, "Audio sources not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Name string `json:"name"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.sources.Select(req.Name); err != nil {
+			log.Printf("Failed to select audio source %q: %v", req.Name, err)
+			response := map[string]interface{}{
+				"success": false,
+				"message": fmt.Sprintf("Failed to select source: %v", err),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		log.Printf("Selected audio source %q", req.Name)
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"sources": s.sources.Sources(),
+		"active":  s.sources.Active(),
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+// targetHealth sums up a voice target: "connecting" until it is ready,
+// "failed" when its speaking state cannot be set, "stalled" when it is
+// meant to be speaking but packets stopped getting through, and "ok"
+// otherwise.
+func targetHealth(target discord.TargetStats) string {
+	switch {
+	case !target.Ready:
+		return "connecting"
+	case target.Error != "":
+		return "failed"
+	case target.Speaking && time.Since(target.LastSent) > constants.TargetStallTimeout:
+		return "stalled"
+	default:
+		return "ok"
+	}
+}
+
+// handleStream serves the audio sent to Discord to HTTP listeners.
+func (s *Server) handleStream(w http.ResponseWriter, r *http.Request) {
+	if s.streams == nil {
+		http.Error(w, "Streaming not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	if r.URL.Path == "/stream.wav" {
+		s.streams.ServeWAV(w, r)
+		return
+	}
+	s.streams.ServeOgg(w, r)
+}
+
+func (s *Server) handleQueue(w http.ResponseWriter, r *http.Request) {
+	if s.fileQueue == nil {
+		http.Error(w, "File playback not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Action string `json:"action"` // add, remove, move or skip
+			Path   string `json:"path"`
+			ID     string `json:"id"`
+			Index  int    `json:"index"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		switch req.Action {
+		case "add":
+			_, err = s.fileQueue.Add(req.Path)
+		case "remove":
+			err = s.fileQueue.Remove(req.ID)
+		case "move":
+			err = s.fileQueue.Move(req.ID, req.Index)
+		case "skip":
+			s.fileQueue.Skip()
+		default:
+			http.Error(w, "Unknown queue action", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Queue %s failed: %v", req.Action, err)
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success":    true,
+		"nowPlaying": s.fileQueue.NowPlaying(),
+		"queue":      s.fileQueue.Queue(),
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleTone starts the diagnostics tone in place of the active source, and
+// stopping it switches back to that source.
+func (s *Server) handleTone(w http.ResponseWriter, r *http.Request) {
+	if s.tone == nil || s.sources == nil {
+		http.Error(w, "Diagnostics tone not available", http.StatusServiceUnavailable)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Action  string   `json:"action"` // start or stop
+			Pattern string   `json:"pattern"`
+			Level   *float64 `json:"level"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		switch req.Action {
+		case "start":
+			err = s.startTone(req.Pattern, req.Level)
+		case "stop":
+			err = s.stopTone()
+		default:
+			http.Error(w, "Unknown tone action", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("Diagnostics tone %s failed: %v", req.Action, err)
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	pattern, level := s.tone.Tone()
+	response := map[string]interface{}{
+		"success": true,
+		"active":  s.sources.Active() == constants.SourceTone,
+		"pattern": pattern,
+		"level":   level,
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+func (s *Server) startTone(patternName string, level *float64) error {
+	pattern, current := s.tone.Tone()
+	if patternName != "" {
+		var err error
+		if pattern, err = source.ParseTonePattern(patternName); err != nil {
+			return err
+		}
+	}
+	if level != nil {
+		current = *level
+	}
+	if err := s.tone.SetTone(pattern, current); err != nil {
+		return err
+	}
+
+	s.toneMu.Lock()
+	defer s.toneMu.Unlock()
+	if active := s.sources.Active(); active != constants.SourceTone {
+		s.toneReturn = active
+	}
+	return s.sources.Select(constants.SourceTone)
+}
+
+func (s *Server) stopTone() error {
+	s.toneMu.Lock()
+	defer s.toneMu.Unlock()
+	if s.sources.Active() != constants.SourceTone {
+		return nil
+	}
+	previous := s.toneReturn
+	if previous == "" {
+		previous = constants.SourceExtension
+	}
+	return s.sources.Select(previous)
+}
+
+// handleMixer lists the extension clients being mixed and sets their gain
+// and mute. Gain and muted are optional so either can be changed alone.
+func (s *Server) handleMixer(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			ID    string   `json:"id"`
+			Gain  *float64 `json:"gain"`
+			Muted *bool    `json:"muted"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if req.Gain != nil {
+			err = s.wsServer.SetInputGain(req.ID, *req.Gain)
+		}
+		if err == nil && req.Muted != nil {
+			err = s.wsServer.SetInputMuted(req.ID, *req.Muted)
+		}
+		if err != nil {
+			log.Printf("Failed to update mixer input %q: %v", req.ID, err)
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"inputs":  s.wsServer.GetMixerInputs(),
+		"clipped": s.wsServer.GetMixerClipped(),
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleVolume gets and sets the output volume in percent. The volume is
+// saved for the connected guild and restored when connecting to it again.
+func (s *Server) handleVolume(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Volume *int `json:"volume"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Volume == nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.streamer.SetVolume(*req.Volume); err != nil {
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+		if guildID := s.streamer.GetGuildID(); s.settings != nil && guildID != "" {
+			err := s.settings.UpdateGuild(guildID, func(guild *config.GuildSettings) { guild.Volume = *req.Volume })
+			if err != nil {
+				log.Printf("Failed to save volume: %v", err)
+			}
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"volume":  s.streamer.GetVolume(),
+		"limited": s.streamer.GetLimitedSamples(),
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleLoudness gets loudness measurements and turns normalization on or
+// off or changes its target.
+func (s *Server) handleLoudness(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Enabled *bool    `json:"enabled"`
+			Target  *float64 `json:"target"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || (req.Enabled == nil && req.Target == nil) {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		current := s.streamer.GetLoudnessStats()
+		enabled, target := current.Enabled, current.Target
+		if req.Enabled != nil {
+			enabled = *req.Enabled
+		}
+		if req.Target != nil {
+			target = *req.Target
+		}
+		if err := s.streamer.SetLoudness(enabled, target); err != nil {
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := loudnessStatus(s.streamer.GetLoudnessStats())
+	response["success"] = true
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+func loudnessStatus(stats audio.LoudnessStats) map[string]interface{} {
+	return map[string]interface{}{
+		"enabled":    stats.Enabled,
+		"target":     stats.Target,
+		"momentary":  stats.Momentary,
+		"shortTerm":  stats.ShortTerm,
+		"integrated": stats.Integrated,
+		"gainDb":     stats.GainDB,
+	}
+}
+
+// handleFilters gets and replaces the DSP filter chain and lists the filter
+// types it can be built from.
+func (s *Server) handleFilters(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Filters *[]audio.FilterSpec `json:"filters"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Filters == nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.streamer.SetFilters(*req.Filters); err != nil {
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	types := []map[string]interface{}{}
+	for _, filterType := range audio.FilterTypes() {
+		types = append(types, map[string]interface{}{
+			"name":     filterType.Name,
+			"defaults": filterType.Defaults,
+		})
+	}
+	response := map[string]interface{}{
+		"success": true,
+		"filters": s.streamer.GetFilters(),
+		"types":   types,
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+// handleRecording starts or stops recording what is sent to Discord.
+func (s *Server) handleRecording(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Recording *bool `json:"recording"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Recording == nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+
+		var err error
+		if *req.Recording {
+			_, err = s.streamer.StartRecording()
+		} else {
+			err = s.streamer.StopRecording()
+		}
+		if err != nil {
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := recordingStatus(s.streamer.GetRecordingStats())
+	response["success"] = true
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
+func recordingStatus(stats opus.RecordingStats) map[string]interface{} {
+	return map[string]interface{}{
+		"active":   stats.Active,
+		"path":     stats.Path,
+		"duration": stats.Duration.Seconds(),
+		"chapters": stats.Chapters,
+	}
+}
+
+// handleClients lists the connected extension clients and lets one take
+// over as the live source.
+func (s *Server) handleClients(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		if !s.verifyLocalRequest(w, r) {
+			return
+		}
+
+		var req struct {
+			Action string `json:"action"` // takeover
+			ID     string `json:"id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "Invalid request", http.StatusBadRequest)
+			return
+		}
+		if req.Action != "takeover" {
+			http.Error(w, "Unknown client action", http.StatusBadRequest)
+			return
+		}
+
+		if err := s.wsServer.TakeOver(req.ID); err != nil {
+			log.Printf("Takeover by client %q failed: %v", req.ID, err)
+			response := map[string]interface{}{
+				"success": false,
+				"message": err.Error(),
+			}
+			w.Header().Set("Content-Type", constants.ContentTypeJSON)
+			json.NewEncoder(w).Encode(response)
+			return
+		}
+	default:
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	response := map[string]interface{}{
+		"success": true,
+		"clients": s.wsServer.GetClients(),
+	}
+	w.Header().Set("Content-Type", constants.ContentTypeJSON)
+	json.NewEncoder(w).Encode(response)
+}
+
 func (s *Server) handleChannels(w http.ResponseWriter, r *http.Request) {
 	if s.tokenData == nil {
 		http.Error(w, "Not authenticated", http.StatusUnauthorized)
@@ -1,17 +1,30 @@
 package web
 
 import (
+	"encoding/json"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
+	"time"
 
+	"trunecord/internal/audio"
 	"trunecord/internal/auth"
+	"trunecord/internal/broadcast"
 	"trunecord/internal/config"
+	"trunecord/internal/discord"
+	"trunecord/internal/opus"
+	"trunecord/internal/source"
+	"trunecord/internal/websocket"
 )
 
 // Mock WebSocket server
 type mockWebSocketServer struct {
 	streaming bool
+	ingest    audio.IngestStats
+	inputs    []audio.MixerInput
+	clients   []websocket.ClientInfo
 }
 
 func (m *mockWebSocketServer) IsStreaming() bool {
@@ -22,18 +35,159 @@ func (m *mockWebSocketServer) IsConnected() bool {
 	return true
 }
 
+func (m *mockWebSocketServer) GetIngestStats() audio.IngestStats {
+	return m.ingest
+}
+
+func (m *mockWebSocketServer) GetMixerInputs() []audio.MixerInput {
+	return m.inputs
+}
+
+func (m *mockWebSocketServer) input(id string) (*audio.MixerInput, error) {
+	for i := range m.inputs {
+		if m.inputs[i].ID == id {
+			return &m.inputs[i], nil
+		}
+	}
+	return nil, fmt.Errorf("unknown mixer input: %s", id)
+}
+
+func (m *mockWebSocketServer) SetInputGain(id string, gain float64) error {
+	input, err := m.input(id)
+	if err != nil {
+		return err
+	}
+	input.Gain = gain
+	return nil
+}
+
+func (m *mockWebSocketServer) SetInputMuted(id string, muted bool) error {
+	input, err := m.input(id)
+	if err != nil {
+		return err
+	}
+	input.Muted = muted
+	return nil
+}
+
+func (m *mockWebSocketServer) GetMixerClipped() uint64 {
+	return 0
+}
+
+func (m *mockWebSocketServer) GetClients() []websocket.ClientInfo {
+	return m.clients
+}
+
+func (m *mockWebSocketServer) TakeOver(id string) error {
+	found := false
+	for i := range m.clients {
+		m.clients[i].Live = m.clients[i].ID == id
+		found = found || m.clients[i].Live
+	}
+	if !found {
+		return fmt.Errorf("unknown client: %s", id)
+	}
+	return nil
+}
+
 // Mock Discord streamer
 type mockDiscordStreamer struct {
-	connected bool
-	streaming bool
-	guildID   string
-	channelID string
+	connected   bool
+	streaming   bool
+	guildID     string
+	channelID   string
+	buffer      audio.JitterStats
+	drift       audio.DriftStats
+	options     opus.Options
+	maxBitrate  int
+	passthrough opus.PassthroughStats
+	volume      int
+	loudness    audio.LoudnessStats
+	filters     []audio.FilterSpec
+	recording   opus.RecordingStats
+	targets     []discord.TargetStats
+}
+
+func (m *mockDiscordStreamer) SetVolume(percent int) error {
+	if percent < 0 || percent > audio.MaxVolume {
+		return fmt.Errorf("volume out of range: %d", percent)
+	}
+	m.volume = percent
+	return nil
+}
+
+func (m *mockDiscordStreamer) GetVolume() int {
+	return m.volume
+}
+
+func (m *mockDiscordStreamer) GetLimitedSamples() uint64 {
+	return 0
+}
+
+func (m *mockDiscordStreamer) SetLoudness(enabled bool, target float64) error {
+	if target < audio.MinLoudnessTarget || target > audio.MaxLoudnessTarget {
+		return fmt.Errorf("loudness target out of range: %g", target)
+	}
+	m.loudness.Enabled = enabled
+	m.loudness.Target = target
+	return nil
+}
+
+func (m *mockDiscordStreamer) GetLoudnessStats() audio.LoudnessStats {
+	return m.loudness
+}
+
+func (m *mockDiscordStreamer) IsSpeaking() bool {
+	return m.streaming
+}
+
+func (m *mockDiscordStreamer) SetFilters(specs []audio.FilterSpec) error {
+	chain := audio.NewFilterChain()
+	if err := chain.Set(specs); err != nil {
+		return err
+	}
+	m.filters = chain.Specs()
+	return nil
+}
+
+func (m *mockDiscordStreamer) GetFilters() []audio.FilterSpec {
+	return m.filters
+}
+
+func (m *mockDiscordStreamer) StartRecording() (string, error) {
+	if !m.connected {
+		return "", fmt.Errorf("not connected to a voice channel")
+	}
+	if m.recording.Active {
+		return "", fmt.Errorf("already recording to %s", m.recording.Path)
+	}
+	m.recording = opus.RecordingStats{Active: true, Path: "/recordings/" + m.guildID + "-" + m.channelID + ".ogg"}
+	return m.recording.Path, nil
+}
+
+func (m *mockDiscordStreamer) StopRecording() error {
+	m.recording.Active = false
+	return nil
+}
+
+func (m *mockDiscordStreamer) GetRecordingStats() opus.RecordingStats {
+	return m.recording
 }
 
 func (m *mockDiscordStreamer) Connect(botToken, guildID, channelID string) error {
-	m.connected = true
-	m.guildID = guildID
-	m.channelID = channelID
+	target := discord.TargetStats{ID: guildID, GuildID: guildID, ChannelID: channelID, Ready: true}
+	for i := range m.targets {
+		if m.targets[i].ID == guildID {
+			m.targets[i] = target
+			return nil
+		}
+	}
+	m.targets = append(m.targets, target)
+	if !m.connected {
+		m.connected = true
+		m.guildID = guildID
+		m.channelID = channelID
+	}
 	return nil
 }
 
@@ -41,9 +195,29 @@ func (m *mockDiscordStreamer) Disconnect() error {
 	m.connected = false
 	m.guildID = ""
 	m.channelID = ""
+	m.targets = nil
 	return nil
 }
 
+func (m *mockDiscordStreamer) DisconnectTarget(guildID string) error {
+	for i, target := range m.targets {
+		if target.ID != guildID {
+			continue
+		}
+		m.targets = append(m.targets[:i], m.targets[i+1:]...)
+		if len(m.targets) == 0 {
+			return m.Disconnect()
+		}
+		m.guildID, m.channelID = m.targets[0].GuildID, m.targets[0].ChannelID
+		return nil
+	}
+	return fmt.Errorf("not connected to %s", guildID)
+}
+
+func (m *mockDiscordStreamer) GetTargets() []discord.TargetStats {
+	return m.targets
+}
+
 func (m *mockDiscordStreamer) IsConnected() bool {
 	return m.connected
 }
@@ -60,6 +234,151 @@ func (m *mockDiscordStreamer) GetChannelID() string {
 	return m.channelID
 }
 
+func (m *mockDiscordStreamer) GetBufferStats() audio.JitterStats {
+	return m.buffer
+}
+
+func (m *mockDiscordStreamer) GetDriftStats() audio.DriftStats {
+	return m.drift
+}
+
+func (m *mockDiscordStreamer) SetEncoderOptions(options opus.Options) error {
+	if err := options.Validate(m.maxBitrate); err != nil {
+		return err
+	}
+	if m.streaming {
+		return fmt.Errorf("cannot change encoder options while streaming")
+	}
+	m.options = options
+	return nil
+}
+
+func (m *mockDiscordStreamer) GetEncoderOptions() opus.Options {
+	return m.options
+}
+
+func (m *mockDiscordStreamer) GetBitrate() int {
+	if m.options.Bitrate == opus.BitrateAuto {
+		return m.maxBitrate
+	}
+	return m.options.Bitrate
+}
+
+func (m *mockDiscordStreamer) GetMaxBitrate() int {
+	return m.maxBitrate
+}
+
+func (m *mockDiscordStreamer) GetPassthroughStats() opus.PassthroughStats {
+	return m.passthrough
+}
+
+// Mock audio source manager
+type mockSourceManager struct {
+	sources []string
+	active  string
+}
+
+func (m *mockSourceManager) Sources() []string {
+	return m.sources
+}
+
+func (m *mockSourceManager) Active() string {
+	return m.active
+}
+
+func (m *mockSourceManager) Select(name string) error {
+	for _, source := range m.sources {
+		if source == name {
+			m.active = name
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown audio source %q", name)
+}
+
+// Mock file source queue
+type mockFileQueue struct {
+	queue   []source.QueueItem
+	skipped bool
+}
+
+func (m *mockFileQueue) Add(path string) (source.QueueItem, error) {
+	if !strings.HasSuffix(path, ".wav") {
+		return source.QueueItem{}, fmt.Errorf("unsupported file type")
+	}
+	item := source.QueueItem{ID: fmt.Sprint(len(m.queue) + 1), Path: path}
+	m.queue = append(m.queue, item)
+	return item, nil
+}
+
+func (m *mockFileQueue) Remove(id string) error {
+	for i, item := range m.queue {
+		if item.ID == id {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			return nil
+		}
+	}
+	return fmt.Errorf("queue item not found")
+}
+
+func (m *mockFileQueue) Move(id string, index int) error {
+	for i, item := range m.queue {
+		if item.ID == id {
+			m.queue = append(m.queue[:i], m.queue[i+1:]...)
+			m.queue = append(m.queue[:index], append([]source.QueueItem{item}, m.queue[index:]...)...)
+			return nil
+		}
+	}
+	return fmt.Errorf("queue item not found")
+}
+
+func (m *mockFileQueue) Skip() {
+	m.skipped = true
+}
+
+func (m *mockFileQueue) Queue() []source.QueueItem {
+	return m.queue
+}
+
+func (m *mockFileQueue) NowPlaying() *source.QueueItem {
+	return nil
+}
+
+// Mock diagnostics tone
+type mockToneGenerator struct {
+	pattern source.TonePattern
+	level   float64
+}
+
+func (m *mockToneGenerator) SetTone(pattern source.TonePattern, level float64) error {
+	if level > 0 {
+		return fmt.Errorf("level above 0 dBFS")
+	}
+	m.pattern = pattern
+	m.level = level
+	return nil
+}
+
+func (m *mockToneGenerator) Tone() (source.TonePattern, float64) {
+	return m.pattern, m.level
+}
+
+type mockStreamOutput struct {
+	served string
+}
+
+func (m *mockStreamOutput) ServeOgg(w http.ResponseWriter, r *http.Request) {
+	m.served = broadcast.FormatOgg
+}
+
+func (m *mockStreamOutput) ServeWAV(w http.ResponseWriter, r *http.Request) {
+	m.served = broadcast.FormatWAV
+}
+
+func (m *mockStreamOutput) Listeners() []broadcast.ListenerStats {
+	return []broadcast.ListenerStats{{ID: 1, Format: broadcast.FormatOgg, Address: "192.0.2.1:5000", Dropped: 3}}
+}
+
 func TestNewServer(t *testing.T) {
 	port := "48766"
 	authClient := auth.NewClient("https://test.api.com")
@@ -109,3 +428,727 @@ func TestServer_HandleStatus(t *testing.T) {
 		t.Error("Expected non-empty response body")
 	}
 }
+
+func TestServer_HandleStatusReportsBuffer(t *testing.T) {
+	streamer := &mockDiscordStreamer{
+		connected: true,
+		buffer: audio.JitterStats{
+			Depth:     80 * time.Millisecond,
+			Target:    60 * time.Millisecond,
+			Underruns: 2,
+		},
+	}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+
+	var status struct {
+		Buffer struct {
+			DepthMs   int64  `json:"depthMs"`
+			TargetMs  int64  `json:"targetMs"`
+			Underruns uint64 `json:"underruns"`
+		} `json:"buffer"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	if status.Buffer.DepthMs != 80 || status.Buffer.TargetMs != 60 || status.Buffer.Underruns != 2 {
+		t.Errorf("buffer status = %+v, want depth 80ms, target 60ms, 2 underruns", status.Buffer)
+	}
+}
+
+func TestServer_HandleStatusReportsBitrate(t *testing.T) {
+	streamer := &mockDiscordStreamer{connected: true, options: opus.DefaultOptions(), maxBitrate: 96000}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+
+	var status struct {
+		Bitrate struct {
+			Current      int  `json:"current"`
+			ChannelLimit int  `json:"channelLimit"`
+			Auto         bool `json:"auto"`
+		} `json:"bitrate"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	if status.Bitrate.Current != 96000 || status.Bitrate.ChannelLimit != 96000 || !status.Bitrate.Auto {
+		t.Errorf("bitrate status = %+v, want automatic 96000 bps", status.Bitrate)
+	}
+}
+
+func TestServer_HandleStatusReportsPassthrough(t *testing.T) {
+	streamer := &mockDiscordStreamer{
+		connected:   true,
+		passthrough: opus.PassthroughStats{Forwarded: 500, Rejected: 2, Dropped: 1},
+	}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+
+	var status struct {
+		Passthrough struct {
+			Forwarded uint64 `json:"forwarded"`
+			Rejected  uint64 `json:"rejected"`
+			Dropped   uint64 `json:"dropped"`
+		} `json:"passthrough"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	if status.Passthrough.Forwarded != 500 || status.Passthrough.Rejected != 2 || status.Passthrough.Dropped != 1 {
+		t.Errorf("passthrough status = %+v, want 500 forwarded, 2 rejected, 1 dropped", status.Passthrough)
+	}
+}
+
+func TestServer_HandleStatusReportsIngest(t *testing.T) {
+	wsServer := &mockWebSocketServer{
+		ingest: audio.IngestStats{Received: 100, Lost: 3, Late: 1, Concealed: 3},
+	}
+	server := NewServer("48767", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, wsServer, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+
+	var status struct {
+		Ingest struct {
+			Received  uint64 `json:"received"`
+			Lost      uint64 `json:"lost"`
+			Late      uint64 `json:"late"`
+			Concealed uint64 `json:"concealed"`
+		} `json:"ingest"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+
+	if status.Ingest.Received != 100 || status.Ingest.Lost != 3 || status.Ingest.Late != 1 || status.Ingest.Concealed != 3 {
+		t.Errorf("ingest status = %+v, want 100 received, 3 lost, 1 late, 3 concealed", status.Ingest)
+	}
+}
+
+func TestServer_HandleConnectAppliesEncoderOptions(t *testing.T) {
+	tests := []struct {
+		name        string
+		body        string
+		wantStatus  int
+		wantSuccess bool
+		wantWarning bool
+		wantBitrate int
+	}{
+		{
+			name:        "without encoder options",
+			body:        `{"guildId": "g", "channelId": "c"}`,
+			wantStatus:  http.StatusOK,
+			wantSuccess: true,
+			wantBitrate: 64000,
+		},
+		{
+			name:        "partial options within the channel limit",
+			body:        `{"guildId": "g", "channelId": "c", "encoder": {"bitrate": 96000, "fec": true}}`,
+			wantStatus:  http.StatusOK,
+			wantSuccess: true,
+			wantBitrate: 96000,
+		},
+		{
+			name:        "above the channel limit",
+			body:        `{"guildId": "g", "channelId": "c", "encoder": {"bitrate": 128000}}`,
+			wantStatus:  http.StatusOK,
+			wantSuccess: true,
+			wantWarning: true,
+			wantBitrate: 64000,
+		},
+		{
+			name:        "out of range",
+			body:        `{"guildId": "g", "channelId": "c", "encoder": {"complexity": 20}}`,
+			wantStatus:  http.StatusBadRequest,
+			wantBitrate: 64000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := opus.DefaultOptions()
+			options.Bitrate = 64000
+			streamer := &mockDiscordStreamer{options: options, maxBitrate: 96000}
+			server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{DiscordBotToken: "token"})
+			server.tokenData = &auth.TokenData{Token: "session"}
+
+			rr := httptest.NewRecorder()
+			server.handleConnect(rr, httptest.NewRequest("POST", "/api/connect", strings.NewReader(tt.body)))
+
+			if rr.Code != tt.wantStatus {
+				t.Fatalf("status code = %d, want %d", rr.Code, tt.wantStatus)
+			}
+			if tt.wantStatus == http.StatusOK {
+				var response struct {
+					Success bool   `json:"success"`
+					Warning string `json:"warning"`
+				}
+				if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+					t.Fatalf("Failed to decode response: %v", err)
+				}
+				if response.Success != tt.wantSuccess {
+					t.Errorf("success = %v, want %v", response.Success, tt.wantSuccess)
+				}
+				if (response.Warning != "") != tt.wantWarning {
+					t.Errorf("warning = %q, want one: %v", response.Warning, tt.wantWarning)
+				}
+				if streamer.connected != tt.wantSuccess {
+					t.Errorf("connected = %v, want %v", streamer.connected, tt.wantSuccess)
+				}
+			}
+			if streamer.options.Bitrate != tt.wantBitrate {
+				t.Errorf("encoder bitrate = %d, want %d", streamer.options.Bitrate, tt.wantBitrate)
+			}
+		})
+	}
+}
+
+func TestServer_HandleSources(t *testing.T) {
+	sources := &mockSourceManager{sources: []string{"extension", "tone"}, active: "extension"}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, &mockWebSocketServer{}, &config.Config{})
+	server.SetSourceManager(sources)
+
+	type sourcesResponse struct {
+		Success bool     `json:"success"`
+		Sources []string `json:"sources"`
+		Active  string   `json:"active"`
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleSources(rr, httptest.NewRequest("GET", "/api/sources", nil))
+	var listed sourcesResponse
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed.Sources) != 2 || listed.Active != "extension" {
+		t.Errorf("GET /api/sources = %+v, want 2 sources with extension active", listed)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleSources(rr, httptest.NewRequest("POST", "/api/sources", strings.NewReader(`{"name": "tone"}`)))
+	var selected sourcesResponse
+	if err := json.NewDecoder(rr.Body).Decode(&selected); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if !selected.Success || selected.Active != "tone" {
+		t.Errorf("POST /api/sources = %+v, want tone active", selected)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleSources(rr, httptest.NewRequest("POST", "/api/sources", strings.NewReader(`{"name": "missing"}`)))
+	var failed sourcesResponse
+	if err := json.NewDecoder(rr.Body).Decode(&failed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if failed.Success || sources.active != "tone" {
+		t.Errorf("selecting an unknown source should fail and keep tone active, got %+v", failed)
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+	var status struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if status.Source != "tone" {
+		t.Errorf("status source = %q, want tone", status.Source)
+	}
+}
+
+func TestServer_HandleQueue(t *testing.T) {
+	queue := &mockFileQueue{}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, &mockWebSocketServer{}, &config.Config{})
+	server.SetFileQueue(queue)
+
+	type queueResponse struct {
+		Success bool               `json:"success"`
+		Message string             `json:"message"`
+		Queue   []source.QueueItem `json:"queue"`
+	}
+	post := func(body string) (int, queueResponse) {
+		rr := httptest.NewRecorder()
+		server.handleQueue(rr, httptest.NewRequest("POST", "/api/queue", strings.NewReader(body)))
+		var response queueResponse
+		if rr.Code == http.StatusOK {
+			if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+				t.Fatalf("Failed to decode response: %v", err)
+			}
+		}
+		return rr.Code, response
+	}
+
+	post(`{"action": "add", "path": "/music/a.wav"}`)
+	post(`{"action": "add", "path": "/music/b.wav"}`)
+	if _, response := post(`{"action": "add", "path": "/music/c.mp3"}`); response.Success || response.Message == "" {
+		t.Errorf("adding an unsupported file should fail with a message, got %+v", response)
+	}
+
+	_, response := post(`{"action": "move", "id": "2", "index": 0}`)
+	if !response.Success || len(response.Queue) != 2 || response.Queue[0].Path != "/music/b.wav" {
+		t.Errorf("move response = %+v, want b.wav first", response)
+	}
+
+	_, response = post(`{"action": "remove", "id": "1"}`)
+	if !response.Success || len(response.Queue) != 1 {
+		t.Errorf("remove response = %+v, want one item left", response)
+	}
+
+	if _, response = post(`{"action": "skip"}`); !response.Success || !queue.skipped {
+		t.Error("skip should skip the current file")
+	}
+
+	if code, _ := post(`{"action": "shuffle"}`); code != http.StatusBadRequest {
+		t.Errorf("unknown action status = %d, want %d", code, http.StatusBadRequest)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleQueue(rr, httptest.NewRequest("GET", "/api/queue", nil))
+	var listed queueResponse
+	if err := json.NewDecoder(rr.Body).Decode(&listed); err != nil {
+		t.Fatalf("Failed to decode response: %v", err)
+	}
+	if len(listed.Queue) != 1 || listed.Queue[0].Path != "/music/b.wav" {
+		t.Errorf("GET /api/queue = %+v, want b.wav queued", listed)
+	}
+}
+
+func TestServer_HandleTone(t *testing.T) {
+	sources := &mockSourceManager{sources: []string{"file", "tone"}, active: "file"}
+	tone := &mockToneGenerator{pattern: source.ToneSweep, level: -12}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, &mockWebSocketServer{}, &config.Config{})
+	server.SetSourceManager(sources)
+	server.SetToneGenerator(tone)
+
+	type toneResponse struct {
+		Success bool    `json:"success"`
+		Active  bool    `json:"active"`
+		Pattern string  `json:"pattern"`
+		Level   float64 `json:"level"`
+	}
+	post := func(body string) toneResponse {
+		rr := httptest.NewRecorder()
+		server.handleTone(rr, httptest.NewRequest("POST", "/api/diagnostics/tone", strings.NewReader(body)))
+		var response toneResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := post(`{"action": "start", "pattern": "leftright", "level": -6}`)
+	if !response.Success || !response.Active || response.Pattern != "leftright" || response.Level != -6 {
+		t.Errorf("start response = %+v, want leftright at -6 dBFS playing", response)
+	}
+	if sources.active != "tone" {
+		t.Errorf("active source = %q, want tone", sources.active)
+	}
+
+	// Restarting with another pattern keeps the level and the source to return to
+	if response = post(`{"action": "start", "pattern": "beep"}`); response.Pattern != "beep" || response.Level != -6 {
+		t.Errorf("restart response = %+v, want beep at -6 dBFS", response)
+	}
+	if response = post(`{"action": "start", "pattern": "noise"}`); response.Success {
+		t.Error("starting an unknown pattern should fail")
+	}
+
+	if response = post(`{"action": "stop"}`); !response.Success || response.Active {
+		t.Errorf("stop response = %+v, want the tone stopped", response)
+	}
+	if sources.active != "file" {
+		t.Errorf("active source after stop = %q, want file restored", sources.active)
+	}
+}
+
+func TestServer_HandleMixer(t *testing.T) {
+	wsServer := &mockWebSocketServer{inputs: []audio.MixerInput{{ID: "1", Gain: 1}, {ID: "2", Gain: 1}}}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, wsServer, &config.Config{})
+
+	type mixerResponse struct {
+		Success bool               `json:"success"`
+		Inputs  []audio.MixerInput `json:"inputs"`
+	}
+	post := func(body string) mixerResponse {
+		rr := httptest.NewRecorder()
+		server.handleMixer(rr, httptest.NewRequest("POST", "/api/mixer", strings.NewReader(body)))
+		var response mixerResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := post(`{"id": "2", "gain": 0.5}`)
+	if !response.Success || response.Inputs[1].Gain != 0.5 || response.Inputs[1].Muted {
+		t.Errorf("gain response = %+v, want input 2 at half gain", response)
+	}
+
+	// Muting leaves the gain alone
+	response = post(`{"id": "2", "muted": true}`)
+	if !response.Success || response.Inputs[1].Gain != 0.5 || !response.Inputs[1].Muted {
+		t.Errorf("mute response = %+v, want input 2 muted at half gain", response)
+	}
+
+	if response = post(`{"id": "3", "gain": 1}`); response.Success {
+		t.Error("updating an unknown input should fail")
+	}
+}
+
+func TestServer_HandleClients(t *testing.T) {
+	wsServer := &mockWebSocketServer{clients: []websocket.ClientInfo{{ID: "1", Live: true}, {ID: "2", Waiting: true}}}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), &mockDiscordStreamer{}, wsServer, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+	var status struct {
+		Clients    []websocket.ClientInfo `json:"clients"`
+		LiveClient string                 `json:"liveClient"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if len(status.Clients) != 2 || status.LiveClient != "1" {
+		t.Errorf("status = %+v, want both clients with client 1 live", status)
+	}
+
+	type clientsResponse struct {
+		Success bool                   `json:"success"`
+		Clients []websocket.ClientInfo `json:"clients"`
+	}
+	post := func(body string) clientsResponse {
+		rr := httptest.NewRecorder()
+		server.handleClients(rr, httptest.NewRequest("POST", "/api/clients", strings.NewReader(body)))
+		var response clientsResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	response := post(`{"action": "takeover", "id": "2"}`)
+	if !response.Success || response.Clients[0].Live || !response.Clients[1].Live {
+		t.Errorf("takeover response = %+v, want client 2 live", response)
+	}
+	if response = post(`{"action": "takeover", "id": "3"}`); response.Success {
+		t.Error("taking over with an unknown client should fail")
+	}
+}
+
+func TestServer_HandleVolume(t *testing.T) {
+	streamer := &mockDiscordStreamer{connected: true, guildID: "guild-1", volume: 100}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{})
+	settings := config.NewSettings("")
+	server.SetSettings(settings)
+
+	type volumeResponse struct {
+		Success bool `json:"success"`
+		Volume  int  `json:"volume"`
+	}
+	post := func(body string) volumeResponse {
+		rr := httptest.NewRecorder()
+		server.handleVolume(rr, httptest.NewRequest("POST", "/api/volume", strings.NewReader(body)))
+		var response volumeResponse
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	if response := post(`{"volume": 70}`); !response.Success || response.Volume != 70 {
+		t.Errorf("response = %+v, want volume 70", response)
+	}
+	if volume := settings.Guild("guild-1").Volume; volume != 70 {
+		t.Errorf("saved volume = %d, want 70 for the connected guild", volume)
+	}
+	if response := post(`{"volume": 250}`); response.Success || streamer.volume != 70 {
+		t.Errorf("response = %+v, want out of range volumes rejected", response)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleVolume(rr, httptest.NewRequest("POST", "/api/volume", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status without a volume = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_HandleLoudness(t *testing.T) {
+	streamer := &mockDiscordStreamer{loudness: audio.LoudnessStats{Target: audio.DefaultLoudnessTarget}}
+	server := NewServer("8080", nil, streamer, &mockWebSocketServer{}, &config.Config{})
+
+	type loudnessResponse struct {
+		Success bool    `json:"success"`
+		Enabled bool    `json:"enabled"`
+		Target  float64 `json:"target"`
+	}
+	post := func(body string) loudnessResponse {
+		rr := httptest.NewRecorder()
+		server.handleLoudness(rr, httptest.NewRequest("POST", "/api/loudness", strings.NewReader(body)))
+		var response loudnessResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	if response := post(`{"enabled": true}`); !response.Success || !response.Enabled || response.Target != audio.DefaultLoudnessTarget {
+		t.Errorf("response = %+v, want normalization enabled at the default target", response)
+	}
+	if response := post(`{"target": -23}`); !response.Success || !response.Enabled || response.Target != -23 {
+		t.Errorf("response = %+v, want the target changed and normalization left on", response)
+	}
+	if response := post(`{"target": 0}`); response.Success || streamer.loudness.Target != -23 {
+		t.Errorf("response = %+v, want out of range targets rejected", response)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleLoudness(rr, httptest.NewRequest("POST", "/api/loudness", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("status without changes = %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_HandleFilters(t *testing.T) {
+	streamer := &mockDiscordStreamer{}
+	server := NewServer("8080", nil, streamer, &mockWebSocketServer{}, &config.Config{})
+
+	type filtersResponse struct {
+		Success bool               `json:"success"`
+		Message string             `json:"message"`
+		Filters []audio.FilterSpec `json:"filters"`
+		Types   []struct {
+			Name string `json:"name"`
+		} `json:"types"`
+	}
+	request := func(method, body string) filtersResponse {
+		rr := httptest.NewRecorder()
+		server.handleFilters(rr, httptest.NewRequest(method, "/api/filters", strings.NewReader(body)))
+		var response filtersResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	response := request("GET", "")
+	if !response.Success || len(response.Filters) != 0 || len(response.Types) < 3 {
+		t.Errorf("GET response = %+v, want an empty chain and the built-in types", response)
+	}
+
+	response = request("POST", `{"filters": [{"type": "highpass"}, {"type": "eq", "params": {"freq": 3000, "gain": -3}}]}`)
+	if !response.Success || len(response.Filters) != 2 || response.Filters[1].Params["gain"] != -3 {
+		t.Errorf("POST response = %+v, want the highpass and eq", response)
+	}
+	if response := request("POST", `{"filters": [{"type": "reverb"}]}`); response.Success || len(streamer.filters) != 2 {
+		t.Errorf("POST response = %+v, want unknown filters rejected and the chain kept", response)
+	}
+	if response := request("POST", `{"filters": []}`); !response.Success || len(streamer.filters) != 0 {
+		t.Errorf("POST response = %+v, want the chain cleared", response)
+	}
+}
+
+func TestServer_HandleRecording(t *testing.T) {
+	streamer := &mockDiscordStreamer{}
+	server := NewServer("8080", nil, streamer, &mockWebSocketServer{}, &config.Config{})
+
+	type recordingResponse struct {
+		Success bool   `json:"success"`
+		Message string `json:"message"`
+		Active  bool   `json:"active"`
+		Path    string `json:"path"`
+	}
+	request := func(method, body string) recordingResponse {
+		rr := httptest.NewRecorder()
+		server.handleRecording(rr, httptest.NewRequest(method, "/api/recording", strings.NewReader(body)))
+		var response recordingResponse
+		if err := json.Unmarshal(rr.Body.Bytes(), &response); err != nil {
+			t.Fatalf("Failed to parse response: %v", err)
+		}
+		return response
+	}
+
+	if response := request("POST", `{"recording": true}`); response.Success || streamer.recording.Active {
+		t.Errorf("POST response = %+v, want recording refused while disconnected", response)
+	}
+
+	streamer.Connect("token", "g", "c")
+	response := request("POST", `{"recording": true}`)
+	if !response.Success || !response.Active || response.Path != "/recordings/g-c.ogg" {
+		t.Errorf("POST response = %+v, want recording to the guild's file", response)
+	}
+	if response := request("GET", ""); !response.Success || !response.Active {
+		t.Errorf("GET response = %+v, want the active recording", response)
+	}
+	if response := request("POST", `{"recording": false}`); !response.Success || response.Active || response.Path == "" {
+		t.Errorf("POST response = %+v, want recording stopped with the last path kept", response)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleRecording(rr, httptest.NewRequest("POST", "/api/recording", strings.NewReader(`{}`)))
+	if rr.Code != http.StatusBadRequest {
+		t.Errorf("POST without recording returned %d, want %d", rr.Code, http.StatusBadRequest)
+	}
+}
+
+func TestServer_HandleStream(t *testing.T) {
+	server := NewServer("8080", nil, &mockDiscordStreamer{}, &mockWebSocketServer{}, &config.Config{})
+
+	rr := httptest.NewRecorder()
+	server.handleStream(rr, httptest.NewRequest("GET", "/stream.ogg", nil))
+	if rr.Code != http.StatusServiceUnavailable {
+		t.Errorf("stream without output returned %d, want %d", rr.Code, http.StatusServiceUnavailable)
+	}
+
+	streams := &mockStreamOutput{}
+	server.SetStreamOutput(streams)
+	for path, want := range map[string]string{"/stream.ogg": broadcast.FormatOgg, "/stream.wav": broadcast.FormatWAV} {
+		server.handleStream(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+		if streams.served != want {
+			t.Errorf("%s served %q, want %q", path, streams.served, want)
+		}
+	}
+
+	rr = httptest.NewRecorder()
+	server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+	var status struct {
+		Listeners []struct {
+			Format  string `json:"format"`
+			Dropped uint64 `json:"dropped"`
+		} `json:"listeners"`
+	}
+	if err := json.NewDecoder(rr.Body).Decode(&status); err != nil {
+		t.Fatalf("Failed to decode status: %v", err)
+	}
+	if len(status.Listeners) != 1 || status.Listeners[0].Format != "ogg" || status.Listeners[0].Dropped != 3 {
+		t.Errorf("listeners = %+v, want the Ogg listener", status.Listeners)
+	}
+}
+
+func TestServer_HandleConnectRestoresVolume(t *testing.T) {
+	streamer := &mockDiscordStreamer{volume: 100}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{DiscordBotToken: "token"})
+	server.tokenData = &auth.TokenData{Token: "session"}
+	settings := config.NewSettings("")
+	settings.UpdateGuild("g", func(guild *config.GuildSettings) { guild.Volume = 40 })
+	server.SetSettings(settings)
+
+	rr := httptest.NewRecorder()
+	server.handleConnect(rr, httptest.NewRequest("POST", "/api/connect", strings.NewReader(`{"guildId": "g", "channelId": "c"}`)))
+	if !streamer.connected || streamer.volume != 40 {
+		t.Errorf("connected = %v with volume %d, want the guild's saved volume 40", streamer.connected, streamer.volume)
+	}
+}
+
+func TestServer_HandleConnectAddsTargetWhileStreaming(t *testing.T) {
+	options := opus.DefaultOptions()
+	streamer := &mockDiscordStreamer{options: options, maxBitrate: 96000}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{DiscordBotToken: "token"})
+	server.tokenData = &auth.TokenData{Token: "session"}
+
+	connect := func(body string) map[string]interface{} {
+		rr := httptest.NewRecorder()
+		server.handleConnect(rr, httptest.NewRequest("POST", "/api/connect", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("connect status code = %d, want %d", rr.Code, http.StatusOK)
+		}
+		var response map[string]interface{}
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response
+	}
+
+	connect(`{"guildId": "g1", "channelId": "c1", "encoder": {"bitrate": 0}}`)
+	streamer.streaming = true
+
+	// The web UI always sends the encoder options, unchanged or not
+	if response := connect(`{"guildId": "g2", "channelId": "c2", "encoder": {"bitrate": 0}}`); response["success"] != true || response["warning"] != nil {
+		t.Errorf("response = %v, want success for unchanged options", response)
+	}
+	if response := connect(`{"guildId": "g3", "channelId": "c3", "encoder": {"bitrate": 32000}}`); response["success"] != true || response["warning"] == nil {
+		t.Errorf("response = %v, want success with a warning for new options", response)
+	}
+	if response := connect(`{"guildId": "g1", "channelId": "c4", "encoder": {"bitrate": 32000}}`); response["success"] != true {
+		t.Errorf("response = %v, want moving within a guild to succeed", response)
+	}
+
+	if len(streamer.targets) != 3 || streamer.targets[0].ChannelID != "c4" {
+		t.Errorf("targets = %+v, want g1 moved to c4 alongside g2 and g3", streamer.targets)
+	}
+	if streamer.options != options {
+		t.Errorf("encoder options = %+v, want them kept while streaming", streamer.options)
+	}
+}
+
+func TestServer_HandleDisconnectTarget(t *testing.T) {
+	streamer := &mockDiscordStreamer{}
+	server := NewServer("48766", auth.NewClient("https://test.api.com"), streamer, &mockWebSocketServer{}, &config.Config{DiscordBotToken: "token"})
+	server.tokenData = &auth.TokenData{Token: "session"}
+
+	for _, body := range []string{`{"guildId": "g1", "channelId": "c1"}`, `{"guildId": "g2", "channelId": "c2"}`} {
+		rr := httptest.NewRecorder()
+		server.handleConnect(rr, httptest.NewRequest("POST", "/api/connect", strings.NewReader(body)))
+		if rr.Code != http.StatusOK {
+			t.Fatalf("connect status code = %d, want %d", rr.Code, http.StatusOK)
+		}
+	}
+
+	status := func() []map[string]interface{} {
+		rr := httptest.NewRecorder()
+		server.handleStatus(rr, httptest.NewRequest("GET", "/api/status", nil))
+		var response struct {
+			Targets []map[string]interface{} `json:"targets"`
+		}
+		if err := json.NewDecoder(rr.Body).Decode(&response); err != nil {
+			t.Fatalf("Failed to decode response: %v", err)
+		}
+		return response.Targets
+	}
+	if targets := status(); len(targets) != 2 || targets[1]["channelId"] != "c2" || targets[1]["health"] != "ok" {
+		t.Errorf("targets = %v, want both channels healthy", targets)
+	}
+
+	rr := httptest.NewRecorder()
+	server.handleDisconnect(rr, httptest.NewRequest("POST", "/api/disconnect", strings.NewReader(`{"guildId": "g1"}`)))
+	if rr.Code != http.StatusOK {
+		t.Fatalf("disconnect status code = %d, want %d", rr.Code, http.StatusOK)
+	}
+	if targets := status(); len(targets) != 1 || targets[0]["guildId"] != "g2" || !streamer.connected {
+		t.Errorf("targets = %v, want only g2 left", targets)
+	}
+
+	// Without a body every channel is left
+	rr = httptest.NewRecorder()
+	server.handleDisconnect(rr, httptest.NewRequest("POST", "/api/disconnect", nil))
+	if rr.Code != http.StatusOK || streamer.connected || len(status()) != 0 {
+		t.Errorf("status code = %d, connected = %v, want every channel left", rr.Code, streamer.connected)
+	}
+}
+
+func TestTargetHealth(t *testing.T) {
+	tests := []struct {
+		name   string
+		target discord.TargetStats
+		want   string
+	}{
+		{"joining", discord.TargetStats{}, "connecting"},
+		{"quiet", discord.TargetStats{Ready: true}, "ok"},
+		{"sending", discord.TargetStats{Ready: true, Speaking: true, LastSent: time.Now()}, "ok"},
+		{"stuck", discord.TargetStats{Ready: true, Speaking: true, LastSent: time.Now().Add(-time.Minute)}, "stalled"},
+		{"refused", discord.TargetStats{Ready: true, Speaking: true, LastSent: time.Now(), Error: "gateway closed"}, "failed"},
+	}
+	for _, tt := range tests {
+		if got := targetHealth(tt.target); got != tt.want {
+			t.Errorf("%s: targetHealth() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
@@ -15,6 +15,7 @@ const indexTemplate = `<!DOCTYPE html>
             --discord-blurple: #5865f2;
             --discord-green: #57f287;
             --discord-red: #ed4245;
+            --discord-yellow: #fee75c;
         }
         
         body {
@@ -135,6 +136,11 @@ const indexTemplate = `<!DOCTYPE html>
             color: var(--discord-red);
         }
         
+        .status-indicator.idle {
+            background-color: rgba(254, 231, 92, 0.2);
+            color: var(--discord-yellow);
+        }
+        
         .alert-error {
             background-color: rgba(237, 66, 69, 0.2);
             border: 1px solid var(--discord-red);
@@ -395,6 +401,129 @@ const indexTemplate = `<!DOCTYPE html>
                                 </select>
                             </div>
                             
+                            <div class="mb-4">
+                                <label class="form-label" for="source-select">Audio Source</label>
+                                <select id="source-select" class="form-select" disabled></select>
+                            </div>
+                            
+                            <div class="mb-4">
+                                <label class="form-label" for="volume-slider">Volume <small id="volume-value" class="text-muted">100%</small></label>
+                                <input id="volume-slider" type="range" class="form-range" min="0" max="200" step="5" value="100">
+                                <div class="input-group input-group-sm mt-2">
+                                    <div class="input-group-text">
+                                        <input id="loudness-enabled" class="form-check-input mt-0 me-2" type="checkbox">
+                                        <label for="loudness-enabled">Normalize to</label>
+                                    </div>
+                                    <input id="loudness-target" type="number" class="form-control" min="-36" max="-6" step="1" value="-16">
+                                    <span class="input-group-text">LUFS</span>
+                                </div>
+                                <small id="loudness-readout" class="text-muted"></small>
+                            </div>
+                            
+                            <div class="mb-4">
+                                <button id="record-btn" class="btn btn-outline-danger btn-sm">Record</button>
+                                <small id="record-readout" class="text-muted ms-2"></small>
+                                <small class="text-muted d-block mt-1">
+                                    Listen outside Discord at <a href="/stream.ogg" target="_blank">/stream.ogg</a> or <a href="/stream.wav" target="_blank">/stream.wav</a><span id="stream-listeners"></span>
+                                </small>
+                            </div>
+                            
+                            <details id="queue-panel" class="mb-4">
+                                <summary class="form-label">Play Queue</summary>
+                                <div class="input-group mt-2">
+                                    <input id="queue-path" type="text" class="form-control" placeholder="File in the media directory: .wav, .ogg or .flac">
+                                    <button id="queue-add-btn" class="btn btn-outline-primary">Add</button>
+                                    <button id="queue-skip-btn" class="btn btn-outline-secondary">Skip</button>
+                                </div>
+                                <small id="queue-now-playing" class="text-muted"></small>
+                                <ul id="queue-list" class="list-group mt-2"></ul>
+                                <small class="text-muted">Select the "file" audio source to play the queue.</small>
+                            </details>
+                            
+                            <details id="clients-panel" class="mb-4">
+                                <summary class="form-label">Extension Clients</summary>
+                                <ul id="clients-list" class="list-group mt-2"></ul>
+                            </details>
+                            
+                            <details id="mixer-panel" class="mb-4">
+                                <summary class="form-label">Mixer</summary>
+                                <ul id="mixer-list" class="list-group mt-2"></ul>
+                                <small id="mixer-clipped" class="text-muted"></small>
+                            </details>
+                            
+                            <details id="filters-panel" class="mb-4">
+                                <summary class="form-label">Filters</summary>
+                                <textarea id="filters-chain" class="form-control font-monospace mt-2" rows="6" spellcheck="false"></textarea>
+                                <div class="d-flex gap-2 mt-2 align-items-center">
+                                    <button id="filters-apply-btn" class="btn btn-outline-primary">Apply</button>
+                                    <small id="filters-types" class="text-muted"></small>
+                                </div>
+                            </details>
+                            
+                            <details id="tone-panel" class="mb-4">
+                                <summary class="form-label">Diagnostics</summary>
+                                <div class="row g-3 mt-1">
+                                    <div class="col-6">
+                                        <label class="form-label" for="tone-pattern">Test Signal</label>
+                                        <select id="tone-pattern" class="form-select">
+                                            <option value="sweep">Sine sweep (20Hz-20kHz)</option>
+                                            <option value="leftright">Left/right channel test</option>
+                                            <option value="beep">Beep every second</option>
+                                        </select>
+                                    </div>
+                                    <div class="col-6">
+                                        <label class="form-label" for="tone-level">Level (dBFS)</label>
+                                        <input id="tone-level" type="number" class="form-control" min="-60" max="0" step="1" value="-12">
+                                    </div>
+                                </div>
+                                <div class="d-flex gap-2 mt-2 align-items-center">
+                                    <button id="tone-start-btn" class="btn btn-outline-primary">Play Tone</button>
+                                    <button id="tone-stop-btn" class="btn btn-outline-secondary">Stop</button>
+                                    <small id="tone-status" class="text-muted"></small>
+                                </div>
+                            </details>
+                            
+                            <details class="mb-4">
+                                <summary class="form-label">Audio Quality</summary>
+                                <div class="row g-3 mt-1">
+                                    <div class="col-6">
+                                        <label class="form-label" for="opus-bitrate">Bitrate (kbps)</label>
+                                        <input id="opus-bitrate" type="number" class="form-control" min="6" max="510" step="1" placeholder="Auto">
+                                        <small id="opus-bitrate-current" class="text-muted"></small>
+                                    </div>
+                                    <div class="col-6">
+                                        <label class="form-label" for="opus-application">Optimize For</label>
+                                        <select id="opus-application" class="form-select">
+                                            <option value="music">Music</option>
+                                            <option value="voice">Voice</option>
+                                        </select>
+                                    </div>
+                                    <div class="col-6">
+                                        <label class="form-label" for="opus-complexity">Complexity (0-10)</label>
+                                        <input id="opus-complexity" type="number" class="form-control" min="0" max="10" step="1">
+                                    </div>
+                                    <div class="col-6">
+                                        <label class="form-label" for="opus-packet-loss">Expected Packet Loss (%)</label>
+                                        <input id="opus-packet-loss" type="number" class="form-control" min="0" max="100" step="1">
+                                    </div>
+                                    <div class="col-6">
+                                        <div class="form-check">
+                                            <input id="opus-fec" type="checkbox" class="form-check-input">
+                                            <label class="form-check-label" for="opus-fec">Forward error correction</label>
+                                        </div>
+                                    </div>
+                                    <div class="col-6">
+                                        <div class="form-check">
+                                            <input id="opus-dtx" type="checkbox" class="form-check-input">
+                                            <label class="form-check-label" for="opus-dtx">Discontinuous transmission</label>
+                                        </div>
+                                    </div>
+                                </div>
+                                <small class="text-muted">Leave the bitrate empty to match the voice channel. A manual bitrate cannot exceed the channel's.</small>
+                            </details>
+                            
+                            <ul id="targets-list" class="list-group mb-3"></ul>
+                            
                             <div class="d-flex gap-3 justify-content-center">
                                 <button id="connect-btn" class="btn btn-success btn-lg" disabled>
                                     <i class="fas fa-plug me-2"></i>Connect
@@ -435,7 +564,399 @@ const indexTemplate = `<!DOCTYPE html>
             const channelSelect = document.getElementById('channel-select');
             const connectBtn = document.getElementById('connect-btn');
             const disconnectBtn = document.getElementById('disconnect-btn');
+            let discordConnected = false;
             const streamingStatus = document.getElementById('streaming-status');
+            const sourceSelect = document.getElementById('source-select');
+            let encoderLoaded = false;
+            
+            function fillEncoderOptions(encoder) {
+                document.getElementById('opus-bitrate').value = encoder.bitrate ? Math.round(encoder.bitrate / 1000) : '';
+                document.getElementById('opus-complexity').value = encoder.complexity;
+                document.getElementById('opus-packet-loss').value = encoder.packetLossPercent;
+                document.getElementById('opus-fec').checked = encoder.fec;
+                document.getElementById('opus-dtx').checked = encoder.dtx;
+                document.getElementById('opus-application').value = encoder.application;
+            }
+            
+            function readEncoderOptions() {
+                return {
+                    bitrate: (parseInt(document.getElementById('opus-bitrate').value, 10) || 0) * 1000,
+                    complexity: parseInt(document.getElementById('opus-complexity').value, 10),
+                    packetLossPercent: parseInt(document.getElementById('opus-packet-loss').value, 10),
+                    fec: document.getElementById('opus-fec').checked,
+                    dtx: document.getElementById('opus-dtx').checked,
+                    application: document.getElementById('opus-application').value
+                };
+            }
+            
+            async function loadSources() {
+                try {
+                    const response = await fetch('/api/sources');
+                    if (!response.ok) {
+                        return;
+                    }
+                    const data = await response.json();
+                    sourceSelect.innerHTML = '';
+                    data.sources.forEach(name => {
+                        const option = document.createElement('option');
+                        option.value = name;
+                        option.textContent = name;
+                        sourceSelect.appendChild(option);
+                    });
+                    sourceSelect.value = data.active;
+                    sourceSelect.disabled = false;
+                } catch (error) {
+                    console.error('Error loading sources:', error);
+                }
+            }
+            
+            if (sourceSelect) {
+                loadSources();
+                sourceSelect.addEventListener('change', async function() {
+                    try {
+                        const response = await fetch('/api/sources', {
+                            method: 'POST',
+                            headers: { 'Content-Type': 'application/json' },
+                            body: JSON.stringify({ name: sourceSelect.value })
+                        });
+                        const data = await response.json();
+                        if (!data.success) {
+                            alert(data.message);
+                        }
+                    } catch (error) {
+                        alert('Source selection error: ' + error.message);
+                    }
+                });
+            }
+            
+            const volumeSlider = document.getElementById('volume-slider');
+            if (volumeSlider) {
+                volumeSlider.addEventListener('input', function() {
+                    document.getElementById('volume-value').textContent = volumeSlider.value + '%';
+                });
+                volumeSlider.addEventListener('change', async function() {
+                    try {
+                        const response = await fetch('/api/volume', {
+                            method: 'POST',
+                            headers: { 'Content-Type': 'application/json' },
+                            body: JSON.stringify({ volume: parseInt(volumeSlider.value, 10) })
+                        });
+                        const data = await response.json();
+                        if (!data.success) {
+                            alert(data.message);
+                        }
+                    } catch (error) {
+                        alert('Volume error: ' + error.message);
+                    }
+                });
+            }
+            
+            const loudnessEnabled = document.getElementById('loudness-enabled');
+            const loudnessTarget = document.getElementById('loudness-target');
+            async function setLoudness(body) {
+                try {
+                    const response = await fetch('/api/loudness', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(body)
+                    });
+                    const data = await response.json();
+                    if (!data.success) {
+                        alert(data.message);
+                    }
+                } catch (error) {
+                    alert('Loudness error: ' + error.message);
+                }
+            }
+            if (loudnessEnabled && loudnessTarget) {
+                loudnessEnabled.addEventListener('change', function() {
+                    setLoudness({ enabled: loudnessEnabled.checked });
+                });
+                loudnessTarget.addEventListener('change', function() {
+                    setLoudness({ target: parseFloat(loudnessTarget.value) });
+                });
+            }
+            
+            const recordBtn = document.getElementById('record-btn');
+            if (recordBtn) {
+                recordBtn.addEventListener('click', async function() {
+                    try {
+                        const response = await fetch('/api/recording', {
+                            method: 'POST',
+                            headers: { 'Content-Type': 'application/json' },
+                            body: JSON.stringify({ recording: recordBtn.dataset.active !== 'true' })
+                        });
+                        const data = await response.json();
+                        if (!data.success) {
+                            alert(data.message);
+                        }
+                        checkStatus();
+                    } catch (error) {
+                        alert('Recording error: ' + error.message);
+                    }
+                });
+            }
+            
+            async function queueAction(body) {
+                try {
+                    const response = await fetch('/api/queue', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(body)
+                    });
+                    const data = await response.json();
+                    if (!data.success) {
+                        alert(data.message);
+                        return;
+                    }
+                    renderQueue(data);
+                } catch (error) {
+                    alert('Queue error: ' + error.message);
+                }
+            }
+            
+            function renderQueue(data) {
+                document.getElementById('queue-now-playing').textContent = data.nowPlaying
+                    ? 'Now playing: ' + data.nowPlaying.name
+                    : 'Nothing playing';
+                
+                const list = document.getElementById('queue-list');
+                list.innerHTML = '';
+                (data.queue || []).forEach((item, index) => {
+                    const entry = document.createElement('li');
+                    entry.className = 'list-group-item d-flex align-items-center gap-2';
+                    const name = document.createElement('span');
+                    name.className = 'me-auto';
+                    name.textContent = item.name;
+                    name.title = item.path;
+                    entry.appendChild(name);
+                    
+                    [['↑', { action: 'move', id: item.id, index: index - 1 }],
+                     ['↓', { action: 'move', id: item.id, index: index + 1 }],
+                     ['✕', { action: 'remove', id: item.id }]].forEach(([label, body]) => {
+                        const button = document.createElement('button');
+                        button.className = 'btn btn-sm btn-outline-secondary';
+                        button.textContent = label;
+                        button.addEventListener('click', () => queueAction(body));
+                        entry.appendChild(button);
+                    });
+                    list.appendChild(entry);
+                });
+            }
+            
+            async function loadQueue() {
+                try {
+                    const response = await fetch('/api/queue');
+                    if (response.ok) {
+                        renderQueue(await response.json());
+                    }
+                } catch (error) {
+                    console.error('Error loading queue:', error);
+                }
+            }
+            
+            const queuePanel = document.getElementById('queue-panel');
+            if (queuePanel) {
+                document.getElementById('queue-add-btn').addEventListener('click', function() {
+                    const path = document.getElementById('queue-path');
+                    if (path.value.trim()) {
+                        queueAction({ action: 'add', path: path.value.trim() });
+                        path.value = '';
+                    }
+                });
+                document.getElementById('queue-skip-btn').addEventListener('click', () => queueAction({ action: 'skip' }));
+                queuePanel.addEventListener('toggle', loadQueue);
+            }
+            
+            async function takeOver(id) {
+                try {
+                    const response = await fetch('/api/clients', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify({ action: 'takeover', id: id })
+                    });
+                    const data = await response.json();
+                    if (!data.success) {
+                        alert(data.message);
+                        return;
+                    }
+                    renderClients(data.clients);
+                } catch (error) {
+                    alert('Takeover error: ' + error.message);
+                }
+            }
+            
+            function renderClients(clients) {
+                const list = document.getElementById('clients-list');
+                list.innerHTML = (clients || []).length ? '' : '<li class="list-group-item text-muted">No extension clients connected</li>';
+                (clients || []).forEach(client => {
+                    const entry = document.createElement('li');
+                    entry.className = 'list-group-item d-flex align-items-center gap-2';
+                    const name = document.createElement('span');
+                    name.className = 'me-auto';
+                    name.textContent = 'Client ' + client.id + (client.version ? ' (v' + client.version + ')' : '');
+                    entry.appendChild(name);
+                    
+                    if (client.live) {
+                        const badge = document.createElement('span');
+                        badge.className = 'badge bg-success';
+                        badge.textContent = 'Live';
+                        entry.appendChild(badge);
+                    } else {
+                        if (client.waiting) {
+                            const badge = document.createElement('span');
+                            badge.className = 'badge bg-secondary';
+                            badge.textContent = 'Waiting';
+                            entry.appendChild(badge);
+                        }
+                        const button = document.createElement('button');
+                        button.className = 'btn btn-sm btn-outline-primary';
+                        button.textContent = 'Take Over';
+                        button.addEventListener('click', () => takeOver(client.id));
+                        entry.appendChild(button);
+                    }
+                    list.appendChild(entry);
+                });
+            }
+            
+            async function mixerAction(body) {
+                try {
+                    const response = await fetch('/api/mixer', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(body)
+                    });
+                    const data = await response.json();
+                    if (!data.success) {
+                        alert(data.message);
+                    }
+                } catch (error) {
+                    alert('Mixer error: ' + error.message);
+                }
+            }
+            
+            // Inputs are only re-rendered when clients come or go, so a
+            // slider being dragged is not replaced under the pointer
+            let mixerInputIds = '';
+            function renderMixer(mixer) {
+                const inputs = mixer.inputs || [];
+                document.getElementById('mixer-clipped').textContent = mixer.clipped
+                    ? mixer.clipped + ' samples limited'
+                    : '';
+                
+                const ids = inputs.map(input => input.id).join(',');
+                if (ids === mixerInputIds) {
+                    return;
+                }
+                mixerInputIds = ids;
+                
+                const list = document.getElementById('mixer-list');
+                list.innerHTML = inputs.length ? '' : '<li class="list-group-item text-muted">No extension clients connected</li>';
+                inputs.forEach(input => {
+                    const entry = document.createElement('li');
+                    entry.className = 'list-group-item d-flex align-items-center gap-2';
+                    const name = document.createElement('span');
+                    name.textContent = 'Client ' + input.id;
+                    entry.appendChild(name);
+                    
+                    const gain = document.createElement('input');
+                    gain.type = 'range';
+                    gain.className = 'form-range flex-grow-1';
+                    gain.min = 0;
+                    gain.max = 200;
+                    gain.value = Math.round(input.gain * 100);
+                    gain.title = gain.value + '%';
+                    gain.addEventListener('change', () => {
+                        gain.title = gain.value + '%';
+                        mixerAction({ id: input.id, gain: gain.value / 100 });
+                    });
+                    entry.appendChild(gain);
+                    
+                    const mute = document.createElement('button');
+                    mute.className = 'btn btn-sm ' + (input.muted ? 'btn-secondary' : 'btn-outline-secondary');
+                    mute.textContent = 'Mute';
+                    mute.addEventListener('click', () => {
+                        input.muted = !input.muted;
+                        mute.className = 'btn btn-sm ' + (input.muted ? 'btn-secondary' : 'btn-outline-secondary');
+                        mixerAction({ id: input.id, muted: input.muted });
+                    });
+                    entry.appendChild(mute);
+                    list.appendChild(entry);
+                });
+            }
+            
+            async function toneAction(body) {
+                try {
+                    const response = await fetch('/api/diagnostics/tone', {
+                        method: 'POST',
+                        headers: { 'Content-Type': 'application/json' },
+                        body: JSON.stringify(body)
+                    });
+                    const data = await response.json();
+                    if (!data.success) {
+                        alert(data.message);
+                        return;
+                    }
+                    document.getElementById('tone-status').textContent = data.active
+                        ? 'Playing ' + data.pattern + ' at ' + data.level + ' dBFS'
+                        : '';
+                } catch (error) {
+                    alert('Tone error: ' + error.message);
+                }
+            }
+            
+            const filtersPanel = document.getElementById('filters-panel');
+            function showFilters(data) {
+                document.getElementById('filters-chain').value = JSON.stringify(data.filters, null, 2);
+                document.getElementById('filters-types').textContent = 'Types: ' + data.types.map(type =>
+                    type.name + ' (' + Object.keys(type.defaults).sort().join(', ') + ')').join(', ');
+            }
+            if (filtersPanel) {
+                filtersPanel.addEventListener('toggle', async function() {
+                    if (!filtersPanel.open) {
+                        return;
+                    }
+                    try {
+                        const response = await fetch('/api/filters');
+                        showFilters(await response.json());
+                    } catch (error) {
+                        console.error('Error loading filters:', error);
+                    }
+                });
+                document.getElementById('filters-apply-btn').addEventListener('click', async function() {
+                    let filters;
+                    try {
+                        filters = JSON.parse(document.getElementById('filters-chain').value || '[]');
+                    } catch (error) {
+                        alert('Filters must be a JSON list: ' + error.message);
+                        return;
+                    }
+                    try {
+                        const response = await fetch('/api/filters', {
+                            method: 'POST',
+                            headers: { 'Content-Type': 'application/json' },
+                            body: JSON.stringify({ filters: filters })
+                        });
+                        const data = await response.json();
+                        if (!data.success) {
+                            alert(data.message);
+                            return;
+                        }
+                        showFilters(data);
+                    } catch (error) {
+                        alert('Filters error: ' + error.message);
+                    }
+                });
+            }
+            
+            if (document.getElementById('tone-panel')) {
+                document.getElementById('tone-start-btn').addEventListener('click', () => toneAction({
+                    action: 'start',
+                    pattern: document.getElementById('tone-pattern').value,
+                    level: parseFloat(document.getElementById('tone-level').value)
+                }));
+                document.getElementById('tone-stop-btn').addEventListener('click', () => toneAction({ action: 'stop' }));
+            }
             
             if (guildSelect) {
                 guildSelect.addEventListener('change', async function() {
@@ -490,24 +1011,28 @@ const indexTemplate = `<!DOCTYPE html>
                         const response = await fetch('/api/connect', {
                             method: 'POST',
                             headers: { 'Content-Type': 'application/json' },
-                            body: JSON.stringify({ guildId, channelId })
+                            body: JSON.stringify({ guildId, channelId, encoder: readEncoderOptions() })
                         });
+                        if (!response.ok) {
+                            throw new Error(await response.text());
+                        }
                         
                         const data = await response.json();
                         if (data.success) {
                             updateDiscordStatus(true);
-                            connectBtn.disabled = true;
                             disconnectBtn.disabled = false;
-                            guildSelect.disabled = true;
-                            channelSelect.disabled = true;
+                            checkStatus();
+                            if (data.warning) {
+                                alert(data.warning);
+                            }
                         } else {
                             throw new Error(data.message || 'Connection failed');
                         }
                     } catch (error) {
                         alert('Connection error: ' + error.message);
-                        connectBtn.disabled = false;
                     } finally {
-                        connectBtn.innerHTML = '<i class="fas fa-plug me-2"></i>Connect';
+                        connectBtn.disabled = false;
+                        connectBtn.innerHTML = connectLabel();
                     }
                 });
             }
@@ -522,10 +1047,9 @@ const indexTemplate = `<!DOCTYPE html>
                         const data = await response.json();
                         if (data.success) {
                             updateDiscordStatus(false);
-                            connectBtn.disabled = false;
+                            connectBtn.disabled = !channelSelect.value;
                             disconnectBtn.disabled = true;
-                            guildSelect.disabled = false;
-                            channelSelect.disabled = false;
+                            renderTargets([]);
                         }
                     } catch (error) {
                         alert('Disconnection error: ' + error.message);
@@ -536,7 +1060,57 @@ const indexTemplate = `<!DOCTYPE html>
                 });
             }
             
+            // Further channels are added alongside the ones already joined
+            function connectLabel() {
+                return discordConnected
+                    ? '<i class="fas fa-plus me-2"></i>Add Channel'
+                    : '<i class="fas fa-plug me-2"></i>Connect';
+            }
+            
+            function renderTargets(targets) {
+                const list = document.getElementById('targets-list');
+                list.innerHTML = '';
+                (targets || []).forEach(target => {
+                    const entry = document.createElement('li');
+                    entry.className = 'list-group-item d-flex align-items-center gap-2';
+                    const name = document.createElement('span');
+                    name.className = 'me-auto';
+                    const guild = guildSelect.querySelector('option[value="' + target.guildId + '"]');
+                    name.textContent = (guild ? guild.textContent : target.guildId) + ' (' + target.sent + ' sent, ' + target.dropped + ' dropped)';
+                    entry.appendChild(name);
+                    
+                    const badge = document.createElement('span');
+                    badge.className = 'badge ' + ({ ok: 'bg-success', stalled: 'bg-warning text-dark', failed: 'bg-danger' }[target.health] || 'bg-secondary');
+                    badge.textContent = target.health;
+                    entry.appendChild(badge);
+                    
+                    const button = document.createElement('button');
+                    button.className = 'btn btn-sm btn-outline-danger';
+                    button.textContent = 'Leave';
+                    button.addEventListener('click', async () => {
+                        button.disabled = true;
+                        try {
+                            const response = await fetch('/api/disconnect', {
+                                method: 'POST',
+                                headers: { 'Content-Type': 'application/json' },
+                                body: JSON.stringify({ guildId: target.id })
+                            });
+                            const data = await response.json();
+                            if (!data.success) {
+                                throw new Error(data.message || 'Disconnection failed');
+                            }
+                        } catch (error) {
+                            alert('Disconnection error: ' + error.message);
+                        }
+                        checkStatus();
+                    });
+                    entry.appendChild(button);
+                    list.appendChild(entry);
+                });
+            }
+            
             function updateDiscordStatus(connected) {
+                discordConnected = connected;
                 const discordStatus = document.getElementById('discord-status');
                 if (discordStatus) {
                     if (connected) {
@@ -562,9 +1136,12 @@ const indexTemplate = `<!DOCTYPE html>
                 }
             }
             
-            function updateStreamingStatus(streaming) {
+            function updateStreamingStatus(streaming, speaking) {
                 if (streamingStatus) {
-                    if (streaming) {
+                    if (streaming && !speaking) {
+                        streamingStatus.className = 'status-indicator idle';
+                        streamingStatus.innerHTML = '<i class="fas fa-circle fa-xs"></i>Silent';
+                    } else if (streaming) {
                         streamingStatus.className = 'status-indicator connected';
                         streamingStatus.innerHTML = '<i class="fas fa-circle fa-xs"></i>Streaming';
                     } else {
@@ -582,18 +1159,78 @@ const indexTemplate = `<!DOCTYPE html>
                     
                     // Update all status indicators
                     updateDiscordStatus(status.discordConnected);
+                    renderTargets(status.targets);
                     updateExtensionStatus(status.wsConnected || status.chromeConnected);
-                    updateStreamingStatus(status.streaming);
+                    updateStreamingStatus(status.streaming, status.speaking);
+                    
+                    if (status.encoder && !encoderLoaded && document.getElementById('opus-bitrate')) {
+                        fillEncoderOptions(status.encoder);
+                        encoderLoaded = true;
+                    }
+                    
+                    if (queuePanel && queuePanel.open) {
+                        loadQueue();
+                    }
+                    
+                    if (document.getElementById('clients-panel')) {
+                        renderClients(status.clients);
+                    }
+                    
+                    if (status.mixer && document.getElementById('mixer-panel')) {
+                        renderMixer(status.mixer);
+                    }
+                    
+                    if (sourceSelect && status.source && document.activeElement !== sourceSelect) {
+                        sourceSelect.value = status.source;
+                    }
+                    
+                    if (volumeSlider && status.volume && document.activeElement !== volumeSlider) {
+                        volumeSlider.value = status.volume.percent;
+                        document.getElementById('volume-value').textContent = status.volume.percent + '%';
+                    }
+                    
+                    if (loudnessEnabled && status.loudness) {
+                        loudnessEnabled.checked = status.loudness.enabled;
+                        if (document.activeElement !== loudnessTarget) {
+                            loudnessTarget.value = status.loudness.target;
+                        }
+                        document.getElementById('loudness-readout').textContent =
+                            'Short-term ' + status.loudness.shortTerm.toFixed(1) + ' LUFS, integrated ' +
+                            status.loudness.integrated.toFixed(1) + ' LUFS' +
+                            (status.loudness.enabled ? ', gain ' + status.loudness.gainDb.toFixed(1) + ' dB' : '');
+                    }
+                    
+                    if (recordBtn && status.recording) {
+                        const recording = status.recording;
+                        recordBtn.dataset.active = recording.active;
+                        recordBtn.textContent = recording.active ? 'Stop Recording' : 'Record';
+                        recordBtn.classList.toggle('btn-danger', recording.active);
+                        recordBtn.classList.toggle('btn-outline-danger', !recording.active);
+                        const minutes = Math.floor(recording.duration / 60);
+                        const seconds = Math.floor(recording.duration % 60).toString().padStart(2, '0');
+                        document.getElementById('record-readout').textContent = recording.path
+                            ? (recording.active ? 'Recording ' : 'Saved ') + minutes + ':' + seconds + ' to ' + recording.path
+                            : '';
+                    }
+                    
+                    const streamListeners = document.getElementById('stream-listeners');
+                    if (streamListeners && status.listeners) {
+                        const count = status.listeners.length;
+                        streamListeners.textContent = count ? ' (' + count + (count === 1 ? ' listener)' : ' listeners)') : '';
+                    }
+                    
+                    const bitrateCurrent = document.getElementById('opus-bitrate-current');
+                    if (bitrateCurrent) {
+                        bitrateCurrent.textContent = status.bitrate
+                            ? 'Encoding at ' + Math.round(status.bitrate.current / 1000) + ' kbps'
+                            : '';
+                    }
                     
                     // Control button states based on Discord connection
-                    if (status.discordConnected) {
-                        connectBtn.disabled = true;
-                        disconnectBtn.disabled = false;
-                        guildSelect.disabled = true;
-                        channelSelect.disabled = true;
-                    } else {
-                        disconnectBtn.disabled = true;
-                        guildSelect.disabled = false;
+                    disconnectBtn.disabled = !status.discordConnected;
+                    guildSelect.disabled = false;
+                    if (!connectBtn.querySelector('.spinner-border')) {
+                        connectBtn.innerHTML = connectLabel();
                     }
                 } catch (error) {
                     console.error('Status check error:', error);
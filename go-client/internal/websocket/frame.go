@@ -0,0 +1,86 @@
+package websocket
+
+import (
+	"encoding/binary"
+	"fmt"
+
+	"trunecord/internal/audio"
+)
+
+// Binary audio frames carry raw PCM, or a single Opus packet when the
+// "opus" encoding was negotiated, with a fixed 16-byte little-endian header:
+//
+//	offset 0  uint8   frame version (BinaryFrameVersion)
+//	offset 1  uint8   sample format (SampleFormatInt16, SampleFormatFloat32, SampleFormatOpus)
+//	offset 2  uint8   channel count (0 = as negotiated in the handshake)
+//	offset 3  uint8   reserved, must be 0
+//	offset 4  uint32  sequence number
+//	offset 8  uint64  capture timestamp in microseconds (client clock)
+//	offset 16 ...     interleaved PCM samples or the Opus packet
+const (
+	BinaryFrameVersion    = 1
+	BinaryFrameHeaderSize = 16
+)
+
+type SampleFormat uint8
+
+const (
+	SampleFormatInt16   SampleFormat = 0
+	SampleFormatFloat32 SampleFormat = 1
+	SampleFormatOpus    SampleFormat = 2
+)
+
+func (f SampleFormat) encoding() (audio.Encoding, error) {
+	switch f {
+	case SampleFormatInt16:
+		return audio.EncodingInt16, nil
+	case SampleFormatFloat32:
+		return audio.EncodingFloat32, nil
+	}
+	return audio.EncodingInt16, fmt.Errorf("unsupported sample format: %d", f)
+}
+
+type BinaryFrame struct {
+	Format    SampleFormat
+	Channels  int
+	Sequence  uint32
+	Timestamp uint64
+	PCM       []byte
+}
+
+// EncodeBinaryFrame serializes a frame into the wire format accepted by the server.
+func EncodeBinaryFrame(frame BinaryFrame) []byte {
+	data := make([]byte, BinaryFrameHeaderSize+len(frame.PCM))
+	data[0] = BinaryFrameVersion
+	data[1] = byte(frame.Format)
+	data[2] = byte(frame.Channels)
+	binary.LittleEndian.PutUint32(data[4:], frame.Sequence)
+	binary.LittleEndian.PutUint64(data[8:], frame.Timestamp)
+	copy(data[BinaryFrameHeaderSize:], frame.PCM)
+	return data
+}
+
+// DecodeBinaryFrame parses a binary WebSocket message. The returned PCM slice
+// aliases data.
+func DecodeBinaryFrame(data []byte) (*BinaryFrame, error) {
+	if len(data) < BinaryFrameHeaderSize {
+		return nil, fmt.Errorf("binary frame too short: %d bytes", len(data))
+	}
+
+	if data[0] != BinaryFrameVersion {
+		return nil, fmt.Errorf("unsupported binary frame version: %d", data[0])
+	}
+
+	// Kept zero so the byte can be given a meaning later
+	if data[3] != 0 {
+		return nil, fmt.Errorf("reserved header byte is %d, want 0", data[3])
+	}
+
+	return &BinaryFrame{
+		Format:    SampleFormat(data[1]),
+		Channels:  int(data[2]),
+		Sequence:  binary.LittleEndian.Uint32(data[4:]),
+		Timestamp: binary.LittleEndian.Uint64(data[8:]),
+		PCM:       data[BinaryFrameHeaderSize:],
+	}, nil
+}
@@ -0,0 +1,52 @@
+package websocket
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBinaryFrameRoundTrip(t *testing.T) {
+	frame := BinaryFrame{
+		Format:    SampleFormatInt16,
+		Channels:  2,
+		Sequence:  42,
+		Timestamp: 1234567890,
+		PCM:       []byte{1, 2, 3, 4},
+	}
+
+	data := EncodeBinaryFrame(frame)
+	if len(data) != BinaryFrameHeaderSize+len(frame.PCM) {
+		t.Fatalf("encoded length = %d, want %d", len(data), BinaryFrameHeaderSize+len(frame.PCM))
+	}
+
+	decoded, err := DecodeBinaryFrame(data)
+	if err != nil {
+		t.Fatalf("DecodeBinaryFrame() error = %v", err)
+	}
+
+	if decoded.Format != frame.Format || decoded.Channels != frame.Channels ||
+		decoded.Sequence != frame.Sequence || decoded.Timestamp != frame.Timestamp {
+		t.Errorf("decoded header = %+v, want %+v", decoded, frame)
+	}
+	if !bytes.Equal(decoded.PCM, frame.PCM) {
+		t.Errorf("decoded PCM = %v, want %v", decoded.PCM, frame.PCM)
+	}
+}
+
+func TestDecodeBinaryFrameErrors(t *testing.T) {
+	if _, err := DecodeBinaryFrame([]byte{1, 0, 0}); err == nil {
+		t.Error("DecodeBinaryFrame() should reject short frames")
+	}
+
+	data := EncodeBinaryFrame(BinaryFrame{})
+	data[0] = 99
+	if _, err := DecodeBinaryFrame(data); err == nil {
+		t.Error("DecodeBinaryFrame() should reject unknown versions")
+	}
+
+	data = EncodeBinaryFrame(BinaryFrame{})
+	data[3] = 1
+	if _, err := DecodeBinaryFrame(data); err == nil {
+		t.Error("DecodeBinaryFrame() should reject a non-zero reserved byte")
+	}
+}
@@ -0,0 +1,45 @@
+package websocket
+
+const (
+	// maxConcealedChunks limits concealment to ~100ms of 10ms chunks; longer
+	// gaps are left to the streamer's jitter buffer.
+	maxConcealedChunks = 10
+	// sequenceResyncWindow treats jumps larger than this as a client restart
+	// rather than loss or reordering.
+	sequenceResyncWindow = 1000
+)
+
+// sequenceTracker detects gaps and late arrivals in a client's audio
+// sequence numbers. Sequence numbers wrap around at 2^32.
+type sequenceTracker struct {
+	started bool
+	next    uint32
+}
+
+// check classifies seq. It returns how many chunks are missing before seq,
+// and whether seq arrived after the stream had already moved past it.
+func (t *sequenceTracker) check(seq uint32) (missing uint32, late bool) {
+	if !t.started {
+		t.started = true
+		t.next = seq + 1
+		return 0, false
+	}
+
+	diff := int32(seq - t.next)
+	switch {
+	case diff == 0:
+	case diff > 0 && diff <= sequenceResyncWindow:
+		missing = uint32(diff)
+	case diff < 0 && diff >= -sequenceResyncWindow:
+		return 0, true
+	default:
+		// Client restarted its counter; pick up from here
+	}
+
+	t.next = seq + 1
+	return missing, false
+}
+
+func (t *sequenceTracker) reset() {
+	t.started = false
+}
@@ -0,0 +1,44 @@
+package websocket
+
+import "testing"
+
+func TestSequenceTracker(t *testing.T) {
+	tests := []struct {
+		name        string
+		sequence    []uint32
+		wantMissing uint32
+		wantLate    bool
+	}{
+		{name: "in order", sequence: []uint32{1, 2, 3}},
+		{name: "gap", sequence: []uint32{1, 2, 5}, wantMissing: 2},
+		{name: "late", sequence: []uint32{1, 3, 2}, wantLate: true},
+		{name: "duplicate", sequence: []uint32{1, 2, 2}, wantLate: true},
+		{name: "wraparound", sequence: []uint32{0xfffffffe, 0xffffffff, 0, 1}},
+		{name: "gap across wraparound", sequence: []uint32{0xffffffff, 1}, wantMissing: 1},
+		{name: "resync after restart", sequence: []uint32{50000, 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var tracker sequenceTracker
+			var missing uint32
+			var late bool
+			for _, seq := range tt.sequence {
+				missing, late = tracker.check(seq)
+			}
+			if missing != tt.wantMissing || late != tt.wantLate {
+				t.Errorf("check() = (%d, %v), want (%d, %v)", missing, late, tt.wantMissing, tt.wantLate)
+			}
+		})
+	}
+}
+
+func TestSequenceTrackerReset(t *testing.T) {
+	var tracker sequenceTracker
+	tracker.check(100)
+	tracker.reset()
+
+	if missing, late := tracker.check(5); missing != 0 || late {
+		t.Errorf("check() after reset = (%d, %v), want a fresh start", missing, late)
+	}
+}
@@ -2,33 +2,82 @@ package websocket
 
 import (
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
 	"log"
 	"net"
 	"net/http"
+	"sort"
+	"strconv"
 	"sync"
 	"time"
 
 	"github.com/gorilla/websocket"
+	"trunecord/internal/audio"
 	"trunecord/internal/constants"
 )
 
 type Server struct {
 	upgrader         websocket.Upgrader
 	audioBuffer      chan []byte
-	clients          map[*websocket.Conn]bool
+	opusBuffer       chan []byte
+	clients          map[*websocket.Conn]*clientState
+	live             *clientState
+	mixClients       bool
 	isStreaming      bool
 	streamingMutex   sync.RWMutex
 	lastAudioTime    time.Time
 	timeoutTimer     *time.Timer
 	timeoutTimerLock sync.Mutex
 	clientMutex      sync.RWMutex
+	outputChannels   int
+	sourceStopped    bool
+	paused           bool
+	onPause          func(paused bool)
+	pauseMutex       sync.Mutex // keeps pause notifications in order
+	ingestStats      audio.IngestStats
+	statsMutex       sync.Mutex
+	mixer            *audio.Mixer
+	nextClientID     int
 }
 
 type Message struct {
-	Type    string `json:"type"`
-	Audio   string `json:"audio,omitempty"`
-	Version string `json:"version,omitempty"`
+	Type         string  `json:"type"`
+	Audio        string  `json:"audio,omitempty"`
+	Version      string  `json:"version,omitempty"`
+	Channels     int     `json:"channels,omitempty"`
+	SampleRate   int     `json:"sampleRate,omitempty"`
+	SampleFormat string  `json:"sampleFormat,omitempty"`
+	Encoding     string  `json:"encoding,omitempty"`
+	Sequence     *uint32 `json:"sequence,omitempty"`
+}
+
+// HandshakeAck confirms the encoding and audio format the server will
+// expect from a client that declared them in its handshake.
+type HandshakeAck struct {
+	Type         string `json:"type"`
+	Encoding     string `json:"encoding"`
+	SampleRate   int    `json:"sampleRate"`
+	Channels     int    `json:"channels"`
+	SampleFormat string `json:"sampleFormat"`
+}
+
+// SourceBusy tells a client its audio is being discarded because another
+// client is live.
+type SourceBusy struct {
+	Type       string `json:"type"`
+	LiveClient string `json:"liveClient"`
+	Message    string `json:"message"`
+}
+
+// ClientInfo describes a connected extension client.
+type ClientInfo struct {
+	ID          string    `json:"id"`
+	Version     string    `json:"version,omitempty"`
+	Encoding    string    `json:"encoding"`
+	ConnectedAt time.Time `json:"connectedAt"`
+	Live        bool      `json:"live"`
+	Waiting     bool      `json:"waiting"`
 }
 
 type StatusResponse struct {
@@ -46,9 +95,58 @@ func NewServer() *Server {
 			ReadBufferSize:  1024,
 			WriteBufferSize: 1024,
 		},
-		audioBuffer: make(chan []byte, 100), // Reduce buffer size for lower latency
-		clients:     make(map[*websocket.Conn]bool),
+		audioBuffer:    make(chan []byte, 100), // Reduce buffer size for lower latency
+		opusBuffer:     make(chan []byte, constants.AudioBufferSize),
+		clients:        make(map[*websocket.Conn]*clientState),
+		outputChannels: constants.DefaultChannels,
+		sourceStopped:  true, // until selected as the audio source
+		mixer:          audio.NewMixer(constants.DefaultChannels),
+	}
+}
+
+// SetOutputChannels sets the channel count of the PCM delivered on the audio
+// channel. Client audio is up- or down-mixed to match.
+func (s *Server) SetOutputChannels(channels int) error {
+	if !audio.ValidChannels(channels) {
+		return fmt.Errorf("unsupported channel count: %d", channels)
 	}
+	s.streamingMutex.Lock()
+	s.outputChannels = channels
+	s.streamingMutex.Unlock()
+	s.mixer.SetChannels(channels)
+	return nil
+}
+
+// SetJitterConfig keeps a mixed client in the mix for as long as the jitter
+// buffer would wait for its audio.
+func (s *Server) SetJitterConfig(config audio.JitterConfig) error {
+	if err := config.Validate(); err != nil {
+		return fmt.Errorf("invalid jitter buffer configuration: %v", err)
+	}
+	return s.mixer.SetIdleTimeout(config.Max)
+}
+
+// SetPauseListener sets a function called when the player feeding the
+// extension source pauses or resumes, so the stream can fade out and stop
+// speaking instead of waiting for the audio to run dry.
+func (s *Server) SetPauseListener(listener func(paused bool)) {
+	s.streamingMutex.Lock()
+	defer s.streamingMutex.Unlock()
+	s.onPause = listener
+}
+
+// SetMixClients chooses between mixing every client that sends audio and
+// playing a single live client at a time, which is the default.
+func (s *Server) SetMixClients(enabled bool) {
+	s.clientMutex.Lock()
+	defer s.clientMutex.Unlock()
+	s.mixClients = enabled
+}
+
+func (s *Server) getOutputChannels() int {
+	s.streamingMutex.RLock()
+	defer s.streamingMutex.RUnlock()
+	return s.outputChannels
 }
 
 func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
@@ -59,12 +157,26 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	}
 	defer conn.Close()
 
+	// Older extensions never declare a format or encoding and always send
+	// 48kHz mono 16-bit PCM as base64 JSON
+	client := &clientState{
+		conn:        conn,
+		connectedAt: time.Now(),
+		format:      audio.Format{SampleRate: constants.SampleRate, Channels: constants.MonoChannels, Encoding: audio.EncodingInt16},
+		encoding:    constants.AudioEncodingJSON,
+	}
+
 	// Add client
 	s.clientMutex.Lock()
-	s.clients[conn] = true
+	s.nextClientID++
+	client.id = strconv.Itoa(s.nextClientID)
+	s.clients[conn] = client
 	s.clientMutex.Unlock()
+	s.mixer.Add(client.id)
 
 	defer func() {
+		s.release(client)
+		s.mixer.Remove(client.id)
 		s.clientMutex.Lock()
 		delete(s.clients, conn)
 		// If no clients are connected, stop streaming
@@ -78,14 +190,13 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 	handshakeRequest := map[string]string{
 		"type": constants.MessageTypeHandshake,
 	}
-	if err := conn.WriteJSON(handshakeRequest); err != nil {
+	if err := client.send(handshakeRequest); err != nil {
 		log.Printf("Failed to send handshake request: %v", err)
 		return
 	}
 
 	for {
-		var msg Message
-		err := conn.ReadJSON(&msg)
+		messageType, data, err := conn.ReadMessage()
 		if err != nil {
 			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseAbnormalClosure) {
 				log.Printf("WebSocket error: %v", err)
@@ -93,10 +204,64 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			break
 		}
 
+		if messageType == websocket.BinaryMessage {
+			s.handleBinaryAudio(client, data)
+			continue
+		}
+
+		var msg Message
+		if err := json.Unmarshal(data, &msg); err != nil {
+			log.Printf("Failed to parse WebSocket message: %v", err)
+			continue
+		}
+
 		switch msg.Type {
 		case constants.MessageTypeHandshake:
+			// A new handshake starts a new sequence
+			client.sequence.reset()
+			client.lastChunk = nil
+
+			negotiated := false
+			if msg.declaresFormat() {
+				format, err := overrideFormat(client.format, &msg)
+				if err != nil {
+					log.Printf("Ignoring unsupported audio format from extension: %v", err)
+				} else {
+					client.format = format
+					log.Printf("Extension audio format: %s", format)
+				}
+				negotiated = true
+			}
+
+			// Only newer extensions declare an encoding; they wait for the
+			// acknowledgement before switching to binary frames
+			if msg.Encoding != "" {
+				s.clientMutex.Lock()
+				client.encoding = negotiateEncoding(msg.Encoding, s.mixClients)
+				s.clientMutex.Unlock()
+				log.Printf("Extension audio encoding: %s", client.encoding)
+				negotiated = true
+			}
+
+			if negotiated {
+				ack := HandshakeAck{
+					Type:         constants.MessageTypeHandshakeAck,
+					Encoding:     client.encoding,
+					SampleRate:   client.format.SampleRate,
+					Channels:     client.format.Channels,
+					SampleFormat: client.format.Encoding.String(),
+				}
+				if err := client.send(ack); err != nil {
+					log.Printf("Failed to send handshake acknowledgement: %v", err)
+				}
+			}
+
 			// Check extension version
 			if msg.Version != "" {
+				s.clientMutex.Lock()
+				client.version = msg.Version
+				s.clientMutex.Unlock()
+
 				if msg.Version != constants.ExpectedExtensionVersion {
 					warningMsg := fmt.Sprintf("⚠️ Chrome extension version mismatch\nExpected: v%s\nActual: v%s\n\nPlease update the extension.",
 						constants.ExpectedExtensionVersion, msg.Version)
@@ -109,7 +274,7 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 						"expectedVersion": constants.ExpectedExtensionVersion,
 						"actualVersion":   msg.Version,
 					}
-					if err := conn.WriteJSON(warningResponse); err != nil {
+					if err := client.send(warningResponse); err != nil {
 						log.Printf("Failed to send version warning: %v", err)
 					}
 				} else {
@@ -118,10 +283,6 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 			}
 		case constants.MessageTypeAudio:
 			if msg.Audio != "" {
-				// Mark as streaming when we receive audio data
-				s.setStreaming(true)
-				s.resetStreamingTimeout()
-
 				// Decode base64 audio and send to audio buffer
 				audioData, err := base64.StdEncoding.DecodeString(msg.Audio)
 				if err != nil {
@@ -129,19 +290,15 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 					continue
 				}
 
-				select {
-				case s.audioBuffer <- audioData:
-					// Audio queued successfully
-				default:
-					// Buffer full, drop oldest chunk to prevent latency
-					select {
-					case <-s.audioBuffer:
-						// Dropped oldest chunk
-						s.audioBuffer <- audioData
-					default:
-						// Still can't add, skip
+				format := client.format
+				if msg.declaresFormat() {
+					format, err = overrideFormat(client.format, &msg)
+					if err != nil {
+						log.Printf("Dropping audio in unsupported format: %v", err)
+						continue
 					}
 				}
+				s.queueAudio(client, audioData, format, msg.Sequence)
 			}
 
 		case constants.MessageTypeStatus:
@@ -151,33 +308,511 @@ func (s *Server) HandleWebSocket(w http.ResponseWriter, r *http.Request) {
 				Connected: true, // TODO: Get actual Discord connection status
 				Streaming: s.IsStreaming(),
 			}
-			if err := conn.WriteJSON(status); err != nil {
+			if err := client.send(status); err != nil {
 				log.Printf("Failed to send status: %v", err)
 			}
 
 		case constants.MessageTypeStreamStart:
 			s.setStreaming(true)
+			if s.controlsPlayback(client) {
+				s.setPaused(false)
+			}
 			log.Println("Received stream start notification from Chrome extension")
 
 		case constants.MessageTypeStreamStop:
 			s.setStreaming(false)
+			s.release(client)
 			log.Println("Received stream stop notification from Chrome extension")
 
 		case constants.MessageTypeStreamPause:
 			s.setStreaming(false)
+			if s.controlsPlayback(client) {
+				s.setPaused(true)
+			}
 			log.Println("YouTube Music paused - streaming paused")
 
 		case constants.MessageTypeStreamResume:
 			s.setStreaming(true)
+			if s.controlsPlayback(client) {
+				s.setPaused(false)
+			}
 			log.Println("YouTube Music resumed - streaming resumed")
+
+		case constants.MessageTypeTakeover:
+			if err := s.TakeOver(client.id); err != nil {
+				log.Printf("Takeover by client %s failed: %v", client.id, err)
+			}
+		}
+	}
+}
+
+// clientState tracks what a single extension connection negotiated. Fields
+// other goroutines read are written under the server's clientMutex.
+type clientState struct {
+	id          string
+	conn        *websocket.Conn
+	writeMutex  sync.Mutex
+	connectedAt time.Time
+	version     string
+	format      audio.Format
+	encoding    string
+	converter   *audio.Converter
+	sequence    sequenceTracker
+	lastChunk   []byte
+	lastAudio   time.Time
+	waiting     bool
+}
+
+// send writes a JSON message. Takeovers notify clients from other
+// goroutines, so writes are serialized.
+func (c *clientState) send(v interface{}) error {
+	c.writeMutex.Lock()
+	defer c.writeMutex.Unlock()
+	return c.conn.WriteJSON(v)
+}
+
+// claim reports whether a client's audio should be played, making it the
+// live client when no other client is. A live client that has not sent
+// audio for LiveClientIdleTimeout gives way, so a paused tab does not block
+// the others.
+func (s *Server) claim(client *clientState) bool {
+	now := time.Now()
+	s.clientMutex.Lock()
+	if s.mixClients || s.live == client {
+		client.lastAudio = now
+		s.clientMutex.Unlock()
+		return true
+	}
+
+	live := s.live
+	if live != nil && now.Sub(live.lastAudio) > constants.LiveClientIdleTimeout {
+		log.Printf("Live client %s went idle; client %s takes over", live.id, client.id)
+		live = nil
+	}
+	if live == nil {
+		s.live = client
+		client.lastAudio = now
+		client.waiting = false
+		s.clientMutex.Unlock()
+
+		log.Printf("Client %s is now the live audio source", client.id)
+		s.notifyActive(client)
+		return true
+	}
+
+	notify := !client.waiting
+	client.waiting = true
+	s.clientMutex.Unlock()
+
+	if notify {
+		log.Printf("Client %s is waiting: client %s is live", client.id, live.id)
+		s.notifyBusy(client, live)
+	}
+	return false
+}
+
+// release gives up the live slot if client holds it, ending its pause. The
+// next client to send audio becomes live.
+func (s *Server) release(client *clientState) {
+	s.clientMutex.Lock()
+	released := s.live == client
+	if released {
+		s.live = nil
+		log.Printf("Client %s is no longer the live audio source", client.id)
+	}
+	s.clientMutex.Unlock()
+
+	if released {
+		s.setPaused(false)
+	}
+}
+
+// controlsPlayback reports whether a client's pause and resume messages
+// apply to the stream: those of the live client, or of any client while
+// none is live. When mixing, only a client mixed on its own controls it.
+func (s *Server) controlsPlayback(client *clientState) bool {
+	s.clientMutex.RLock()
+	defer s.clientMutex.RUnlock()
+	if s.mixClients {
+		return len(s.clients) == 1
+	}
+	return s.live == nil || s.live == client
+}
+
+// setPaused tells the pause listener that playback paused or resumed.
+// Pauses only count while the extension is the audio source.
+func (s *Server) setPaused(paused bool) {
+	s.pauseMutex.Lock()
+	defer s.pauseMutex.Unlock()
+
+	s.streamingMutex.Lock()
+	if s.paused == paused || (paused && s.sourceStopped) {
+		s.streamingMutex.Unlock()
+		return
+	}
+	s.paused = paused
+	listener := s.onPause
+	s.streamingMutex.Unlock()
+
+	if listener != nil {
+		listener(paused)
+	}
+}
+
+// TakeOver makes a client live immediately, sending the client it replaces
+// a sourceBusy message.
+func (s *Server) TakeOver(id string) error {
+	s.clientMutex.Lock()
+	var client *clientState
+	for _, c := range s.clients {
+		if c.id == id {
+			client = c
 		}
 	}
+	if client == nil {
+		s.clientMutex.Unlock()
+		return fmt.Errorf("unknown client: %s", id)
+	}
+	previous := s.live
+	s.live = client
+	client.lastAudio = time.Now()
+	client.waiting = false
+	if previous != nil && previous != client {
+		previous.waiting = true
+	}
+	s.clientMutex.Unlock()
+
+	if previous == client {
+		return nil
+	}
+	log.Printf("Client %s took over as the live audio source", id)
+	s.setPaused(false)
+	s.notifyActive(client)
+	if previous != nil {
+		// Do not hold the new client's audio back waiting for the old one
+		s.mixer.Pause(previous.id)
+		s.notifyBusy(previous, client)
+	}
+	return nil
+}
+
+func (s *Server) notifyActive(client *clientState) {
+	message := map[string]string{
+		"type": constants.MessageTypeSourceActive,
+	}
+	if err := client.send(message); err != nil {
+		log.Printf("Failed to send source active message: %v", err)
+	}
+}
+
+func (s *Server) notifyBusy(client, live *clientState) {
+	message := SourceBusy{
+		Type:       constants.MessageTypeSourceBusy,
+		LiveClient: live.id,
+		Message:    fmt.Sprintf("Another tab (client %s) is streaming. Take over to stream from this tab instead.", live.id),
+	}
+	if err := client.send(message); err != nil {
+		log.Printf("Failed to send source busy message: %v", err)
+	}
+}
+
+// GetClients lists the connected clients in the order they connected.
+func (s *Server) GetClients() []ClientInfo {
+	s.clientMutex.RLock()
+	defer s.clientMutex.RUnlock()
+
+	now := time.Now()
+	clients := make([]ClientInfo, 0, len(s.clients))
+	for _, client := range s.clients {
+		live := client == s.live
+		if s.mixClients {
+			live = !client.lastAudio.IsZero() && now.Sub(client.lastAudio) <= constants.LiveClientIdleTimeout
+		}
+		clients = append(clients, ClientInfo{
+			ID:          client.id,
+			Version:     client.version,
+			Encoding:    client.encoding,
+			ConnectedAt: client.connectedAt,
+			Live:        live,
+			Waiting:     client.waiting && !live,
+		})
+	}
+	sort.Slice(clients, func(i, j int) bool {
+		return clients[i].ConnectedAt.Before(clients[j].ConnectedAt)
+	})
+	return clients
+}
+
+// negotiateEncoding picks the encoding to acknowledge. Opus packets cannot
+// be mixed, so while mixing Opus clients are told to send binary PCM.
+func negotiateEncoding(requested string, mixing bool) string {
+	switch requested {
+	case constants.AudioEncodingOpus:
+		if mixing {
+			return constants.AudioEncodingBinary
+		}
+		return requested
+	case constants.AudioEncodingBinary:
+		return requested
+	}
+	return constants.AudioEncodingJSON
+}
+
+func (m *Message) declaresFormat() bool {
+	return m.SampleRate != 0 || m.Channels != 0 || m.SampleFormat != ""
+}
+
+// overrideFormat applies the format fields a message declares on top of the
+// format negotiated for the connection.
+func overrideFormat(base audio.Format, msg *Message) (audio.Format, error) {
+	format := base
+	if msg.SampleRate != 0 {
+		format.SampleRate = msg.SampleRate
+	}
+	if msg.Channels != 0 {
+		format.Channels = msg.Channels
+	}
+	if msg.SampleFormat != "" {
+		encoding, err := audio.ParseEncoding(msg.SampleFormat)
+		if err != nil {
+			return base, err
+		}
+		format.Encoding = encoding
+	}
+	if err := format.Validate(); err != nil {
+		return base, err
+	}
+	return format, nil
+}
+
+func (s *Server) handleBinaryAudio(client *clientState, data []byte) {
+	if client.encoding == constants.AudioEncodingJSON {
+		log.Printf("Dropping binary audio frame: binary encoding was not negotiated")
+		return
+	}
+
+	frame, err := DecodeBinaryFrame(data)
+	if err != nil {
+		log.Printf("Failed to decode binary audio frame: %v", err)
+		return
+	}
+
+	if len(frame.PCM) == 0 {
+		return
+	}
+
+	if frame.Format == SampleFormatOpus {
+		if client.encoding != constants.AudioEncodingOpus {
+			log.Printf("Dropping Opus frame: opus encoding was not negotiated")
+			return
+		}
+		packet := make([]byte, len(frame.PCM))
+		copy(packet, frame.PCM)
+		s.queueOpus(client, packet, frame.Sequence)
+		return
+	}
+
+	encoding, err := frame.Format.encoding()
+	if err != nil {
+		log.Printf("Dropping binary audio frame: %v", err)
+		return
+	}
+
+	format := client.format
+	format.Encoding = encoding
+	if frame.Channels != 0 {
+		format.Channels = frame.Channels
+	}
+	if err := format.Validate(); err != nil {
+		log.Printf("Dropping binary audio frame: %v", err)
+		return
+	}
+
+	// The frame aliases the read buffer, so keep our own copy
+	pcm := make([]byte, len(frame.PCM))
+	copy(pcm, frame.PCM)
+	s.queueAudio(client, pcm, format, &frame.Sequence)
+}
+
+// queueAudio converts client PCM to the pipeline format and hands it to the
+// streamer. Chunks carrying a sequence number are checked for gaps, which are
+// concealed, and for late arrivals, which are dropped.
+func (s *Server) queueAudio(client *clientState, audioData []byte, format audio.Format, sequence *uint32) {
+	if !s.claim(client) {
+		// Start afresh once live instead of concealing what was discarded
+		client.sequence.reset()
+		client.lastChunk = nil
+		return
+	}
+
+	// Mark as streaming when we receive audio data
+	s.setStreaming(true)
+	s.resetStreamingTimeout()
+
+	var missing uint32
+	if sequence != nil {
+		var late bool
+		missing, late = client.sequence.check(*sequence)
+		if late {
+			s.updateIngestStats(func(stats *audio.IngestStats) { stats.Late++ })
+			return
+		}
+	}
+
+	output := audio.PipelineFormat(s.getOutputChannels())
+	if client.converter == nil || client.converter.Input() != format || client.converter.Output() != output {
+		converter, err := audio.NewConverter(format, output)
+		if err != nil {
+			log.Printf("Failed to set up audio conversion: %v", err)
+			return
+		}
+		if format != output {
+			log.Printf("Converting extension audio from %s to %s", format, output)
+		}
+		client.converter = converter
+		client.lastChunk = nil
+	}
+
+	audioData, err := client.converter.Convert(audioData)
+	if err != nil {
+		log.Printf("Failed to convert audio data: %v", err)
+		return
+	}
+	if len(audioData) == 0 {
+		return
+	}
+
+	concealed := 0
+	if missing > 0 && client.lastChunk != nil {
+		count := int(missing)
+		if count > maxConcealedChunks {
+			count = maxConcealedChunks
+		}
+		for _, chunk := range audio.ConcealLoss(client.lastChunk, output.Channels, count) {
+			s.pushAudio(client, chunk)
+		}
+		concealed = count
+	}
+
+	s.updateIngestStats(func(stats *audio.IngestStats) {
+		stats.Received++
+		stats.Lost += uint64(missing)
+		stats.Concealed += uint64(concealed)
+	})
+	client.lastChunk = audioData
+	s.pushAudio(client, audioData)
+}
+
+// queueOpus hands an already encoded packet straight to the streamer. Lost
+// packets are only counted; Discord clients conceal them when decoding.
+func (s *Server) queueOpus(client *clientState, packet []byte, sequence uint32) {
+	s.clientMutex.RLock()
+	mixing := s.mixClients
+	s.clientMutex.RUnlock()
+	if mixing {
+		return
+	}
+
+	if !s.claim(client) {
+		client.sequence.reset()
+		return
+	}
+
+	s.setStreaming(true)
+	s.resetStreamingTimeout()
+
+	missing, late := client.sequence.check(sequence)
+	if late {
+		s.updateIngestStats(func(stats *audio.IngestStats) { stats.Late++ })
+		return
+	}
+
+	s.updateIngestStats(func(stats *audio.IngestStats) {
+		stats.Received++
+		stats.Lost += uint64(missing)
+	})
+	if s.isSourceStopped() {
+		return
+	}
+	pushLatest(s.opusBuffer, packet)
+}
+
+// pushAudio mixes a client's PCM with the other clients' and hands the
+// result to the streamer, dropping the oldest chunk when the buffer is full.
+func (s *Server) pushAudio(client *clientState, audioData []byte) {
+	if s.isSourceStopped() {
+		return
+	}
+	if mixed := s.mixer.Push(client.id, audioData, time.Now()); mixed != nil {
+		pushLatest(s.audioBuffer, mixed)
+	}
+}
+
+func (s *Server) isSourceStopped() bool {
+	s.streamingMutex.RLock()
+	defer s.streamingMutex.RUnlock()
+	return s.sourceStopped
+}
+
+func pushLatest(buffer chan []byte, data []byte) {
+	select {
+	case buffer <- data:
+		// Audio queued successfully
+	default:
+		// Buffer full, drop oldest chunk to prevent latency
+		select {
+		case <-buffer:
+			// Dropped oldest chunk
+			buffer <- data
+		default:
+			// Still can't add, skip
+		}
+	}
+}
+
+func (s *Server) updateIngestStats(update func(stats *audio.IngestStats)) {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	update(&s.ingestStats)
+}
+
+// GetIngestStats reports received, lost, late and concealed audio chunks
+// across all clients.
+func (s *Server) GetIngestStats() audio.IngestStats {
+	s.statsMutex.Lock()
+	defer s.statsMutex.Unlock()
+	return s.ingestStats
+}
+
+// GetMixerInputs lists the connected clients as mixer inputs.
+func (s *Server) GetMixerInputs() []audio.MixerInput {
+	return s.mixer.Inputs()
+}
+
+// SetInputGain sets the linear gain applied to one client's audio.
+func (s *Server) SetInputGain(id string, gain float64) error {
+	return s.mixer.SetGain(id, gain)
+}
+
+func (s *Server) SetInputMuted(id string, muted bool) error {
+	return s.mixer.SetMuted(id, muted)
+}
+
+// GetMixerClipped counts samples the mixer had to limit.
+func (s *Server) GetMixerClipped() uint64 {
+	return s.mixer.Clipped()
 }
 
 func (s *Server) GetAudioChannel() <-chan []byte {
 	return s.audioBuffer
 }
 
+// GetOpusChannel delivers Opus packets from clients that negotiated the
+// "opus" encoding, for forwarding without re-encoding.
+func (s *Server) GetOpusChannel() <-chan []byte {
+	return s.opusBuffer
+}
+
 func (s *Server) Start(port string) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/", s.HandleWebSocket)
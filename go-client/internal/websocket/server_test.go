@@ -9,8 +9,27 @@ import (
 	"time"
 
 	"github.com/gorilla/websocket"
+	"trunecord/internal/audio"
 )
 
+// stereo is what the server makes of a mono PCM chunk: every sample in both
+// channels of the default stereo output.
+func stereo(mono []byte) []byte {
+	out := make([]byte, 0, len(mono)*2)
+	for i := 0; i+1 < len(mono); i += 2 {
+		out = append(out, mono[i], mono[i+1], mono[i], mono[i+1])
+	}
+	return out
+}
+
+// newStartedServer returns a server selected as the audio source, as it is
+// on startup with the default source.
+func newStartedServer() *Server {
+	server := NewServer()
+	server.Source().Start()
+	return server
+}
+
 func TestNewServer(t *testing.T) {
 	server := NewServer()
 
@@ -29,7 +48,7 @@ func TestNewServer(t *testing.T) {
 }
 
 func TestServer_GetAudioChannel(t *testing.T) {
-	server := NewServer()
+	server := newStartedServer()
 
 	audioChannel := server.GetAudioChannel()
 	if audioChannel == nil {
@@ -41,7 +60,7 @@ func TestServer_GetAudioChannel(t *testing.T) {
 }
 
 func TestServer_Start(t *testing.T) {
-	server := NewServer()
+	server := newStartedServer()
 	port := "18765" // Use a different port to avoid conflicts
 
 	// Start server in a goroutine
@@ -69,7 +88,7 @@ func TestServer_Start(t *testing.T) {
 	}
 
 	// Test sending an audio message (must be base64 encoded in JSON)
-	testAudioData := []byte("test audio data")
+	testAudioData := []byte("test audio data!")
 	testMessage := Message{
 		Type:  "audio",
 		Audio: base64.StdEncoding.EncodeToString(testAudioData),
@@ -82,8 +101,8 @@ func TestServer_Start(t *testing.T) {
 	// Verify the message was received through the audio channel
 	select {
 	case received := <-server.GetAudioChannel():
-		if string(received) != string(testAudioData) {
-			t.Errorf("Received data = %v, want %v", received, testAudioData)
+		if want := stereo(testAudioData); string(received) != string(want) {
+			t.Errorf("Received data = %v, want %v", received, want)
 		}
 	case <-time.After(1 * time.Second):
 		t.Error("Did not receive audio data from channel")
@@ -91,7 +110,7 @@ func TestServer_Start(t *testing.T) {
 }
 
 func TestServer_HandleWebSocket(t *testing.T) {
-	server := NewServer()
+	server := newStartedServer()
 
 	// Create a test WebSocket server
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -139,7 +158,7 @@ func TestServer_HandleWebSocket(t *testing.T) {
 }
 
 func TestServer_MultipleClients(t *testing.T) {
-	server := NewServer()
+	server := newStartedServer()
 
 	// Create a test WebSocket server
 	testServer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -179,3 +198,567 @@ func TestServer_MultipleClients(t *testing.T) {
 		conn.Close()
 	}
 }
+
+func TestServer_UpmixesMonoToStereo(t *testing.T) {
+	server := newStartedServer()
+	if err := server.SetOutputChannels(2); err != nil {
+		t.Fatalf("SetOutputChannels(2) returned error: %v", err)
+	}
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	// Legacy clients do not declare channels and send mono samples
+	mono := []byte{0x01, 0x00, 0x02, 0x00}
+	if err := conn.WriteJSON(Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(mono)}); err != nil {
+		t.Fatalf("Failed to send audio: %v", err)
+	}
+
+	want := []byte{0x01, 0x00, 0x01, 0x00, 0x02, 0x00, 0x02, 0x00}
+	select {
+	case received := <-server.GetAudioChannel():
+		if string(received) != string(want) {
+			t.Errorf("Received data = %v, want %v", received, want)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive audio data from channel")
+	}
+}
+
+func TestServer_SetOutputChannelsRejectsInvalid(t *testing.T) {
+	server := newStartedServer()
+	if err := server.SetOutputChannels(3); err == nil {
+		t.Error("SetOutputChannels(3) should return error")
+	}
+}
+
+func TestServer_BinaryAudioFrames(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	// Binary frames are only understood once negotiated
+	early := EncodeBinaryFrame(BinaryFrame{Format: SampleFormatInt16, Sequence: 1, PCM: []byte{0x01, 0x00, 0x02, 0x00}})
+	if err := conn.WriteMessage(websocket.BinaryMessage, early); err != nil {
+		t.Fatalf("Failed to send binary frame: %v", err)
+	}
+
+	if err := conn.WriteJSON(Message{Type: "handshake", Encoding: "binary"}); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	var ack HandshakeAck
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("Failed to read handshake ack: %v", err)
+	}
+	if ack.Type != "handshakeAck" || ack.Encoding != "binary" {
+		t.Fatalf("handshake ack = %+v, want binary encoding", ack)
+	}
+
+	pcm := []byte{0x10, 0x00, 0x20, 0x00}
+	frame := EncodeBinaryFrame(BinaryFrame{Format: SampleFormatInt16, Sequence: 2, PCM: pcm})
+	if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+		t.Fatalf("Failed to send binary frame: %v", err)
+	}
+
+	select {
+	case received := <-server.GetAudioChannel():
+		if want := stereo(pcm); string(received) != string(want) {
+			t.Errorf("Received data = %v, want %v", received, want)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive audio data from binary frame")
+	}
+
+	// JSON audio keeps working on the same connection
+	if err := conn.WriteJSON(Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(pcm)}); err != nil {
+		t.Fatalf("Failed to send JSON audio: %v", err)
+	}
+
+	select {
+	case received := <-server.GetAudioChannel():
+		if want := stereo(pcm); string(received) != string(want) {
+			t.Errorf("Received data = %v, want %v", received, want)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive audio data from JSON message")
+	}
+}
+
+func TestServer_ConvertsDeclaredFormat(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	if err := conn.WriteJSON(Message{Type: "handshake", SampleRate: 48000, Channels: 2, SampleFormat: "f32"}); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	var ack HandshakeAck
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("Failed to read handshake ack: %v", err)
+	}
+	if ack.SampleRate != 48000 || ack.Channels != 2 || ack.SampleFormat != "f32" || ack.Encoding != "json" {
+		t.Fatalf("handshake ack = %+v, want 48000Hz stereo f32 over json", ack)
+	}
+
+	// Stereo float samples become the stereo int16 output
+	stereo := audio.EncodeFloat32([]float32{0.5, 0.5, -0.25, -0.75}, audio.EncodingFloat32)
+	if err := conn.WriteJSON(Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(stereo)}); err != nil {
+		t.Fatalf("Failed to send audio: %v", err)
+	}
+
+	select {
+	case received := <-server.GetAudioChannel():
+		got := audio.BytesToInt16(received)
+		want := []int16{16384, 16384, -8192, -24576}
+		if len(got) != len(want) || got[0] != want[0] || got[1] != want[1] || got[2] != want[2] || got[3] != want[3] {
+			t.Errorf("Received samples = %v, want %v", got, want)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive converted audio")
+	}
+}
+
+func TestServer_ResamplesPerMessageFormat(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	// 10ms of mono at 24kHz becomes 10ms of stereo at 48kHz
+	pcm := make([]byte, 240*2)
+	msg := Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(pcm), SampleRate: 24000}
+	if err := conn.WriteJSON(msg); err != nil {
+		t.Fatalf("Failed to send audio: %v", err)
+	}
+
+	select {
+	case received := <-server.GetAudioChannel():
+		if len(received) != 480*2*2 {
+			t.Errorf("Received %d bytes, want %d", len(received), 480*2*2)
+		}
+	case <-time.After(1 * time.Second):
+		t.Error("Did not receive resampled audio")
+	}
+}
+
+func TestServer_ConcealsSequenceGaps(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	pcm := []byte{0x00, 0x40, 0x00, 0x40}
+	for _, seq := range []uint32{1, 4, 2} {
+		seq := seq
+		msg := Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(pcm), Sequence: &seq}
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("Failed to send audio: %v", err)
+		}
+	}
+
+	// The first chunk, two concealed chunks for 2 and 3, then chunk 4;
+	// the late chunk 2 is dropped
+	for i := 0; i < 4; i++ {
+		select {
+		case received := <-server.GetAudioChannel():
+			if len(received) != len(pcm)*2 {
+				t.Errorf("chunk %d has %d bytes, want %d", i, len(received), len(pcm)*2)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("Did not receive chunk %d", i)
+		}
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for server.GetIngestStats().Late == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	want := audio.IngestStats{Received: 2, Lost: 2, Late: 1, Concealed: 2}
+	if stats := server.GetIngestStats(); stats != want {
+		t.Errorf("GetIngestStats() = %+v, want %+v", stats, want)
+	}
+}
+
+func TestServer_OpusPassthrough(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	// Opus frames are ignored until the encoding is negotiated
+	packet := []byte{0xfc, 0xff, 0xfe}
+	early := EncodeBinaryFrame(BinaryFrame{Format: SampleFormatOpus, Sequence: 1, PCM: packet})
+	if err := conn.WriteMessage(websocket.BinaryMessage, early); err != nil {
+		t.Fatalf("Failed to send Opus frame: %v", err)
+	}
+
+	if err := conn.WriteJSON(Message{Type: "handshake", Encoding: "opus"}); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	var ack HandshakeAck
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("Failed to read handshake ack: %v", err)
+	}
+	if ack.Encoding != "opus" {
+		t.Fatalf("handshake ack = %+v, want opus encoding", ack)
+	}
+
+	frame := EncodeBinaryFrame(BinaryFrame{Format: SampleFormatOpus, Sequence: 5, PCM: packet})
+	if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+		t.Fatalf("Failed to send Opus frame: %v", err)
+	}
+
+	select {
+	case received := <-server.GetOpusChannel():
+		if string(received) != string(packet) {
+			t.Errorf("Received packet = %v, want %v", received, packet)
+		}
+	case <-time.After(1 * time.Second):
+		t.Fatal("Did not receive Opus packet")
+	}
+
+	select {
+	case <-server.GetAudioChannel():
+		t.Error("Opus packets should not reach the PCM audio channel")
+	case <-server.GetOpusChannel():
+		t.Error("Opus frame sent before negotiation should be dropped")
+	default:
+	}
+}
+
+func TestServer_MixingRefusesOpus(t *testing.T) {
+	server := newStartedServer()
+	server.SetMixClients(true)
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+	if err := conn.WriteJSON(Message{Type: "handshake", Encoding: "opus"}); err != nil {
+		t.Fatalf("Failed to send handshake: %v", err)
+	}
+
+	// Opus cannot be mixed, so the client is asked for PCM
+	var ack HandshakeAck
+	if err := conn.ReadJSON(&ack); err != nil {
+		t.Fatalf("Failed to read handshake ack: %v", err)
+	}
+	if ack.Encoding != "binary" {
+		t.Fatalf("handshake ack = %+v, want binary encoding", ack)
+	}
+
+	frame := EncodeBinaryFrame(BinaryFrame{Format: SampleFormatOpus, Sequence: 1, PCM: []byte{0xfc, 0xff, 0xfe}})
+	if err := conn.WriteMessage(websocket.BinaryMessage, frame); err != nil {
+		t.Fatalf("Failed to send Opus frame: %v", err)
+	}
+	select {
+	case <-server.GetOpusChannel():
+		t.Error("Opus packets should not be forwarded while mixing")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestServer_MixesClients(t *testing.T) {
+	server := newStartedServer()
+	server.SetMixClients(true)
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	var conns []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect client %d: %v", i, err)
+		}
+		defer conn.Close()
+
+		var handshake Message
+		if err := conn.ReadJSON(&handshake); err != nil {
+			t.Fatalf("Failed to read handshake: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	inputs := server.GetMixerInputs()
+	if len(inputs) != 2 {
+		t.Fatalf("GetMixerInputs() = %+v, want one input per client", inputs)
+	}
+	if err := server.SetInputGain(inputs[1].ID, 0.5); err != nil {
+		t.Fatalf("SetInputGain() error = %v", err)
+	}
+
+	send := func(conn *websocket.Conn, value byte) {
+		pcm := []byte{0x00, value, 0x00, value}
+		if err := conn.WriteJSON(Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(pcm)}); err != nil {
+			t.Fatalf("Failed to send audio: %v", err)
+		}
+	}
+	receive := func() []byte {
+		select {
+		case received := <-server.GetAudioChannel():
+			return received
+		case <-time.After(time.Second):
+			t.Fatal("Did not receive audio data from channel")
+			return nil
+		}
+	}
+
+	// The first client plays alone until the second one sends
+	send(conns[0], 0x10)
+	if received := receive(); received[1] != 0x10 {
+		t.Fatalf("Received data = %v, want the first client's audio", received)
+	}
+	send(conns[1], 0x20)
+	time.Sleep(20 * time.Millisecond)
+	send(conns[0], 0x10)
+
+	// 0x1000 plus half of 0x2000
+	want := stereo([]byte{0x00, 0x20, 0x00, 0x20})
+	if received := receive(); string(received) != string(want) {
+		t.Errorf("Received data = %v, want %v", received, want)
+	}
+}
+
+func TestServer_ArbitratesClients(t *testing.T) {
+	server := newStartedServer()
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	var conns []*websocket.Conn
+	for i := 0; i < 2; i++ {
+		conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+		if err != nil {
+			t.Fatalf("Failed to connect client %d: %v", i, err)
+		}
+		defer conn.Close()
+
+		var handshake Message
+		if err := conn.ReadJSON(&handshake); err != nil {
+			t.Fatalf("Failed to read handshake: %v", err)
+		}
+		conns = append(conns, conn)
+	}
+
+	send := func(conn *websocket.Conn, value byte) {
+		pcm := []byte{0x00, value}
+		if err := conn.WriteJSON(Message{Type: "audio", Audio: base64.StdEncoding.EncodeToString(pcm)}); err != nil {
+			t.Fatalf("Failed to send audio: %v", err)
+		}
+	}
+	expectMessage := func(conn *websocket.Conn, messageType string) {
+		conn.SetReadDeadline(time.Now().Add(time.Second))
+		var msg SourceBusy
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("Failed to read %s message: %v", messageType, err)
+		}
+		if msg.Type != messageType {
+			t.Fatalf("message = %+v, want %s", msg, messageType)
+		}
+	}
+	expectAudio := func(value byte) {
+		select {
+		case received := <-server.GetAudioChannel():
+			if received[1] != value {
+				t.Fatalf("Received data = %v, want audio from the client sending %#x", received, value)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("Did not receive audio data from channel")
+		}
+	}
+
+	// The first client to send audio goes live; the second has to wait
+	send(conns[0], 0x10)
+	expectMessage(conns[0], "sourceActive")
+	expectAudio(0x10)
+	send(conns[1], 0x20)
+	expectMessage(conns[1], "sourceBusy")
+
+	clients := server.GetClients()
+	if len(clients) != 2 || !clients[0].Live || clients[1].Live || !clients[1].Waiting {
+		t.Fatalf("GetClients() = %+v, want the first client live and the second waiting", clients)
+	}
+
+	// Taking over swaps them
+	if err := conns[1].WriteJSON(Message{Type: "takeover"}); err != nil {
+		t.Fatalf("Failed to send takeover: %v", err)
+	}
+	expectMessage(conns[1], "sourceActive")
+	expectMessage(conns[0], "sourceBusy")
+	send(conns[1], 0x20)
+	expectAudio(0x20)
+
+	select {
+	case received := <-server.GetAudioChannel():
+		t.Fatalf("Received %v, want nothing while the first client waits", received)
+	default:
+	}
+
+	// Once the live client leaves, the other one can go live again
+	conns[1].Close()
+	deadline := time.Now().Add(time.Second)
+	for len(server.GetClients()) != 1 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	send(conns[0], 0x10)
+	expectMessage(conns[0], "sourceActive")
+	expectAudio(0x10)
+
+	if err := server.TakeOver("9"); err == nil {
+		t.Error("TakeOver() should reject an unknown client")
+	}
+}
+
+func TestServer_ForwardsPauseAndResume(t *testing.T) {
+	server := newStartedServer()
+	pauses := make(chan bool, 10)
+	server.SetPauseListener(func(paused bool) { pauses <- paused })
+
+	testServer := httptest.NewServer(http.HandlerFunc(server.HandleWebSocket))
+	defer testServer.Close()
+
+	url := "ws" + strings.TrimPrefix(testServer.URL, "http")
+	conn, _, err := websocket.DefaultDialer.Dial(url, nil)
+	if err != nil {
+		t.Fatalf("Failed to connect: %v", err)
+	}
+	defer conn.Close()
+
+	var handshake Message
+	if err := conn.ReadJSON(&handshake); err != nil {
+		t.Fatalf("Failed to read handshake: %v", err)
+	}
+
+	expect := func(want bool) {
+		t.Helper()
+		select {
+		case paused := <-pauses:
+			if paused != want {
+				t.Errorf("pause listener got %v, want %v", paused, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("pause listener was not called with %v", want)
+		}
+	}
+	send := func(messageType string) {
+		t.Helper()
+		if err := conn.WriteJSON(Message{Type: messageType}); err != nil {
+			t.Fatalf("Failed to send %s: %v", messageType, err)
+		}
+	}
+
+	send("streamPause")
+	expect(true)
+	send("streamResume")
+	expect(false)
+
+	// Switching away from the extension ends the pause
+	send("streamPause")
+	expect(true)
+	server.Source().Stop()
+	expect(false)
+
+	// and pauses do not count while another source is playing
+	send("streamPause")
+	send("status")
+	var status StatusResponse
+	if err := conn.ReadJSON(&status); err != nil {
+		t.Fatalf("Failed to read status: %v", err)
+	}
+	select {
+	case paused := <-pauses:
+		t.Errorf("pause listener got %v while the extension was not the source", paused)
+	default:
+	}
+}
@@ -0,0 +1,64 @@
+package websocket
+
+import (
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+// ExtensionSource exposes audio from Chrome extension clients as an audio
+// source, including Opus packets from clients that send them. It starts
+// stopped; while stopped, the server keeps its connections but discards
+// their audio.
+type ExtensionSource struct {
+	server *Server
+}
+
+func (s *Server) Source() *ExtensionSource {
+	return &ExtensionSource{server: s}
+}
+
+func (e *ExtensionSource) Name() string {
+	return constants.SourceExtension
+}
+
+func (e *ExtensionSource) Start() error {
+	e.server.streamingMutex.Lock()
+	defer e.server.streamingMutex.Unlock()
+	e.server.sourceStopped = false
+	return nil
+}
+
+func (e *ExtensionSource) Stop() error {
+	e.server.streamingMutex.Lock()
+	e.server.sourceStopped = true
+	e.server.streamingMutex.Unlock()
+
+	// Discard what was queued so it does not play when switching back
+	drain(e.server.audioBuffer)
+	drain(e.server.opusBuffer)
+	// A paused player must not keep the next source paused
+	e.server.setPaused(false)
+	return nil
+}
+
+func drain(buffer chan []byte) {
+	for {
+		select {
+		case <-buffer:
+		default:
+			return
+		}
+	}
+}
+
+func (e *ExtensionSource) Format() audio.Format {
+	return audio.PipelineFormat(e.server.getOutputChannels())
+}
+
+func (e *ExtensionSource) Frames() <-chan []byte {
+	return e.server.audioBuffer
+}
+
+func (e *ExtensionSource) Packets() <-chan []byte {
+	return e.server.opusBuffer
+}
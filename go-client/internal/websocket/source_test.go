@@ -0,0 +1,62 @@
+package websocket
+
+import (
+	"testing"
+
+	"trunecord/internal/audio"
+	"trunecord/internal/constants"
+)
+
+func TestExtensionSourceDiscardsAudioWhileStopped(t *testing.T) {
+	server := NewServer()
+	src := server.Source()
+
+	if src.Format() != audio.PipelineFormat(constants.DefaultChannels) {
+		t.Errorf("Format() = %s, want the pipeline format", src.Format())
+	}
+
+	// Nothing plays until the source is selected
+	client := &clientState{id: "1"}
+	server.live = client
+	server.pushAudio(client, []byte{1, 0})
+	server.queueOpus(client, []byte{31 << 3, 0xff}, 1)
+	expectNothing(t, src)
+
+	if err := src.Start(); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+	server.pushAudio(client, []byte{3, 0})
+	server.queueOpus(client, []byte{31 << 3, 0xff}, 2)
+
+	select {
+	case frame := <-src.Frames():
+		if frame[0] != 3 {
+			t.Errorf("received %v, want audio queued after Start()", frame)
+		}
+	default:
+		t.Fatal("no audio after Start()")
+	}
+	select {
+	case <-src.Packets():
+	default:
+		t.Fatal("no Opus packet after Start()")
+	}
+
+	if err := src.Stop(); err != nil {
+		t.Fatalf("Stop() error = %v", err)
+	}
+	server.pushAudio(client, []byte{2, 0})
+	server.queueOpus(client, []byte{31 << 3, 0xff}, 3)
+	expectNothing(t, src)
+}
+
+func expectNothing(t *testing.T, src *ExtensionSource) {
+	t.Helper()
+	select {
+	case frame := <-src.Frames():
+		t.Fatalf("received %v from a stopped source", frame)
+	case packet := <-src.Packets():
+		t.Fatalf("received packet %v from a stopped source", packet)
+	default:
+	}
+}